@@ -0,0 +1,51 @@
+package pixfont
+
+// SetAliases sets a table of runes to draw with the glyph of another rune when
+// they have no glyph of their own, such as the curly quotes and dashes in text
+// copied from word processors, which would otherwise be left out. Aliases are
+// not followed further, so an alias for a rune which is itself an alias has no
+// effect. Runes drawn by an alias count as having a glyph for DrawRune and
+// HasGlyph. A nil table, the default, removes all aliases.
+//
+//	f.SetAliases(pixfont.TypographicAliases())
+func (p *PixFont) SetAliases(aliases map[rune]rune) {
+	p.aliases = nil
+	if len(aliases) > 0 {
+		p.aliases = make(map[rune]rune, len(aliases))
+		for r, a := range aliases {
+			p.aliases[r] = a
+		}
+	}
+	p.cache.reset()
+}
+
+// TypographicAliases returns a table for SetAliases which maps typographic
+// quotes, primes, dashes, minus signs and spaces to their ASCII equivalents.
+func TypographicAliases() map[rune]rune {
+	return map[rune]rune{
+		'\u2018': '\'', // left single quotation mark
+		'\u2019': '\'', // right single quotation mark
+		'\u201a': '\'', // single low-9 quotation mark
+		'\u201b': '\'', // single high-reversed-9 quotation mark
+		'\u2032': '\'', // prime
+		'\u201c': '"',  // left double quotation mark
+		'\u201d': '"',  // right double quotation mark
+		'\u201e': '"',  // double low-9 quotation mark
+		'\u201f': '"',  // double high-reversed-9 quotation mark
+		'\u2033': '"',  // double prime
+		'\u2039': '<',  // single left-pointing angle quotation mark
+		'\u203a': '>',  // single right-pointing angle quotation mark
+		'\u2010': '-',  // hyphen
+		'\u2011': '-',  // non-breaking hyphen
+		'\u2012': '-',  // figure dash
+		'\u2013': '-',  // en dash
+		'\u2014': '-',  // em dash
+		'\u2015': '-',  // horizontal bar
+		'\u2212': '-',  // minus sign
+		'\u00a0': ' ',  // no-break space
+		'\u2002': ' ',  // en space
+		'\u2003': ' ',  // em space
+		'\u2009': ' ',  // thin space
+		'\u202f': ' ',  // narrow no-break space
+	}
+}
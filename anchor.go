@@ -0,0 +1,90 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// Anchor names a point of a box, such as its top left corner or its center, used
+// to position text relative to a point or within an image.
+type Anchor int
+
+// Anchors for each corner, the middle of each edge, and the center of a box.
+const (
+	TopLeft Anchor = iota
+	TopCenter
+	TopRight
+	CenterLeft
+	Center
+	CenterRight
+	BottomLeft
+	BottomCenter
+	BottomRight
+
+	// The baseline anchors are on the font's baseline, below the capital
+	// letters and digits but above any descenders. For boxes without a
+	// baseline they are the same as the bottom anchors.
+	BaselineLeft
+	BaselineCenter
+	BaselineRight
+)
+
+// Place returns the top-left corner of a box of the given size positioned
+// inside r at the anchor, at least margin pixels from the edges of r that the
+// anchor refers to. For example, BottomRight places the box in the bottom right
+// corner of r.
+func (a Anchor) Place(r image.Rectangle, size image.Point, margin int) image.Point {
+	var pt image.Point
+	switch a % 3 {
+	case 0:
+		pt.X = r.Min.X + margin
+	case 1:
+		pt.X = r.Min.X + (r.Dx()-size.X)/2
+	case 2:
+		pt.X = r.Max.X - margin - size.X
+	}
+	switch a / 3 {
+	case 0:
+		pt.Y = r.Min.Y + margin
+	case 1:
+		pt.Y = r.Min.Y + (r.Dy()-size.Y)/2
+	default:
+		pt.Y = r.Max.Y - margin - size.Y
+	}
+	return pt
+}
+
+// DrawStringAnchored draws s like DrawString, positioned so that the anchor
+// point of the string's box is at x,y. For example, Center centers the string
+// on x,y, and BaselineLeft starts it at x with its baseline on y. The box
+// doesn't include the spacing after the last character, so right anchored text
+// ends at x. It returns the top-left corner of the string's box.
+func (p *PixFont) DrawStringAnchored(dr Drawable, x, y int, s string, clr color.Color, anchor Anchor) image.Point {
+	size := image.Pt(p.boxWidth(s, p.Spacing()), int(p.charHeight))
+	pt := anchor.Place(image.Rectangle{Min: image.Pt(x, y), Max: image.Pt(x, y)}, size, 0)
+	if anchor >= BaselineLeft {
+		pt.Y = y - p.baseline()
+	}
+	p.DrawString(dr, pt.X, pt.Y, s, clr)
+	return pt
+}
+
+// baseline returns the row below the bottom of the font's capital letters or
+// digits, or the font height if it has neither.
+func (p *PixFont) baseline() int {
+	for _, c := range "HX0" {
+		if !p.has(c) {
+			continue
+		}
+		bottom := 0
+		p.drawRune(func(x, y int) {
+			if y >= bottom {
+				bottom = y + 1
+			}
+		}, 0, 0, c, p.Spacing())
+		if bottom > 0 {
+			return bottom
+		}
+	}
+	return int(p.charHeight)
+}
@@ -0,0 +1,336 @@
+package anim
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/fonts/pico"
+)
+
+// indexRows returns the palette indexes of the rows of m, with rows separated
+// by "|".
+func indexRows(m *image.Paletted) string {
+	var b []byte
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		if y > m.Rect.Min.Y {
+			b = append(b, '|')
+		}
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			b = append(b, '0'+m.ColorIndexAt(x, y))
+		}
+	}
+	return string(b)
+}
+
+func TestCaption(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	shared := color.Palette{color.Transparent, color.Black}
+	full := make(color.Palette, 256)
+	full[0] = color.Transparent
+	for i := 1; i < len(full); i++ {
+		full[i] = color.RGBA{0, uint8(i), 0, 0xff}
+	}
+	full[200] = color.RGBA{0xf0, 0x10, 0, 0xff}
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			image.NewPaletted(image.Rect(0, 0, 4, 5), shared),
+			image.NewPaletted(image.Rect(0, 0, 4, 5), shared),
+			image.NewPaletted(image.Rect(2, 3, 4, 5), shared),
+			image.NewPaletted(image.Rect(0, 0, 4, 5), full),
+			image.NewPaletted(image.Rect(0, 0, 4, 5), color.Palette{color.Black, red}),
+		},
+		Delay:    []int{1, 2, 3, 4, 5},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalPrevious, gif.DisposalNone, gif.DisposalNone},
+	}
+	Caption(g, pico.Font3x5, 0, 0, "I", red)
+
+	for i, want := range []struct {
+		idx  uint8
+		rows string
+	}{
+		{2, "2220|0200|0200|0200|2220"},
+		{2, "2220|0200|0200|0200|2220"},
+		{2, "00|20"}, // only where the frame overlaps the text
+		{200, ""},    // the nearest color, since the palette is full
+		{1, "1110|0100|0100|0100|1110"},
+	} {
+		frame := g.Image[i]
+		if want.rows != "" && indexRows(frame) != want.rows {
+			t.Errorf("frame %d is %s, want %s", i, indexRows(frame), want.rows)
+		}
+		if got := frame.ColorIndexAt(1, 2); got != want.idx && frame.Rect.Min.X == 0 {
+			t.Errorf("frame %d text has index %d, want %d", i, got, want.idx)
+		}
+	}
+	if len(shared) != 2 || len(g.Image[0].Palette) != 3 || g.Image[0].Palette[2] != red {
+		t.Errorf("shared palette is %v, frame palette is %v", shared, g.Image[0].Palette)
+	}
+	if len(full) != 256 {
+		t.Error("full palette was extended")
+	}
+	if g.Delay[4] != 5 || g.Disposal[1] != gif.DisposalBackground {
+		t.Error("frame timing or disposal changed")
+	}
+
+	// a transparent palette entry is never used to draw text
+	m := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Transparent})
+	if idx := paletteIndex(m, color.Transparent, 0); idx != 1 {
+		t.Errorf("transparent text has index %d, want a new entry", idx)
+	}
+}
+
+// digitFont returns a 3x3 font with a glyph for some digits, each of which is
+// a vertical bar in its own column.
+func digitFont() *pixfont.PixFont {
+	glyphs := map[rune]map[int]string{}
+	for c, ln := range map[rune]string{'0': "XXX", '1': "X  ", '2': "  X", '9': " X "} {
+		glyphs[c] = map[int]string{0: ln, 1: ln, 2: ln}
+	}
+	data, cm, _ := pixfont.Pack(3, 3, glyphs)
+	return pixfont.NewPixFont(3, 3, cm, data)
+}
+
+// alphaRows returns the rows of m with 'X' for opaque pixels, separated by "|".
+func alphaRows(m image.Image) string {
+	var rows []string
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		var row []byte
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := byte(' ')
+			if _, _, _, a := m.At(x, y).RGBA(); a != 0 {
+				c = 'X'
+			}
+			row = append(row, c)
+		}
+		rows = append(rows, string(row))
+	}
+	return strings.Join(rows, "|")
+}
+
+func TestOdometerFrames(t *testing.T) {
+	f := digitFont()
+	for _, tc := range []struct {
+		from, to string
+		steps    int
+		want     []string
+	}{
+		{"1", "2", 3, []string{"X  |X  |X  ", "X  |X  |  X", "X  |  X|  X", "  X|  X|  X"}},
+		{"2", "1", 3, []string{"  X|  X|  X", "X  |  X|  X", "X  |X  |  X", "X  |X  |X  "}},
+		{"1", "1", 0, []string{"X  |X  |X  ", "X  |X  |X  "}},
+		// the units roll through 0 to 1, two glyph heights in three steps
+		{"19", "21", 3, []string{
+			"X    X |X    X |X    X ",
+			"X    X |X   XXX|  X XXX",
+			"X   XXX|  X XXX|  X X  ",
+			"  X X  |  X X  |  X X  ",
+		}},
+		// aligned on the right, with the new digit rolling in from a blank
+		{"9", "10", 1, []string{"     X |     X |     X ", "X   XXX|X   XXX|X   XXX"}},
+	} {
+		frames := OdometerFrames(f, tc.from, tc.to, tc.steps, color.Black, color.Transparent)
+		var got []string
+		for _, m := range frames {
+			got = append(got, alphaRows(m))
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%s to %s in %d steps is\n%s\nwant\n%s", tc.from, tc.to, tc.steps,
+				strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+		}
+	}
+}
+
+func TestNumberLess(t *testing.T) {
+	for _, tc := range []struct {
+		s, t string
+		want bool
+	}{
+		{"999", "1,000", true},
+		{"1,000", "999", false},
+		{"007", "10", true},
+		{"12", "12", false},
+		{"", "1", true},
+	} {
+		if got := numberLess(tc.s, tc.t); got != tc.want {
+			t.Errorf("numberLess(%q, %q) is %v", tc.s, tc.t, got)
+		}
+	}
+}
+
+// pngChunk is a chunk of a PNG stream.
+type pngChunk struct {
+	name string
+	data []byte
+}
+
+// pngChunks splits a PNG stream into chunks, checking the signature and the
+// CRC of each chunk.
+func pngChunks(t *testing.T, b []byte) []pngChunk {
+	t.Helper()
+	if !bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")) {
+		t.Fatal("no PNG signature")
+	}
+	var chunks []pngChunk
+	for b = b[8:]; len(b) > 0; {
+		if len(b) < 12 {
+			t.Fatalf("truncated chunk header %q", b)
+		}
+		n := int(binary.BigEndian.Uint32(b))
+		if len(b) < 12+n {
+			t.Fatalf("truncated %s chunk", b[4:8])
+		}
+		c := pngChunk{string(b[4:8]), b[8 : 8+n]}
+		if got, want := binary.BigEndian.Uint32(b[8+n:]), crc32.ChecksumIEEE(b[4:8+n]); got != want {
+			t.Errorf("%s chunk has CRC %08x, want %08x", c.name, got, want)
+		}
+		chunks = append(chunks, c)
+		b = b[12+n:]
+	}
+	return chunks
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	first := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	first.Set(1, 0, red)
+	second := image.NewRGBA(image.Rect(5, 5, 8, 7)) // converted, with an offset
+	second.Set(7, 6, color.White)
+
+	var buf bytes.Buffer
+	if err := EncodeAPNG(&buf, []image.Image{first, second}, []int{5, 300}, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// viewers without APNG support see the first frame
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != first.Rect {
+		t.Fatalf("decoded image is %v, want %v", img.Bounds(), first.Rect)
+	}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			if got, want := color.NRGBAModel.Convert(img.At(x, y)), first.At(x, y); got != want {
+				t.Errorf("decoded pixel %d,%d is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	chunks := pngChunks(t, buf.Bytes())
+	var names []string
+	for _, c := range chunks {
+		names = append(names, c.name)
+	}
+	if got, want := strings.Join(names, " "), "IHDR acTL fcTL IDAT fcTL fdAT IEND"; got != want {
+		t.Fatalf("chunks are %s, want %s", got, want)
+	}
+	if actl := chunks[1].data; binary.BigEndian.Uint32(actl) != 2 || binary.BigEndian.Uint32(actl[4:]) != 2 {
+		t.Errorf("acTL has %d frames and %d loops, want 2 and 2", binary.BigEndian.Uint32(actl), binary.BigEndian.Uint32(actl[4:]))
+	}
+	for i, tc := range []struct {
+		chunk int
+		seq   uint32
+		delay uint16
+	}{{2, 0, 5}, {4, 1, 300}} {
+		fctl := chunks[tc.chunk].data
+		if got := binary.BigEndian.Uint32(fctl); got != tc.seq {
+			t.Errorf("frame %d: fcTL sequence number %d, want %d", i, got, tc.seq)
+		}
+		if w, h := binary.BigEndian.Uint32(fctl[4:]), binary.BigEndian.Uint32(fctl[8:]); w != 3 || h != 2 {
+			t.Errorf("frame %d: fcTL size %dx%d, want 3x2", i, w, h)
+		}
+		if num, den := binary.BigEndian.Uint16(fctl[20:]), binary.BigEndian.Uint16(fctl[22:]); num != tc.delay || den != 100 {
+			t.Errorf("frame %d: delay %d/%d, want %d/100", i, num, den, tc.delay)
+		}
+	}
+
+	fdat := chunks[5].data
+	if got := binary.BigEndian.Uint32(fdat); got != 2 {
+		t.Errorf("fdAT sequence number %d, want 2", got)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(fdat[4:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make([]byte, 2*(1+3*4))
+	copy(want[len(want)-4:], []byte{0xff, 0xff, 0xff, 0xff})
+	if !bytes.Equal(rows, want) {
+		t.Errorf("second frame rows are %v, want %v", rows, want)
+	}
+}
+
+func TestEncodeAPNGErrors(t *testing.T) {
+	small := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	large := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for name, tc := range map[string]struct {
+		frames []image.Image
+		delays []int
+	}{
+		"no frames":      {nil, nil},
+		"delays":         {[]image.Image{small, small}, []int{1}},
+		"sizes":          {[]image.Image{small, large}, []int{1, 1}},
+		"negative delay": {[]image.Image{small}, []int{-1}},
+		"long delay":     {[]image.Image{small}, []int{0x10000}},
+	} {
+		var buf bytes.Buffer
+		if err := EncodeAPNG(&buf, tc.frames, tc.delays, 0); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: wrote %d bytes before failing", name, buf.Len())
+		}
+	}
+}
+
+func TestTickerFrames(t *testing.T) {
+	bg := color.NRGBA{0, 0, 0xff, 0x80}
+	tk := pixfont.NewTicker(pixfont.Font8x8, 12, "Hi")
+	frames := TickerFrames(tk, color.White, bg)
+	if len(frames) != tk.Frames() {
+		t.Fatalf("got %d frames, want %d", len(frames), tk.Frames())
+	}
+	r := image.Rect(0, 0, 12, 8)
+	for _, n := range []int{0, 5, len(frames) - 1} {
+		if frames[n].Bounds() != r {
+			t.Fatalf("frame %d is %v, want %v", n, frames[n].Bounds(), r)
+		}
+		want := image.NewNRGBA(r)
+		draw.Draw(want, r, image.NewUniform(bg), image.Point{}, draw.Src)
+		tk.DrawFrame(want, 0, 0, n, color.White)
+		if !bytes.Equal(frames[n].(*image.NRGBA).Pix, want.Pix) {
+			t.Errorf("frame %d differs from DrawFrame over the background", n)
+		}
+	}
+	white := false
+	for _, f := range frames {
+		white = white || bytes.Contains(f.(*image.NRGBA).Pix, []byte{0xff, 0xff, 0xff, 0xff})
+	}
+	if !white {
+		t.Error("no frame shows the text")
+	}
+
+	var buf bytes.Buffer
+	delays := make([]int, len(frames))
+	if err := EncodeAPNG(&buf, frames, delays, 0); err != nil {
+		t.Fatal(err)
+	}
+	if chunks := pngChunks(t, buf.Bytes()); binary.BigEndian.Uint32(chunks[1].data) != uint32(len(frames)) {
+		t.Errorf("acTL frame count is %d, want %d", binary.BigEndian.Uint32(chunks[1].data), len(frames))
+	}
+}
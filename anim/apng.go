@@ -0,0 +1,134 @@
+package anim
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// EncodeAPNG writes frames to w as an animated PNG, an alternative to GIF that
+// keeps full color and alpha for use on the web. Browsers and viewers without
+// APNG support show the first frame. All frames must be the same size.
+//
+// As with gif.GIF, delays gives the delay after each frame in 100ths of a
+// second, and loopCount is the number of times to play the animation, with 0
+// meaning forever. Delays must be between 0 and 65535.
+func EncodeAPNG(w io.Writer, frames []image.Image, delays []int, loopCount int) error {
+	if len(frames) == 0 {
+		return errors.New("anim: no frames to encode")
+	}
+	if len(delays) != len(frames) {
+		return errors.New("anim: number of delays does not match number of frames")
+	}
+	size := frames[0].Bounds().Size()
+	for i, frame := range frames {
+		if frame.Bounds().Size() != size {
+			return errors.New("anim: frames are not all the same size")
+		}
+		if delays[i] < 0 || delays[i] > 0xffff {
+			return fmt.Errorf("anim: delay %d is outside 0-65535", delays[i])
+		}
+	}
+
+	enc := &apngWriter{w: w}
+	enc.write([]byte("\x89PNG\r\n\x1a\n"))
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(size.X))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(size.Y))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // truecolor with alpha
+	enc.chunk("IHDR", ihdr)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	binary.BigEndian.PutUint32(actl[4:], uint32(loopCount))
+	enc.chunk("acTL", actl)
+
+	seq := uint32(0)
+	for i, frame := range frames {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(size.X))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(size.Y))
+		// x and y offsets are 0
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delays[i]))
+		binary.BigEndian.PutUint16(fctl[22:], 100)
+		// dispose and blend ops are 0: none, and replace the frame region
+		enc.chunk("fcTL", fctl)
+		seq++
+
+		data, err := apngFrameData(frame)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			enc.chunk("IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, seq)
+			enc.chunk("fdAT", append(fdat, data...))
+			seq++
+		}
+	}
+	enc.chunk("IEND", nil)
+	return enc.err
+}
+
+// apngFrameData returns the zlib compressed, unfiltered rows of img as 8-bit
+// non-premultiplied RGBA.
+func apngFrameData(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	m, ok := img.(*image.NRGBA)
+	if !ok {
+		m = image.NewNRGBA(b)
+		draw.Draw(m, b, img, b.Min, draw.Src)
+	}
+
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	rowLen := 4 * b.Dx()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		zw.Write([]byte{0}) // filter type none
+		off := m.PixOffset(b.Min.X, y)
+		zw.Write(m.Pix[off : off+rowLen])
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// apngWriter writes PNG chunks, keeping the first error.
+type apngWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *apngWriter) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *apngWriter) chunk(name string, data []byte) {
+	hdr := make([]byte, 8)
+	binary.BigEndian.PutUint32(hdr, uint32(len(data)))
+	copy(hdr[4:], name)
+	crc := crc32.NewIEEE()
+	crc.Write(hdr[4:])
+	crc.Write(data)
+	e.write(hdr)
+	e.write(data)
+	binary.BigEndian.PutUint32(hdr, crc.Sum32())
+	e.write(hdr[:4])
+}
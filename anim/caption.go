@@ -0,0 +1,86 @@
+// Package anim draws pixel font text into animations, such as captioning an
+// existing animated GIF.
+package anim
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Caption draws s at x,y onto every frame of g, for the common "caption this GIF"
+// use. Frames are changed in place, so their timing and disposal are kept.
+//
+// Each frame's palette is extended with clr if it is not already present and
+// the palette has room, and otherwise the nearest opaque palette color is used.
+// Frames which only cover part of the image are drawn on where they overlap
+// the text.
+func Caption(g *gif.GIF, f *pixfont.PixFont, x, y int, s string, clr color.Color) {
+	for _, frame := range g.Image {
+		idx := paletteIndex(frame, clr, transparentIndex(frame))
+		f.DrawString(&indexDrawable{frame, idx}, x, y, s, clr)
+	}
+}
+
+// transparentIndex returns the index of the first fully transparent color in
+// the frame's palette, or -1.
+func transparentIndex(frame *image.Paletted) int {
+	for i, c := range frame.Palette {
+		if _, _, _, a := c.RGBA(); a == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// paletteIndex returns the index to draw clr with in frame, adding it to the
+// palette if it is missing and there is room.
+func paletteIndex(frame *image.Paletted, clr color.Color, transparent int) uint8 {
+	want := color.RGBA64Model.Convert(clr)
+	for i, c := range frame.Palette {
+		if color.RGBA64Model.Convert(c) == want && i != transparent {
+			return uint8(i)
+		}
+	}
+	if len(frame.Palette) < 256 {
+		// copy, since frames often share a palette
+		p := make(color.Palette, len(frame.Palette), len(frame.Palette)+1)
+		copy(p, frame.Palette)
+		frame.Palette = append(p, clr)
+		return uint8(len(frame.Palette) - 1)
+	}
+
+	// find the nearest color, never choosing the transparent one
+	best, bestDist := 0, uint64(1<<64-1)
+	cr, cg, cb, _ := clr.RGBA()
+	for i, c := range frame.Palette {
+		if i == transparent {
+			continue
+		}
+		r, g, b, _ := c.RGBA()
+		d := sqDiff(cr, r) + sqDiff(cg, g) + sqDiff(cb, b)
+		if d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return uint8(best)
+}
+
+func sqDiff(a, b uint32) uint64 {
+	d := int64(a) - int64(b)
+	return uint64(d * d)
+}
+
+// indexDrawable sets pixels of a paletted frame to a fixed palette index.
+type indexDrawable struct {
+	frame *image.Paletted
+	idx   uint8
+}
+
+func (d *indexDrawable) Set(x, y int, c color.Color) {
+	if image.Pt(x, y).In(d.frame.Rect) {
+		d.frame.Pix[d.frame.PixOffset(x, y)] = d.idx
+	}
+}
@@ -0,0 +1,135 @@
+package anim
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// OdometerFrames renders the change from one number to another as digits rolling
+// vertically, like a mechanical counter, for dashboards and counters encoded
+// with EncodeAPNG or as the frames of a GIF. It returns steps+1 frames, the
+// first showing from and the last showing to, filled with bg (which may be
+// transparent) and with the text drawn in clr.
+//
+// The strings are aligned on the right, so that units line up, and each
+// character is drawn in a cell as wide as the widest digit. Digits which change
+// roll through the digits between, upwards if to is the larger number and
+// downwards otherwise, all arriving together on the last frame. Other
+// characters, such as separators, roll directly to their new value.
+func OdometerFrames(f *pixfont.PixFont, from, to string, steps int, clr, bg color.Color) []image.Image {
+	if steps < 1 {
+		steps = 1
+	}
+	a, b := []rune(from), []rune(to)
+	for len(a) < len(b) {
+		a = append([]rune{' '}, a...)
+	}
+	for len(b) < len(a) {
+		b = append([]rune{' '}, b...)
+	}
+	up := !numberLess(to, from)
+	sp := f.Spacing()
+
+	cell := 0
+	masks := make(map[rune]*image.Alpha)
+	advances := make(map[rune]int)
+	for _, c := range "0123456789" + string(a) + string(b) {
+		if _, ok := masks[c]; ok {
+			continue
+		}
+		masks[c] = f.GlyphMask(c)
+		_, advances[c] = f.MeasureRune(c)
+		if advances[c] > cell {
+			cell = advances[c]
+		}
+	}
+	cell += sp
+	h := f.GetHeight()
+
+	wheels := make([][]rune, len(a))
+	for i := range a {
+		wheels[i] = wheel(a[i], b[i], up)
+	}
+
+	src := image.NewUniform(clr)
+	r := image.Rect(0, 0, len(a)*cell-sp, h)
+	// center narrow characters in their cells
+	glyph := func(m draw.Image, box image.Rectangle, c rune, dy int) {
+		if masks[c] == nil {
+			return
+		}
+		dx := (cell - sp - advances[c]) / 2
+		draw.DrawMask(m, box, src, image.Point{}, masks[c], image.Pt(-dx, -dy), draw.Over)
+	}
+	frames := make([]image.Image, steps+1)
+	for n := range frames {
+		m := image.NewNRGBA(r)
+		draw.Draw(m, r, image.NewUniform(bg), image.Point{}, draw.Src)
+		for i, w := range wheels {
+			// pixels rolled so far, over all the glyphs of the wheel
+			rolled := n * (len(w) - 1) * h / steps
+			k, sub := rolled/h, rolled%h
+			if !up {
+				sub = -sub
+			}
+			box := image.Rect(i*cell, 0, (i+1)*cell, h)
+			glyph(m, box, w[k], -sub)
+			if sub != 0 {
+				next := h - sub
+				if !up {
+					next = -h - sub
+				}
+				glyph(m, box, w[k+1], next)
+			}
+		}
+		frames[n] = m
+	}
+	return frames
+}
+
+// wheel returns the glyphs a counter wheel shows when rolling from a to b.
+func wheel(a, b rune, up bool) []rune {
+	w := []rune{a}
+	if a == b {
+		return w
+	}
+	if !isDigit(a) || !isDigit(b) {
+		return append(w, b)
+	}
+	step := rune(1)
+	if !up {
+		step = 9
+	}
+	for c := a; c != b; {
+		c = '0' + (c-'0'+step)%10
+		w = append(w, c)
+	}
+	return w
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// numberLess returns true if the digits of s make a smaller number than the
+// digits of t.
+func numberLess(s, t string) bool {
+	digits := func(s string) string {
+		s = strings.Map(func(c rune) rune {
+			if isDigit(c) {
+				return c
+			}
+			return -1
+		}, s)
+		return strings.TrimLeft(s, "0")
+	}
+	s, t = digits(s), digits(t)
+	if len(s) != len(t) {
+		return len(s) < len(t)
+	}
+	return s < t
+}
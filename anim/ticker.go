@@ -0,0 +1,25 @@
+package anim
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// TickerFrames renders one complete scrolling cycle of t, for encoding with
+// EncodeAPNG or as the frames of a GIF. Each frame is t.Width pixels wide and
+// the height of the font, filled with bg (which may be transparent) and with
+// the text drawn in clr.
+func TickerFrames(t *pixfont.Ticker, clr, bg color.Color) []image.Image {
+	r := image.Rect(0, 0, t.Width, t.Font.GetHeight())
+	frames := make([]image.Image, t.Frames())
+	for n := range frames {
+		m := image.NewNRGBA(r)
+		draw.Draw(m, r, image.NewUniform(bg), image.Point{}, draw.Src)
+		t.DrawFrame(m, 0, 0, n, clr)
+		frames[n] = m
+	}
+	return frames
+}
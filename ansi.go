@@ -0,0 +1,180 @@
+package pixfont
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// SetANSI sets whether text written to the screen is scanned for ANSI escape
+// sequences, such as the color codes in the output of command line tools, so
+// that it can be rendered as it looks in a terminal. Select Graphic Rendition
+// sequences ("\x1b[...m") set the colors of the text written after them:
+//
+//   - 0 resets to the default colors;
+//   - 1 is bold, which brightens the 8 basic foreground colors, and 22 undoes it;
+//   - 7 swaps the foreground and background colors, and 27 undoes it;
+//   - 30-37 and 90-97 set the foreground to a basic or bright color, and 39 to
+//     the default;
+//   - 40-47 and 100-107 set the background likewise, and 49 to the default;
+//   - 38;5;n and 48;5;n set the foreground or background to color n of the
+//     xterm 256 color palette, and 38;2;r;g;b and 48;2;r;g;b to any color.
+//
+// Other escape sequences, such as those which move the cursor, and control
+// strings, such as those which set the window title, are removed without
+// effect.
+func (s *Screen) SetANSI(on bool) {
+	s.ansi = on
+	s.esc = s.esc[:0]
+}
+
+// escape adds c to the escape sequence being read, and carries out the
+// sequence once it is complete.
+func (s *Screen) escape(c rune) {
+	if len(s.esc) >= 2 && strings.IndexByte(controlStrings, s.esc[1]) >= 0 {
+		// only the end of a control string matters: BEL, or ESC \
+		switch {
+		case c == 0x07, c == '\\' && s.esc[len(s.esc)-1] == 0x1b:
+			s.esc = s.esc[:0]
+		case c == 0x1b:
+			s.esc = append(s.esc[:2], 0x1b)
+		default:
+			s.esc = s.esc[:2]
+		}
+		return
+	}
+	if c >= 0x80 {
+		// not part of any escape sequence, so drop what was read
+		s.esc = s.esc[:0]
+		s.put(c)
+		return
+	}
+	s.esc = append(s.esc, byte(c))
+	if len(s.esc) < 2 {
+		return
+	}
+	if s.esc[1] != '[' {
+		if strings.IndexByte(controlStrings, s.esc[1]) < 0 {
+			// a two character escape, such as ESC 7
+			s.esc = s.esc[:0]
+		}
+		return
+	}
+	// a control sequence ends with a byte from '@' to '~'
+	if len(s.esc) > 2 && c >= '@' && c <= '~' {
+		if c == 'm' {
+			s.sgr(string(s.esc[2 : len(s.esc)-1]))
+		}
+		s.esc = s.esc[:0]
+	}
+}
+
+// controlStrings are the characters after ESC which start a control string:
+// OSC, DCS, APC, PM and SOS.
+const controlStrings = "]P_^X"
+
+// sgr carries out a Select Graphic Rendition sequence with the given
+// parameters.
+func (s *Screen) sgr(params string) {
+	ps := strings.Split(params, ";")
+	arg := func(i int) int {
+		if i >= len(ps) {
+			return 0
+		}
+		n, _ := strconv.Atoi(ps[i])
+		return n
+	}
+	for i := 0; i < len(ps); i++ {
+		switch n := arg(i); {
+		case n == 0:
+			s.pen, s.basicFG, s.bold, s.inverse = Cell{}, 0, false, false
+		case n == 1:
+			s.bold = true
+		case n == 22:
+			s.bold = false
+		case n == 7:
+			s.inverse = true
+		case n == 27:
+			s.inverse = false
+		case n >= 30 && n <= 37:
+			s.pen.FG, s.basicFG = ansiColor(n-30), n
+		case n >= 90 && n <= 97:
+			s.pen.FG, s.basicFG = ansiColor(n-90+8), 0
+		case n == 39:
+			s.pen.FG, s.basicFG = nil, 0
+		case n >= 40 && n <= 47:
+			s.pen.BG = ansiColor(n - 40)
+		case n >= 100 && n <= 107:
+			s.pen.BG = ansiColor(n - 100 + 8)
+		case n == 49:
+			s.pen.BG = nil
+		case n == 38 || n == 48:
+			var clr color.Color
+			switch arg(i + 1) {
+			case 5:
+				clr = ansiColor(arg(i + 2))
+				i += 2
+			case 2:
+				clr = color.RGBA{uint8(arg(i + 2)), uint8(arg(i + 3)), uint8(arg(i + 4)), 255}
+				i += 4
+			default:
+				continue
+			}
+			if n == 38 {
+				s.pen.FG, s.basicFG = clr, 0
+			} else {
+				s.pen.BG = clr
+			}
+		}
+	}
+}
+
+// ansiColors are the 16 basic and bright colors of xterm.
+var ansiColors = [16]color.RGBA{
+	{0, 0, 0, 255}, {205, 0, 0, 255}, {0, 205, 0, 255}, {205, 205, 0, 255},
+	{0, 0, 238, 255}, {205, 0, 205, 255}, {0, 205, 205, 255}, {229, 229, 229, 255},
+	{127, 127, 127, 255}, {255, 0, 0, 255}, {0, 255, 0, 255}, {255, 255, 0, 255},
+	{92, 92, 255, 255}, {255, 0, 255, 255}, {0, 255, 255, 255}, {255, 255, 255, 255},
+}
+
+// ansiColor returns color n of the xterm 256 color palette: the 16 basic and
+// bright colors, a 6x6x6 color cube, and 24 shades of gray.
+func ansiColor(n int) color.Color {
+	switch {
+	case n < 0 || n > 255:
+		return nil
+	case n < 16:
+		return ansiColors[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) uint8 {
+			if v == 0 {
+				return 0
+			}
+			return uint8(55 + 40*v)
+		}
+		return color.RGBA{level(n / 36), level(n / 6 % 6), level(n % 6), 255}
+	}
+	g := uint8(8 + 10*(n-232))
+	return color.RGBA{g, g, g, 255}
+}
+
+// penCell returns a cell for c in the colors of written text, with bold and
+// inverse applied.
+func (s *Screen) penCell(c rune) Cell {
+	cell := s.pen
+	cell.Rune = c
+	if s.bold && s.basicFG != 0 {
+		cell.FG = ansiColor(s.basicFG - 30 + 8)
+	}
+	if s.inverse {
+		if cell.FG == nil {
+			cell.FG = s.fg
+		}
+		if cell.BG == nil {
+			cell.BG = s.bg
+		}
+		cell.FG, cell.BG = cell.BG, cell.FG
+	}
+	return cell
+}
@@ -0,0 +1,121 @@
+package pixfont
+
+// Arabic joining types of the letters U+0621 to U+064A: 'D' for letters which
+// join on both sides, 'R' for letters which only join to the preceding letter,
+// 'U' for letters which don't join, 'C' for the tatweel, which joins on both
+// sides but has no presentation forms, and ' ' for unassigned code points.
+const arabicJoining = "URRRRDRDRDDDDDRRRRDDDDDDDD     CDDDDDDDRRD"
+
+// arabicForms maps each Arabic letter with presentation forms to the isolated,
+// final, initial and medial forms in the Arabic Presentation Forms-B block. Right
+// joining letters have only isolated and final forms.
+var arabicForms = func() map[rune][4]rune {
+	m := make(map[rune][4]rune)
+	next := rune(0xfe80)
+	for i, j := range arabicJoining {
+		c := 0x0621 + rune(i)
+		switch j {
+		case 'U':
+			m[c] = [4]rune{next, 0, 0, 0}
+			next++
+		case 'R':
+			m[c] = [4]rune{next, next + 1, 0, 0}
+			next += 2
+		case 'D':
+			m[c] = [4]rune{next, next + 1, next + 2, next + 3}
+			next += 4
+		}
+	}
+	return m
+}()
+
+// lamAlef maps the alef variants which form a mandatory ligature after lam to
+// the isolated form of the ligature. The final form follows it.
+var lamAlef = map[rune]rune{
+	0x0622: 0xfef5,
+	0x0623: 0xfef7,
+	0x0625: 0xfef9,
+	0x0627: 0xfefb,
+}
+
+// SetArabicShaping toggles shaping of Arabic text. When enabled, each Arabic
+// letter is replaced by its isolated, initial, medial or final presentation
+// form depending on its neighbors, and lam followed by alef by their ligature,
+// so that words are drawn joined instead of as isolated letters. Letters are
+// left unchanged when the PixFont has no glyph for the form needed. Shaping does
+// not reorder text, so strings must already be in visual order.
+func (p *PixFont) SetArabicShaping(on bool) {
+	p.arabic = on
+	p.cache.reset()
+}
+
+// arabicJoiningType returns the joining type of c, treating marks such as
+// harakat as transparent ('T').
+func arabicJoiningType(c rune) byte {
+	switch {
+	case c >= 0x064b && c <= 0x065f, c == 0x0670:
+		return 'T'
+	case c >= 0x0621 && c <= 0x064a:
+		return arabicJoining[c-0x0621]
+	}
+	return 'U'
+}
+
+// shapeArabic replaces the Arabic letters of s with their contextual forms.
+func (p *PixFont) shapeArabic(s string) string {
+	rs := []rune(s)
+	// joinType returns the joining type of the nearest non-transparent rune
+	// from i in direction dir.
+	joinType := func(i, dir int) byte {
+		for i += dir; i >= 0 && i < len(rs); i += dir {
+			if t := arabicJoiningType(rs[i]); t != 'T' {
+				return t
+			}
+		}
+		return 'U'
+	}
+	has := func(c rune) bool {
+		return c != 0 && p.has(c)
+	}
+
+	out := make([]rune, 0, len(rs))
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		t := arabicJoiningType(c)
+		if t != 'D' && t != 'R' {
+			out = append(out, c)
+			continue
+		}
+		prev, next := joinType(i, -1), joinType(i, 1)
+		joinsPrev := prev == 'D' || prev == 'C'
+		joinsNext := t == 'D' && (next == 'D' || next == 'R' || next == 'C')
+
+		if c == 0x0644 && i+1 < len(rs) {
+			if lig, ok := lamAlef[rs[i+1]]; ok {
+				if joinsPrev {
+					lig++
+				}
+				if has(lig) {
+					out = append(out, lig)
+					i++
+					continue
+				}
+			}
+		}
+
+		form := 0 // isolated
+		switch {
+		case joinsPrev && joinsNext:
+			form = 3
+		case joinsPrev:
+			form = 1
+		case joinsNext:
+			form = 2
+		}
+		if f := arabicForms[c][form]; has(f) {
+			c = f
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
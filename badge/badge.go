@@ -0,0 +1,205 @@
+// Package badge draws status badges, such as "build | passing", with pixel
+// fonts. Badges are written as PNG or SVG images, so that CI servers and status
+// pages can serve their own badges without an external service:
+//
+//	b := badge.New("build", "passing")
+//	b.EncodePNG(w)
+package badge
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Colors for badge values, in the style of common CI badges.
+var (
+	Gray   = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	Green  = color.RGBA{0x44, 0xcc, 0x11, 0xff}
+	Yellow = color.RGBA{0xdf, 0xb3, 0x17, 0xff}
+	Orange = color.RGBA{0xfe, 0x7d, 0x37, 0xff}
+	Red    = color.RGBA{0xe0, 0x5d, 0x44, 0xff}
+	Blue   = color.RGBA{0x00, 0x7e, 0xc6, 0xff}
+)
+
+// Badge is a pill with a label on the left and a value on the right, each on
+// its own background color. Either part may be empty, which leaves it out.
+type Badge struct {
+	// Font is used to draw the text. If nil, pixfont.DefaultFont is used.
+	Font *pixfont.PixFont
+	// Label and Value are the text of the two parts of the badge.
+	Label, Value string
+	// LabelColor and ValueColor are the background colors of the two parts,
+	// and TextColor the color of the text. If nil, the label is Gray, the
+	// value Green and the text white.
+	LabelColor, ValueColor, TextColor color.Color
+
+	// Icon, if not 0, is a rune drawn before the label, such as a logo from
+	// an icon font. IconFont is the font of the icon, or Font if nil.
+	Icon     rune
+	IconFont *pixfont.PixFont
+
+	// Padding is the number of pixels between the text and the edges of each
+	// part of the badge.
+	Padding int
+	// Rounded removes the corner pixels of the badge.
+	Rounded bool
+}
+
+// New returns a badge for label and value with the default colors and a padding
+// of 3 pixels.
+func New(label, value string) *Badge {
+	return &Badge{Label: label, Value: value, Padding: 3, Rounded: true}
+}
+
+func (b *Badge) font() *pixfont.PixFont {
+	if b.Font == nil {
+		return pixfont.DefaultFont
+	}
+	return b.Font
+}
+
+func (b *Badge) iconFont() *pixfont.PixFont {
+	if b.IconFont == nil {
+		return b.font()
+	}
+	return b.IconFont
+}
+
+// textWidth returns the width of s without the spacing after the last rune.
+func textWidth(f *pixfont.PixFont, s string) int {
+	w := f.MeasureString(s)
+	if w > 0 {
+		w -= f.Spacing()
+	}
+	return w
+}
+
+// widths returns the widths of the label and value parts, 0 for a part which
+// is left out.
+func (b *Badge) widths() (label, value int) {
+	if b.Label != "" || b.Icon != 0 {
+		label = textWidth(b.font(), b.Label) + 2*b.Padding
+		if b.Icon != 0 {
+			_, w := b.iconFont().MeasureRune(b.Icon)
+			label += w
+			if b.Label != "" {
+				label += b.Padding
+			}
+		}
+	}
+	if b.Value != "" {
+		value = textWidth(b.font(), b.Value) + 2*b.Padding
+	}
+	return label, value
+}
+
+// Size returns the width and height of the badge in pixels.
+func (b *Badge) Size() image.Point {
+	label, value := b.widths()
+	h := b.font().GetHeight()
+	if b.Icon != 0 && b.iconFont().GetHeight() > h {
+		h = b.iconFont().GetHeight()
+	}
+	return image.Pt(label+value, h+2*b.Padding)
+}
+
+// Draw draws the badge with its top-left corner at x,y.
+func (b *Badge) Draw(dr pixfont.Drawable, x, y int) {
+	sz := b.Size()
+	labelW, _ := b.widths()
+	labelColor, valueColor, textColor := b.LabelColor, b.ValueColor, b.TextColor
+	if labelColor == nil {
+		labelColor = Gray
+	}
+	if valueColor == nil {
+		valueColor = Green
+	}
+	if textColor == nil {
+		textColor = color.White
+	}
+
+	for yy := 0; yy < sz.Y; yy++ {
+		for xx := 0; xx < sz.X; xx++ {
+			if b.Rounded && (xx == 0 || xx == sz.X-1) && (yy == 0 || yy == sz.Y-1) {
+				continue
+			}
+			c := valueColor
+			if xx < labelW {
+				c = labelColor
+			}
+			dr.Set(x+xx, y+yy, c)
+		}
+	}
+
+	// center each font vertically in the badge
+	textY := func(f *pixfont.PixFont) int {
+		return y + (sz.Y-f.GetHeight())/2
+	}
+	tx := x + b.Padding
+	if b.Icon != 0 {
+		_, w := b.iconFont().DrawRune(dr, tx, textY(b.iconFont()), b.Icon, textColor)
+		tx += w + b.Padding
+	}
+	b.font().DrawString(dr, tx, textY(b.font()), b.Label, textColor)
+	b.font().DrawString(dr, x+labelW+b.Padding, textY(b.font()), b.Value, textColor)
+}
+
+// Image returns the badge drawn on a transparent image of its size.
+func (b *Badge) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rectangle{Max: b.Size()})
+	b.Draw(img, 0, 0)
+	return img
+}
+
+// EncodePNG writes the badge to w as a PNG image.
+func (b *Badge) EncodePNG(w io.Writer) error {
+	return png.Encode(w, b.Image())
+}
+
+// EncodeSVG writes the badge to w as an SVG image, with each pixel drawn as a
+// scale by scale square. Runs of pixels of the same color are merged into one
+// rectangle to keep the file small.
+func (b *Badge) EncodeSVG(w io.Writer, scale int) error {
+	if scale < 1 {
+		scale = 1
+	}
+	img := b.Image()
+	sz := img.Bounds().Size()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		sz.X*scale, sz.Y*scale, sz.X, sz.Y)
+	if b.Label != "" || b.Value != "" {
+		bw.WriteString("<title>")
+		xml.EscapeText(bw, []byte(b.Label+" "+b.Value))
+		bw.WriteString("</title>\n")
+	}
+	at := func(x, y int) color.NRGBA {
+		return color.NRGBAModel.Convert(img.RGBAAt(x, y)).(color.NRGBA)
+	}
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; {
+			c := at(x, y)
+			n := 1
+			for x+n < sz.X && at(x+n, y) == c {
+				n++
+			}
+			if c.A != 0 {
+				fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="1" fill="#%02x%02x%02x"`, x, y, n, c.R, c.G, c.B)
+				if c.A != 0xff {
+					fmt.Fprintf(bw, ` fill-opacity="%.3f"`, float64(c.A)/0xff)
+				}
+				bw.WriteString("/>\n")
+			}
+			x += n
+		}
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,113 @@
+package badge
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/fonts/pico"
+)
+
+// render draws b onto a paletted image of its size and returns its rows,
+// separated by "|", with '#' for the text, 'o' for the label, '.' for the
+// value and ' ' for pixels which were not drawn.
+func render(b *Badge) string {
+	pal := color.Palette{color.Gray{0x80}, color.Black, color.Gray{0x40}, color.White}
+	img := image.NewPaletted(image.Rectangle{Max: b.Size()}, pal)
+	b.Draw(img, 0, 0)
+	var rows []string
+	for y := 0; y < img.Rect.Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+img.Rect.Dx()]
+		rows = append(rows, strings.Map(func(r rune) rune { return rune(" #o."[r]) }, string(row)))
+	}
+	return strings.Join(rows, "|")
+}
+
+func TestBadge(t *testing.T) {
+	b := &Badge{
+		Font:       pico.Font3x5,
+		Label:      "I",
+		Value:      "T",
+		LabelColor: color.Gray{0x40},
+		ValueColor: color.White,
+		TextColor:  color.Black,
+		Padding:    1,
+	}
+	if sz := b.Size(); sz != image.Pt(10, 7) {
+		t.Errorf("size is %v, want 10x7", sz)
+	}
+	want := "ooooo.....|o###o.###.|oo#oo..#..|oo#oo..#..|oo#oo..#..|o###o..#..|ooooo....."
+	if got := render(b); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	b.Rounded = true
+	want = " oooo.... |o###o.###.|oo#oo..#..|oo#oo..#..|oo#oo..#..|o###o..#..| oooo.... "
+	if got := render(b); got != want {
+		t.Errorf("rounded: got  %q\nwant %q", got, want)
+	}
+
+	b.Rounded, b.Icon = false, 'T'
+	want = "ooooooooo.....|o###o###o.###.|oo#ooo#oo..#..|oo#ooo#oo..#..|oo#ooo#oo..#..|oo#oo###o..#..|ooooooooo....."
+	if got := render(b); got != want {
+		t.Errorf("icon: got  %q\nwant %q", got, want)
+	}
+
+	b.Icon, b.Label = 0, ""
+	if got, want := render(b), ".....|.###.|..#..|..#..|..#..|..#..|....."; got != want {
+		t.Errorf("no label: got  %q\nwant %q", got, want)
+	}
+}
+
+func TestBadgeDefaults(t *testing.T) {
+	b := New("build", "passing")
+	img := b.Image()
+	if img.Bounds().Dy() != pixfont.DefaultFont.GetHeight()+6 {
+		t.Errorf("badge is %v", img.Bounds())
+	}
+	if c := img.RGBAAt(0, 0); c.A != 0 {
+		t.Errorf("rounded corner is %v", c)
+	}
+	if c := img.RGBAAt(1, 1); c != Gray {
+		t.Errorf("label is %v, want gray", c)
+	}
+	if c := img.RGBAAt(img.Bounds().Dx()-2, 1); c != Green {
+		t.Errorf("value is %v, want green", c)
+	}
+
+	var buf bytes.Buffer
+	if err := b.EncodePNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	m, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Bounds() != img.Bounds() {
+		t.Errorf("PNG is %v, want %v", m.Bounds(), img.Bounds())
+	}
+}
+
+func TestBadgeSVG(t *testing.T) {
+	b := &Badge{Font: pico.Font3x5, Label: "I<", Value: "T", Padding: 1, Rounded: true}
+	var buf bytes.Buffer
+	if err := b.EncodeSVG(&buf, 2); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="28" height="14" viewBox="0 0 14 7" shape-rendering="crispEdges">`,
+		"<title>I&lt; T</title>\n",
+		// the first row, without the rounded corners
+		"<rect x=\"1\" y=\"0\" width=\"8\" height=\"1\" fill=\"#555555\"/>\n<rect x=\"9\" y=\"0\" width=\"4\" height=\"1\" fill=\"#44cc11\"/>\n",
+		"</svg>\n",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("missing %q in:\n%s", want, svg)
+		}
+	}
+}
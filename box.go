@@ -0,0 +1,121 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Overflow is what DrawStringBox does with text that doesn't fit in its box.
+type Overflow int
+
+const (
+	// OverflowClip draws the text as it is, clipped to the box.
+	OverflowClip Overflow = iota
+	// OverflowWrap wraps the text into lines no wider than the box, as
+	// DrawStringWrapped does. Lines which don't fit below are clipped.
+	OverflowWrap
+	// OverflowShrink draws the text at the largest Scale at which it fits in
+	// the box, so that short text fills large boxes. Text which doesn't fit at
+	// its own size is clipped.
+	OverflowShrink
+	// OverflowEllipsis shortens each line which is too wide and ends it with an
+	// ellipsis, drawn with U+2026 if the font has a glyph for it and "..."
+	// otherwise.
+	OverflowEllipsis
+)
+
+// DrawStringBox draws s in the box r, positioned at the anchor a within it, and
+// handles text which doesn't fit as set by overflow. Newlines in s start new
+// lines, and each line is aligned to the left, center or right of the text as
+// set by a. Text is always clipped to r; if it is larger than r, its top-left
+// corner is kept rather than its anchor. DrawStringBox returns the part of r
+// covered by the text.
+//
+//	f.DrawStringBox(img, image.Rect(0, 0, 64, 8), title, color.Black, pixfont.CenterLeft, pixfont.OverflowEllipsis)
+func (p *PixFont) DrawStringBox(dr Drawable, r image.Rectangle, s string, clr color.Color, a Anchor, overflow Overflow) image.Rectangle {
+	sp := p.Spacing()
+	var lines []string
+	if overflow == OverflowWrap {
+		lines = p.wrap(s, r.Dx(), sp)
+	} else {
+		lines = strings.Split(s, "\n")
+	}
+	if overflow == OverflowEllipsis {
+		for i, line := range lines {
+			lines[i] = p.ellipsize(line, r.Dx(), sp)
+		}
+	}
+
+	w := 0
+	widths := make([]int, len(lines))
+	for i, line := range lines {
+		widths[i] = p.boxWidth(line, sp)
+		if widths[i] > w {
+			w = widths[i]
+		}
+	}
+	h := len(lines) * int(p.charHeight)
+	scale := 1
+	if overflow == OverflowShrink && w > 0 && h > 0 {
+		scale = r.Dx() / w
+		if sy := r.Dy() / h; sy < scale {
+			scale = sy
+		}
+		if scale < 1 {
+			scale = 1
+		}
+	}
+
+	size := image.Pt(w*scale, h*scale)
+	pt := a.Place(r, size, 0)
+	if size.X > r.Dx() {
+		pt.X = r.Min.X
+	}
+	if size.Y > r.Dy() {
+		pt.Y = r.Min.Y
+	}
+	cd := &clipDrawable{dr, r}
+	opts := &DrawOptions{Scale: scale, Spacing: &sp}
+	for i, line := range lines {
+		x := pt.X
+		switch a % 3 {
+		case 1:
+			x += (size.X - widths[i]*scale) / 2
+		case 2:
+			x += size.X - widths[i]*scale
+		}
+		p.DrawStringOptions(cd, x, pt.Y+i*int(p.charHeight)*scale, line, clr, opts)
+	}
+	return image.Rectangle{pt, pt.Add(size)}.Intersect(r)
+}
+
+// boxWidth returns the width of s with sp pixels between characters, without the
+// spacing after its last character.
+func (p *PixFont) boxWidth(s string, sp int) int {
+	w := p.measureString(s, sp)
+	if w > 0 {
+		w -= sp
+	}
+	return w
+}
+
+// ellipsize returns s, or if it is wider than maxWidth pixels, as much of the
+// start of s as fits with an ellipsis after it.
+func (p *PixFont) ellipsize(s string, maxWidth, sp int) string {
+	if p.boxWidth(s, sp) <= maxWidth {
+		return s
+	}
+	ellipsis := "..."
+	if p.HasGlyph('…') {
+		ellipsis = "…"
+	}
+	runes := []rune(s)
+	for n := len(runes) - 1; n > 0; n-- {
+		t := strings.TrimRight(string(runes[:n]), " ") + ellipsis
+		if p.boxWidth(t, sp) <= maxWidth {
+			return t
+		}
+	}
+	return ellipsis
+}
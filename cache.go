@@ -0,0 +1,127 @@
+package pixfont
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultCacheSize is the number of shaped strings each PixFont remembers,
+// unless changed using SetCacheSize.
+const DefaultCacheSize = 128
+
+// shapedRun is a string after rune decoding, normalization and measurement, so
+// that drawing it again can skip straight to the glyphs.
+type shapedRun struct {
+	runes   []rune
+	offsets []int // x offset of each rune from the start of the string
+	rises   []int // y offset of each rune, for stacked marks
+	width   int
+	spacing int // spacing when measured
+}
+
+type cacheEntry struct {
+	key string
+	run *shapedRun
+}
+
+// runCache is a least recently used cache of shaped strings, safe for
+// concurrent use.
+type runCache struct {
+	mu    sync.Mutex
+	limit int // 0 for DefaultCacheSize, negative if disabled
+	order *list.List
+	items map[string]*list.Element
+}
+
+// get returns the cached run for s measured with the spacing sp, if any, and
+// whether the cache is enabled.
+func (c *runCache) get(s string, sp int) (*shapedRun, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[s]
+	if !ok {
+		return nil, c.limit >= 0
+	}
+	run := e.Value.(*cacheEntry).run
+	if run.spacing != sp {
+		c.order.Remove(e)
+		delete(c.items, s)
+		return nil, true
+	}
+	c.order.MoveToFront(e)
+	return run, true
+}
+
+func (c *runCache) put(s string, run *shapedRun) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limit := c.limit
+	if limit == 0 {
+		limit = DefaultCacheSize
+	}
+	if limit < 0 {
+		return
+	}
+	if c.items == nil {
+		c.order = list.New()
+		c.items = make(map[string]*list.Element)
+	}
+	if e, ok := c.items[s]; ok {
+		e.Value.(*cacheEntry).run = run
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[s] = c.order.PushFront(&cacheEntry{s, run})
+	for c.order.Len() > limit {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.items, e.Value.(*cacheEntry).key)
+	}
+}
+
+// setLimit empties the cache and sets a new size limit.
+func (c *runCache) setLimit(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+	c.order, c.items = nil, nil
+}
+
+// reset empties the cache, for when a font setting changes how strings are
+// shaped.
+func (c *runCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order, c.items = nil, nil
+}
+
+// SetCacheSize sets the number of strings for which this PixFont remembers the
+// decoded runes and their widths, so that services drawing the same labels over
+// and over skip that work. The least recently drawn strings are forgotten first.
+// A size of 0 disables the cache for memory-constrained users. The default size
+// is DefaultCacheSize.
+func (p *PixFont) SetCacheSize(n int) {
+	if n <= 0 {
+		n = -1
+	}
+	p.cache.setLimit(n)
+}
+
+// shape returns the shaped form of s with the spacing sp, using the cache when
+// possible. It returns nil if the cache is disabled.
+func (p *PixFont) shape(s string, sp int) *shapedRun {
+	run, enabled := p.cache.get(s, sp)
+	if run != nil || !enabled {
+		return run
+	}
+	run = &shapedRun{spacing: sp}
+	run.width = p.layout(p.prepare(s), sp, func(c rune, dx, dy int) int {
+		_, w := p.drawRune(nil, 0, 0, c, sp)
+		run.runes = append(run.runes, c)
+		run.offsets = append(run.offsets, dx)
+		run.rises = append(run.rises, dy)
+		return w
+	})
+	p.cache.put(s, run)
+	return run
+}
@@ -0,0 +1,200 @@
+// Package captcha draws simple CAPTCHA images with pixel fonts. Each character
+// of a random answer is drawn at a randomly varied size and height, overlapping
+// its neighbors, over noise pixels and lines:
+//
+//	img, answer := captcha.New(nil)
+//	session.Set("captcha", answer)
+//	png.Encode(w, img)
+//
+// Bitmap text is easy to read for people but not strong against determined
+// solvers, so this is best used to slow down casual spam bots.
+package captcha
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math/rand"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// DefaultCharset leaves out characters which are easily confused, such as O
+// and 0, or I and 1.
+const DefaultCharset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Options changes how New draws a CAPTCHA. The zero value of each field selects
+// its default.
+type Options struct {
+	// Font is used to draw the answer. If nil, pixfont.DefaultFont is used.
+	Font *pixfont.PixFont
+	// Length is the number of characters in the answer, 5 by default.
+	Length int
+	// Charset holds the characters the answer is made from, DefaultCharset
+	// by default.
+	Charset string
+
+	// Scale is the average size each pixel of the font is drawn at, 3 by
+	// default, and ScaleJitter the most it varies from that for each
+	// character. Set ScaleJitter to -1 for no variation.
+	Scale, ScaleJitter int
+	// Jitter is the most each character is moved up or down from the middle
+	// of the image, in pixels. It defaults to twice Scale, and -1 disables it.
+	Jitter int
+	// Overlap is how many pixels each character overlaps the one before it.
+	// It defaults to Scale, and -1 disables it.
+	Overlap int
+	// Noise is the fraction of pixels set to the foreground color at random,
+	// 0.04 by default, and Lines the number of random lines drawn across the
+	// image, 2 by default. Negative values disable them.
+	Noise float64
+	Lines int
+
+	// Foreground and Background are the colors of the image, black on white by
+	// default.
+	Foreground, Background color.Color
+
+	// Rand is the source of randomness. If nil, a generator seeded from
+	// crypto/rand is used, so that answers can't be predicted.
+	Rand *rand.Rand
+}
+
+// withDefaults returns a copy of o with the defaults filled in.
+func (o *Options) withDefaults() Options {
+	var d Options
+	if o != nil {
+		d = *o
+	}
+	if d.Font == nil {
+		d.Font = pixfont.DefaultFont
+	}
+	if d.Length <= 0 {
+		d.Length = 5
+	}
+	if d.Charset == "" {
+		d.Charset = DefaultCharset
+	}
+	if d.Scale <= 0 {
+		d.Scale = 3
+	}
+	if d.ScaleJitter == 0 {
+		d.ScaleJitter = 1
+	}
+	if d.Jitter == 0 {
+		d.Jitter = 2 * d.Scale
+	}
+	if d.Overlap == 0 {
+		d.Overlap = d.Scale
+	}
+	if d.Noise == 0 {
+		d.Noise = 0.04
+	}
+	if d.Lines == 0 {
+		d.Lines = 2
+	}
+	if d.Foreground == nil {
+		d.Foreground = color.Black
+	}
+	if d.Background == nil {
+		d.Background = color.White
+	}
+	if d.Rand == nil {
+		var seed [8]byte
+		crand.Read(seed[:])
+		d.Rand = rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))))
+	}
+	return d
+}
+
+// glyph is one character of the answer as placed in the image.
+type glyph struct {
+	c        string
+	x, y     int
+	scale, w int
+}
+
+// New draws a CAPTCHA as set by opts, which may be nil for the defaults, and
+// returns the image along with its answer.
+func New(opts *Options) (*image.RGBA, string) {
+	o := opts.withDefaults()
+	r := o.Rand
+	between := func(lo, hi int) int {
+		if hi <= lo {
+			return lo
+		}
+		return lo + r.Intn(hi-lo+1)
+	}
+	max := func(a, b int) int {
+		if a > b {
+			return a
+		}
+		return b
+	}
+
+	charset := []rune(o.Charset)
+	answer := make([]rune, o.Length)
+	glyphs := make([]glyph, o.Length)
+	fh := o.Font.GetHeight()
+	jitter, overlap, scaleJitter := max(o.Jitter, 0), max(o.Overlap, 0), max(o.ScaleJitter, 0)
+
+	// place each character, with its top at y relative to the middle of the
+	// image, then size the image to fit them
+	margin := o.Scale * 2
+	x, w, h := margin, 0, 0
+	for i := range answer {
+		answer[i] = charset[r.Intn(len(charset))]
+		g := &glyphs[i]
+		g.c = string(answer[i])
+		g.scale = max(between(o.Scale-scaleJitter, o.Scale+scaleJitter), 1)
+		_, adv := o.Font.MeasureRune(answer[i])
+		g.w = adv * g.scale
+		g.x = x
+		g.y = between(-jitter, jitter) - fh*g.scale/2
+		x += g.w - overlap
+		w = max(w, g.x+g.w)
+		h = max(h, 2*(max(g.y+fh*g.scale, -g.y)))
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w+margin, h+2*margin))
+	b := img.Bounds()
+	for yy := 0; yy < b.Dy(); yy++ {
+		for xx := 0; xx < b.Dx(); xx++ {
+			img.Set(xx, yy, o.Background)
+		}
+	}
+
+	for _, g := range glyphs {
+		o.Font.DrawStringOptions(img, g.x, b.Dy()/2+g.y, g.c, o.Foreground, &pixfont.DrawOptions{Scale: g.scale})
+	}
+	for i := 0; i < o.Lines; i++ {
+		drawLine(img, r.Intn(b.Dx()/4), r.Intn(b.Dy()), b.Dx()-1-r.Intn(b.Dx()/4), r.Intn(b.Dy()), o.Foreground)
+	}
+	if o.Noise > 0 {
+		for n := int(o.Noise * float64(b.Dx()*b.Dy())); n > 0; n-- {
+			img.Set(r.Intn(b.Dx()), r.Intn(b.Dy()), o.Foreground)
+		}
+	}
+	return img, string(answer)
+}
+
+// drawLine draws a line from x0,y0 to x1,y1.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := x1-x0, y1-y0
+	steps := dx
+	if steps < 0 {
+		steps = -steps
+	}
+	if dy > steps || -dy > steps {
+		steps = dy
+		if steps < 0 {
+			steps = -steps
+		}
+	}
+	for i := 0; i <= steps; i++ {
+		x, y := x0, y0
+		if steps > 0 {
+			x, y = x0+dx*i/steps, y0+dy*i/steps
+		}
+		img.Set(x, y, c)
+	}
+}
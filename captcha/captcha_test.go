@@ -0,0 +1,76 @@
+package captcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+)
+
+func TestNew(t *testing.T) {
+	// without any variation the answer can be read back exactly
+	f := pixfont.Font8x8
+	opts := &Options{
+		Font: f, Length: 6, Charset: "HIT5",
+		Scale: 1, ScaleJitter: -1, Jitter: -1, Overlap: -1, Noise: -1, Lines: -1,
+		Rand: rand.New(rand.NewSource(1)),
+	}
+	img, answer := New(opts)
+	if len(answer) != 6 || strings.Trim(answer, "HIT5") != "" {
+		t.Fatalf("answer is %q", answer)
+	}
+	if got := pixfont.Recognize(img, f); got != answer {
+		t.Errorf("image reads %q, want %q", got, answer)
+	}
+	if b := img.Bounds(); b != image.Rect(0, 0, 2+6*8+2, 8+4) {
+		t.Errorf("image is %v", b)
+	}
+
+	// the same source of randomness gives the same CAPTCHA
+	draw := func() (*image.RGBA, string) {
+		return New(&Options{Rand: rand.New(rand.NewSource(42)), Foreground: color.Black})
+	}
+	img1, answer1 := draw()
+	img2, answer2 := draw()
+	if answer1 != answer2 || !bytes.Equal(img1.Pix, img2.Pix) {
+		t.Error("CAPTCHAs from the same seed differ")
+	}
+	if len(answer1) != 5 || strings.Trim(answer1, DefaultCharset) != "" {
+		t.Errorf("default answer is %q", answer1)
+	}
+
+	// noise and lines are drawn in the foreground color
+	opts.Rand = rand.New(rand.NewSource(1))
+	opts.Noise, opts.Lines = 0, 0
+	noisy, _ := New(opts)
+	if bytes.Equal(noisy.Pix, img.Pix) {
+		t.Error("no noise was drawn")
+	}
+}
+
+func TestDrawLine(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 5, 5))
+	drawLine(img, 4, 0, 0, 4, color.Black)
+	drawLine(img, 1, 1, 1, 1, color.Black)
+	var got []image.Point
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 5; x++ {
+			if img.RGBAAt(x, y).A != 0 {
+				got = append(got, image.Pt(x, y))
+			}
+		}
+	}
+	want := []image.Point{{4, 0}, {1, 1}, {3, 1}, {2, 2}, {1, 3}, {0, 4}}
+	if len(got) != len(want) {
+		t.Fatalf("line is %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("line is %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package pixfont
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// C64Upper maps Commodore 64 screen codes of the uppercase/graphics character set
+// to runes, for use with LoadC64. Screen codes without a reasonable Unicode
+// equivalent are mapped to 0 and skipped.
+var C64Upper = c64Order(false)
+
+// C64Lower maps Commodore 64 screen codes of the lowercase/uppercase character
+// set to runes, for use with LoadC64.
+var C64Lower = c64Order(true)
+
+// ZXSpectrum maps the 96 glyphs of a ZX Spectrum font to runes, for use with
+// LoadZX. It is ASCII from ' ' to '~' except for the pound sign and copyright
+// symbol which replace '`' and DEL.
+var ZXSpectrum = zxOrder()
+
+func c64Order(lower bool) []rune {
+	order := make([]rune, 128)
+	order[0] = '@'
+	for i := 1; i <= 26; i++ {
+		order[i] = 'A' + rune(i-1)
+		if lower {
+			order[i] = 'a' + rune(i-1)
+			order[64+i] = 'A' + rune(i-1)
+		}
+	}
+	copy(order[27:32], []rune{'[', '£', ']', '↑', '←'})
+	for i := 32; i < 64; i++ {
+		order[i] = rune(i)
+	}
+
+	graphics := map[int]rune{
+		64: '─', 91: '┼', 93: '│', 97: '▌', 98: '▄', 99: '▔', 100: '▁', 101: '▏',
+		102: '▒', 103: '▕', 107: '├', 109: '└', 110: '┐', 112: '┌', 113: '┴',
+		114: '┬', 115: '┤', 125: '┘',
+	}
+	if !lower {
+		graphics[65] = '♠'
+		graphics[81] = '●'
+		graphics[83] = '♥'
+		graphics[87] = '○'
+		graphics[88] = '♣'
+		graphics[90] = '♦'
+		graphics[94] = 'π'
+	}
+	for i, r := range graphics {
+		order[i] = r
+	}
+	return order
+}
+
+func zxOrder() []rune {
+	order := make([]rune, 96)
+	for i := range order {
+		order[i] = rune(32 + i)
+	}
+	order['`'-32] = '£'
+	order[0x7f-32] = '©'
+	return order
+}
+
+// LoadC64 loads a Commodore 64 character set dump (such as a .64c file) of 8x8
+// glyphs, optionally preceded by a 2-byte load address. The order slice maps
+// each glyph index (screen code) in the dump to a rune; glyphs mapped to 0, and
+// glyphs beyond the end of order, are skipped. See C64Upper and C64Lower.
+func LoadC64(r io.Reader, order []rune) (*PixFont, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%8 == 2 {
+		data = data[2:] // skip the PRG load address
+	}
+	return load8x8(data, order)
+}
+
+// LoadZX loads a headerless 768-byte ZX Spectrum font of 96 8x8 glyphs. The
+// order slice maps each glyph index in the font to a rune; if it is nil, the
+// ZXSpectrum mapping is used.
+func LoadZX(r io.Reader, order []rune) (*PixFont, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 768 {
+		return nil, fmt.Errorf("pixfont: ZX Spectrum fonts are 768 bytes, got %d", len(data))
+	}
+	if order == nil {
+		order = ZXSpectrum
+	}
+	return load8x8(data, order)
+}
+
+// load8x8 converts 8 bytes per glyph, most significant bit at the left, into a
+// new PixFont.
+func load8x8(data []byte, order []rune) (*PixFont, error) {
+	if len(data) == 0 || len(data)%8 != 0 {
+		return nil, fmt.Errorf("pixfont: charset size %d is not a multiple of 8 bytes", len(data))
+	}
+	return loadRaw(data, 8, 8, 0, &RawOptions{Order: order})
+}
+
+// RawOptions describes the layout of a headerless font dump for LoadRaw.
+type RawOptions struct {
+	// LSBFirst is true if the leftmost pixel of each row is stored in the
+	// least significant bit of a byte, instead of the most significant.
+	LSBFirst bool
+
+	// Stride is the number of bytes used by each row of a glyph. If 0, each row
+	// uses the fewest whole bytes that fit the glyph width.
+	Stride int
+
+	// GlyphStride is the number of bytes used by each glyph, for dumps which
+	// pad glyphs (e.g. 8x14 glyphs stored in 16 byte slots). If 0, it is the
+	// glyph height times Stride.
+	GlyphStride int
+
+	// Order maps each glyph index in the dump to a rune, instead of numbering
+	// glyphs consecutively from firstRune. Glyphs mapped to 0, and glyphs
+	// beyond the end of Order, are skipped.
+	Order []rune
+}
+
+// LoadRaw loads a headerless 1 bit per pixel font dump, such as an arcade or
+// computer character ROM, with glyphs w by h pixels. Glyphs are numbered
+// consecutively from firstRune unless opts gives an Order. If opts is nil, rows
+// are stored in whole bytes with the leftmost pixel in the most significant bit.
+// Any partial glyph at the end of the dump is ignored.
+func LoadRaw(r io.Reader, w, h int, firstRune rune, opts *RawOptions) (*PixFont, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return loadRaw(data, w, h, firstRune, opts)
+}
+
+func loadRaw(data []byte, w, h int, firstRune rune, opts *RawOptions) (*PixFont, error) {
+	if w < 1 || w > 32 || h < 1 || h > 255 {
+		return nil, fmt.Errorf("pixfont: glyph size %dx%d is not supported", w, h)
+	}
+	if opts == nil {
+		opts = &RawOptions{}
+	}
+	stride := opts.Stride
+	if stride == 0 {
+		stride = (w + 7) / 8
+	}
+	if stride*8 < w {
+		return nil, fmt.Errorf("pixfont: row stride of %d bytes is too small for %d pixels", stride, w)
+	}
+	glyphStride := opts.GlyphStride
+	if glyphStride == 0 {
+		glyphStride = stride * h
+	}
+	if glyphStride < stride*h {
+		return nil, fmt.Errorf("pixfont: glyph stride of %d bytes is too small for %d rows", glyphStride, h)
+	}
+
+	// the last glyph does not need its padding
+	count := 0
+	if len(data) >= stride*h {
+		count = (len(data)-stride*h)/glyphStride + 1
+	}
+
+	glyphs := make(map[rune]map[int]string)
+	for i := 0; i < count; i++ {
+		c := firstRune + rune(i)
+		if opts.Order != nil {
+			if i >= len(opts.Order) {
+				break
+			}
+			c = opts.Order[i]
+		}
+		if c == 0 && opts.Order != nil {
+			continue
+		}
+		if _, dup := glyphs[c]; dup {
+			continue // keep the first of any duplicate mappings
+		}
+		g := make(map[int]string, h)
+		for y := 0; y < h; y++ {
+			rowData := data[i*glyphStride+y*stride:]
+			row := make([]byte, w)
+			for x := range row {
+				bit := rowData[x/8] & (0x80 >> uint(x%8))
+				if opts.LSBFirst {
+					bit = rowData[x/8] & (1 << uint(x%8))
+				}
+				row[x] = ' '
+				if bit != 0 {
+					row[x] = 'X'
+				}
+			}
+			g[y] = string(row)
+		}
+		glyphs[c] = g
+	}
+	if len(glyphs) == 0 {
+		return nil, fmt.Errorf("pixfont: no complete %dx%d glyphs in %d bytes", w, h, len(data))
+	}
+
+	encoded, cm, err := Pack(w, h, glyphs)
+	if err != nil {
+		return nil, err
+	}
+	return NewPixFont(uint8(w), uint8(h), cm, encoded), nil
+}
@@ -0,0 +1,239 @@
+// Package chart draws axis labels for homemade charts with pixel fonts. It
+// picks "nice" tick values, formats them, drops labels which would collide and
+// draws y axis titles rotated to read from bottom to top:
+//
+//	ax := &chart.Axis{Font: pixfont.DefaultFont, Color: color.Black, Gap: 4}
+//	ticks := chart.NiceTicks(0, 1250, plot.Dx(), 10, chart.FormatSI)
+//	ax.DrawX(img, plot.Min.X, plot.Max.Y+2, ticks)
+//	ax.DrawTitleY(img, 0, plot.Min.Y+plot.Dy()/2, "requests/s")
+package chart
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Formatter returns the label text for an axis value.
+type Formatter func(v float64) string
+
+// FormatNumber formats v with up to 6 significant digits and no exponent or
+// trailing zeros, such as "0.25" or "1200".
+func FormatNumber(v float64) string {
+	if v == 0 {
+		return "0" // avoids "-0"
+	}
+	r, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 6, 64), 64)
+	if err != nil {
+		return strconv.FormatFloat(v, 'g', 6, 64)
+	}
+	return strconv.FormatFloat(r, 'f', -1, 64)
+}
+
+// FormatSI formats v with an SI suffix for large values, such as "1.5k" or
+// "20M", which keeps labels short on narrow axes.
+func FormatSI(v float64) string {
+	a := math.Abs(v)
+	for _, u := range []struct {
+		size   float64
+		suffix string
+	}{{1e12, "T"}, {1e9, "G"}, {1e6, "M"}, {1e3, "k"}} {
+		if a >= u.size {
+			return FormatNumber(v/u.size) + u.suffix
+		}
+	}
+	return FormatNumber(v)
+}
+
+// FormatFixed returns a Formatter which formats values with the given number
+// of decimal places, such as "1.50" for 2 places.
+func FormatFixed(places int) Formatter {
+	return func(v float64) string {
+		s := strconv.FormatFloat(v, 'f', places, 64)
+		if strings.Trim(s, "-0.") == "" {
+			s = strings.TrimPrefix(s, "-")
+		}
+		return s
+	}
+}
+
+// Tick is a labeled position on an axis. Pos is the distance in pixels from
+// the start of the axis: rightwards for x axes, and upwards for y axes.
+type Tick struct {
+	Pos   int
+	Label string
+}
+
+// NiceTicks returns ticks at round values (1, 2 or 5 times a power of ten)
+// between min and max, for an axis length pixels long with about n ticks.
+// Labels are formatted with format, or FormatNumber if nil.
+func NiceTicks(min, max float64, length, n int, format Formatter) []Tick {
+	if format == nil {
+		format = FormatNumber
+	}
+	if n < 1 {
+		n = 1
+	}
+	if max < min {
+		min, max = max, min
+	}
+	span := max - min
+	if span == 0 || math.IsInf(span, 0) || math.IsNaN(span) {
+		return []Tick{{0, format(min)}}
+	}
+
+	step := math.Pow(10, math.Floor(math.Log10(span/float64(n))))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if span/(step*m) <= float64(n) {
+			step *= m
+			break
+		}
+	}
+	// Count ticks with an integer: far from zero, adding 1 to a float index
+	// may not change it.
+	lo := math.Ceil(min / step)
+	hi := math.Floor(max/step + 1e-9)
+	if c := hi - lo; math.IsNaN(c) || math.IsInf(c, 0) || c > float64(10*n) {
+		return []Tick{{0, format(min)}}
+	}
+	var ticks []Tick
+	for i := 0; i <= int(hi-lo); i++ {
+		v := (lo + float64(i)) * step
+		pos := int(math.Round((v - min) / span * float64(length)))
+		ticks = append(ticks, Tick{pos, format(v)})
+	}
+	return ticks
+}
+
+// Axis draws tick labels and titles along the edges of a chart.
+type Axis struct {
+	// Font is used to draw the labels. If nil, pixfont.DefaultFont is used.
+	Font *pixfont.PixFont
+	// Color is the color of the labels.
+	Color color.Color
+	// Gap is the least number of pixels between neighboring labels. Labels
+	// closer than this are thinned out by FitX and FitY.
+	Gap int
+}
+
+func (a *Axis) font() *pixfont.PixFont {
+	if a.Font == nil {
+		return pixfont.DefaultFont
+	}
+	return a.Font
+}
+
+// textWidth returns the width of s without the spacing after the last rune.
+func (a *Axis) textWidth(s string) int {
+	f := a.font()
+	w := f.MeasureString(s)
+	if w > 0 {
+		w -= f.Spacing()
+	}
+	return w
+}
+
+// fit returns every kth tick for the smallest k where no two labels, each
+// covering size(label) pixels centered on its tick, come closer than a.Gap.
+func (a *Axis) fit(ticks []Tick, size func(string) int) []Tick {
+	for k := 1; k < len(ticks); k++ {
+		var kept []Tick
+		fits := true
+		end := 0
+		for i := 0; i < len(ticks); i += k {
+			t := ticks[i]
+			sz := size(t.Label)
+			start := t.Pos - sz/2
+			if len(kept) > 0 && start < end+a.Gap {
+				fits = false
+				break
+			}
+			kept = append(kept, t)
+			end = start + sz
+		}
+		if fits {
+			return kept
+		}
+	}
+	if len(ticks) > 1 {
+		return ticks[:1]
+	}
+	return ticks
+}
+
+// FitX returns the ticks which can be labeled along an x axis without their
+// labels colliding, keeping every second, third, etc. tick as needed. Ticks
+// must be sorted by Pos.
+func (a *Axis) FitX(ticks []Tick) []Tick {
+	return a.fit(ticks, a.textWidth)
+}
+
+// FitY is like FitX for labels along a y axis, which collide when they are
+// closer than the font height.
+func (a *Axis) FitY(ticks []Tick) []Tick {
+	h := a.font().GetHeight()
+	return a.fit(ticks, func(string) int { return h })
+}
+
+// DrawX draws the labels of the ticks which FitX keeps, each centered under
+// its tick, for an x axis starting at x with labels top-aligned at y.
+func (a *Axis) DrawX(dr pixfont.Drawable, x, y int, ticks []Tick) {
+	for _, t := range a.FitX(ticks) {
+		a.font().DrawString(dr, x+t.Pos-a.textWidth(t.Label)/2, y, t.Label, a.Color)
+	}
+}
+
+// DrawY draws the labels of the ticks which FitY keeps, right-aligned to end
+// at x and vertically centered on their ticks, for a y axis starting at y and
+// running upwards.
+func (a *Axis) DrawY(dr pixfont.Drawable, x, y int, ticks []Tick) {
+	h := a.font().GetHeight()
+	for _, t := range a.FitY(ticks) {
+		a.font().DrawString(dr, x-a.textWidth(t.Label), y-t.Pos-h/2, t.Label, a.Color)
+	}
+}
+
+// LabelWidth returns the width of the widest label of ticks, which is the
+// room DrawY needs to the left of the axis.
+func (a *Axis) LabelWidth(ticks []Tick) int {
+	w := 0
+	for _, t := range ticks {
+		if tw := a.textWidth(t.Label); tw > w {
+			w = tw
+		}
+	}
+	return w
+}
+
+// DrawTitleY draws s rotated a quarter turn counter-clockwise, reading from
+// bottom to top, with its left edge at x and vertically centered on y, as is
+// usual for y axis titles.
+func (a *Axis) DrawTitleY(dr pixfont.Drawable, x, y int, s string) {
+	w := a.textWidth(s)
+	// DrawRotated's y is below the bottom row, so the middle row lands on y
+	DrawRotated(dr, a.font(), x, y+w-w/2, s, a.Color)
+}
+
+// DrawRotated draws s with f rotated a quarter turn counter-clockwise, so that
+// it reads from bottom to top starting at x,y, which is the bottom-left corner
+// of the text. It returns the y coordinate above the last rune drawn.
+func DrawRotated(dr pixfont.Drawable, f *pixfont.PixFont, x, y int, s string, clr color.Color) int {
+	// the font's top row becomes the left column, and the text advances
+	// upwards from x,y
+	n := f.DrawString(rotated{dr, x, y}, 0, 0, s, clr)
+	return y - n
+}
+
+// rotated is a Drawable which rotates pixels a quarter turn counter-clockwise
+// around x,y.
+type rotated struct {
+	dr   pixfont.Drawable
+	x, y int
+}
+
+func (r rotated) Set(x, y int, c color.Color) {
+	r.dr.Set(r.x+y, r.y-1-x, c)
+}
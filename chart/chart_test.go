@@ -0,0 +1,132 @@
+package chart
+
+import (
+	"image/color"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/fonts/pico"
+)
+
+// drawn returns the pixels drawn by draw onto a w by h canvas, as rows of 'X'
+// and ' ' separated by "|".
+func drawn(w, h int, draw func(dr pixfont.Drawable)) string {
+	sd := pixfont.NewFixedStringDrawable(w, h)
+	draw(sd)
+	return strings.Join(strings.Split(strings.TrimSuffix(sd.String(), "\n"), "\n"), "|")
+}
+
+func TestFormat(t *testing.T) {
+	for _, tc := range []struct {
+		f    Formatter
+		v    float64
+		want string
+	}{
+		{FormatNumber, 0, "0"},
+		{FormatNumber, math.Copysign(0, -1), "0"},
+		{FormatNumber, 0.1 + 0.2, "0.3"},
+		{FormatNumber, 1200, "1200"},
+		{FormatNumber, -0.25, "-0.25"},
+		{FormatSI, 999, "999"},
+		{FormatSI, 1500, "1.5k"},
+		{FormatSI, -2500, "-2.5k"},
+		{FormatSI, 20e6, "20M"},
+		{FormatSI, 3e9, "3G"},
+		{FormatSI, 4e12, "4T"},
+		{FormatFixed(2), 1.5, "1.50"},
+		{FormatFixed(2), -0.001, "0.00"},
+		{FormatFixed(0), -2, "-2"},
+	} {
+		if got := tc.f(tc.v); got != tc.want {
+			t.Errorf("%v formats as %q, want %q", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestNiceTicks(t *testing.T) {
+	for _, tc := range []struct {
+		min, max  float64
+		length, n int
+		want      []Tick
+	}{
+		{0, 1250, 100, 5, []Tick{{0, "0"}, {40, "500"}, {80, "1000"}}},
+		{1250, 0, 100, 5, []Tick{{0, "0"}, {40, "500"}, {80, "1000"}}},
+		{-1, 1, 20, 4, []Tick{{0, "-1"}, {5, "-0.5"}, {10, "0"}, {15, "0.5"}, {20, "1"}}},
+		{0, 1, 10, 0, []Tick{{0, "0"}, {10, "1"}}},
+		{7, 7, 10, 5, []Tick{{0, "7"}}},
+	} {
+		if got := NiceTicks(tc.min, tc.max, tc.length, tc.n, nil); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("NiceTicks(%v, %v, %d, %d) is %v, want %v", tc.min, tc.max, tc.length, tc.n, got, tc.want)
+		}
+	}
+	if got := NiceTicks(0, 2000, 10, 1, FormatSI); len(got) != 2 || got[1].Label != "2k" {
+		t.Errorf("SI ticks are %v", got)
+	}
+
+	// Far from zero, a float loop counter stops advancing.
+	got := NiceTicks(1.7e18, 1.7e18+256, 200, 5, nil)
+	if len(got) == 0 || len(got) > 6 {
+		t.Fatalf("ticks near 1.7e18 are %v", got)
+	}
+	for _, tk := range got {
+		if tk.Pos < 0 || tk.Pos > 200 {
+			t.Errorf("tick near 1.7e18 at %d, outside the axis", tk.Pos)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	a := &Axis{Font: pico.Font3x5, Gap: 1}
+	ticks := []Tick{{0, "I"}, {2, "I"}, {4, "I"}, {6, "I"}, {8, "I"}}
+	if got, want := a.FitX(ticks), []Tick{{0, "I"}, {4, "I"}, {8, "I"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FitX is %v, want %v", got, want)
+	}
+	// labels are 5 pixels tall, so they need 6 between them vertically
+	if got, want := a.FitY(ticks), []Tick{{0, "I"}, {6, "I"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FitY is %v, want %v", got, want)
+	}
+	wide := []Tick{{0, "IIIIII"}, {2, "I"}}
+	if got := a.FitX(wide); !reflect.DeepEqual(got, wide[:1]) {
+		t.Errorf("FitX of colliding labels is %v", got)
+	}
+	if w := a.LabelWidth(wide); w != 23 {
+		t.Errorf("label width is %d, want 23", w)
+	}
+}
+
+func TestDraw(t *testing.T) {
+	a := &Axis{Font: pico.Font3x5, Color: color.Opaque, Gap: 1}
+	got := drawn(10, 5, func(dr pixfont.Drawable) {
+		a.DrawX(dr, 1, 0, []Tick{{1, "I"}, {2, "T"}, {6, "T"}})
+	})
+	if want := " XXX  XXX |  X    X  |  X    X  |  X    X  | XXX   X  "; got != want {
+		t.Errorf("DrawX: got  %q\nwant %q", got, want)
+	}
+
+	got = drawn(4, 14, func(dr pixfont.Drawable) {
+		a.DrawY(dr, 4, 13, []Tick{{2, "I"}, {8, "T"}})
+	})
+	want := "    |    |    | XXX|  X |  X |  X |  X |    | XXX|  X |  X |  X | XXX"
+	if got != want {
+		t.Errorf("DrawY: got  %q\nwant %q", got, want)
+	}
+
+	got = drawn(5, 4, func(dr pixfont.Drawable) {
+		if y := DrawRotated(dr, pico.Font3x5, 0, 3, "T", color.Opaque); y != -1 {
+			t.Errorf("DrawRotated returned %d, want -1", y)
+		}
+	})
+	if want := "X    |XXXXX|X    |     "; got != want {
+		t.Errorf("DrawRotated: got  %q\nwant %q", got, want)
+	}
+
+	got = drawn(5, 7, func(dr pixfont.Drawable) {
+		a.DrawTitleY(dr, 0, 3, "IT")
+	})
+	if want := "X    |XXXXX|X    |     |X   X|XXXXX|X   X"; got != want {
+		t.Errorf("DrawTitleY: got  %q\nwant %q", got, want)
+	}
+}
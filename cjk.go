@@ -0,0 +1,25 @@
+package pixfont
+
+import "golang.org/x/text/width"
+
+// SetCJKGrid lays out text on a fixed grid of cell pixels, for labels which mix
+// CJK and Latin text. Wide and fullwidth runes, such as CJK ideographs, kana and
+// Hangul, take two cells and all other runes take one, including the spacing
+// after each rune, so that text lines up in columns however the scripts are
+// mixed. Glyphs are drawn at the left of their cells. A cell of 0 (the default)
+// restores the normal advances. For a fixed width font, the usual cell is the
+// font width plus its Spacing.
+func (p *PixFont) SetCJKGrid(cell int) {
+	p.gridCell = cell
+	p.cache.reset()
+}
+
+// gridAdvance returns the advance of c on the CJK grid, not including the
+// spacing sp.
+func (p *PixFont) gridAdvance(c rune, sp int) int {
+	switch width.LookupRune(c).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2*p.gridCell - sp
+	}
+	return p.gridCell - sp
+}
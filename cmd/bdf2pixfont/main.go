@@ -2,38 +2,61 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
-	"sort"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/load"
+	"github.com/pbnjay/pixfont/textfmt"
 )
 
+var runeRanges = flag.String("range", "", "only include characters in these unicode ranges (e.g. U+0020-007E,U+00A0-00FF)")
+
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Fprintf(os.Stderr, "USAGE: %s filename.bdf > filename.txt", os.Args[0])
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "USAGE: %s [-range U+0020-007E] filename.bdf > filename.txt\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
-	f, err := os.Open(os.Args[1])
+	var ranges []pixfont.RuneRange
+	if *runeRanges != "" {
+		var err error
+		ranges, err = pixfont.ParseRanges(*runeRanges)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	f, err := os.Open(flag.Arg(0))
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	bfont, err := OpenBDF(f)
+	pf, err := load.BDF(f)
+	f.Close()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-
-	all := make([]rune, 0, len(bfont.Glyphs))
-	for r := range bfont.Glyphs {
-		all = append(all, r)
+	if ranges != nil {
+		var keep []rune
+		for _, r := range pf.Runes() {
+			if pixfont.InRanges(ranges, r) {
+				keep = append(keep, r)
+			}
+		}
+		if pf, err = pf.Subset(keep); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	}
-	sort.Slice(all, func(i, j int) bool {
-		return all[i] < all[j]
-	})
-	for _, r := range all {
-		data := bfont.Glyphs[r]
-		fmt.Println(data)
+	if err := textfmt.Format(os.Stdout, textfmt.FromPixFont(pf)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-
-	f.Close()
 }
@@ -0,0 +1,80 @@
+// Command ebdt2pixfont extracts an embedded bitmap strike from an OpenType or
+// TrueType font and prints it in the text format read by fontgen -txt.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/load"
+	"github.com/pbnjay/pixfont/textfmt"
+)
+
+var (
+	ppem = flag.Int("ppem", 0, "pixels per em of the strike to extract (default first strike)")
+	list = flag.Bool("list", false, "list the available strike sizes and exit")
+
+	runeRanges = flag.String("range", "", "only include characters in these unicode ranges (e.g. U+0020-007E,U+00A0-00FF)")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "USAGE: %s [-ppem N] [-range U+0020-007E] filename.ttf > filename.txt\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	var ranges []pixfont.RuneRange
+	if *runeRanges != "" {
+		var err error
+		ranges, err = pixfont.ParseRanges(*runeRanges)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	data, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *list {
+		sizes, err := load.OpenTypeStrikes(bytes.NewReader(data))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, s := range sizes {
+			fmt.Println(s)
+		}
+		return
+	}
+
+	fnt, err := load.OpenType(bytes.NewReader(data), *ppem)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fnt.Copyright = strings.Join(strings.Fields(fnt.Copyright), " ")
+	tf := textfmt.FromPixFont(fnt)
+	if ranges != nil {
+		for r := range tf.Glyphs {
+			if !pixfont.InRanges(ranges, r) {
+				delete(tf.Glyphs, r)
+			}
+		}
+	}
+	if err := textfmt.Format(os.Stdout, tf); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
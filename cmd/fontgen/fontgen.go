@@ -24,14 +24,17 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"io/ioutil"
 	"os"
-	"regexp"
-	"sort"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/export"
+	"github.com/pbnjay/pixfont/textfmt"
 )
 
 var (
@@ -42,97 +45,66 @@ var (
 	width     = flag.Int("w", 0, "chop width")
 	alphabet  = flag.String("a", "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", "alphabet to extract")
 	varWidth  = flag.Bool("v", false, "produce variable width font")
+	threshold = flag.Int("t", 0, "gray level difference from the background above which pixels are part of a glyph (default guesses from the image colors)")
+	markers   = flag.Bool("marker", false, "find glyphs using a marker row above the crop region, with a pixel over the first column of each glyph")
 
 	textName = flag.String("txt", "", "text file to extract pixel font from")
 	outName  = flag.String("o", "", "package name to create (becomes <myfont>.go)")
+	pkgName  = flag.String("pkg", "", "package name of the generated Go code (default the -o name, or font with -o -)")
+
+	serveAddr   = flag.String("serve", "", "serve a page on this address (e.g. localhost:8080) for adjusting the -img extraction interactively")
+	previewMode = flag.String("preview", "", "print a specimen of the font to the terminal (sixel, kitty or iterm2)")
+	outLang     = flag.String("lang", "go", "language of the created source file (go, js, py or rs), or svg for an SVG font")
+	compress    = flag.Bool("compress", false, "compress the font data in the generated Go code, for large fonts")
+	sorted      = flag.Bool("sorted", false, "store the character map in sorted slices instead of a map, for microcontrollers")
+	rom         = flag.Bool("rom", false, "store the font data in a string constant, which stays in read-only memory")
+	runeRanges  = flag.String("range", "", "only include characters in these unicode ranges (e.g. U+0020-007E,U+00A0-00FF)")
+	manifest    = flag.String("manifest", "", "JSON file describing several fonts to generate in one run")
+	update      = flag.String("update", "", "regenerate the font in this Go file using the flags in its //fontgen:flags directives, for go generate")
 )
 
-// packFont takes a mostly textual representation of a pixel font and
-// packs it into a tight uint32 representation, returning that representation
-// plus a "mapping" from character code to encoded position.
-func packFont(w, h int, d map[rune]map[int]string) ([]uint32, map[rune]uint16) {
-	cm := make(map[rune]uint16)
-
-	// Sort the glyph list so the representation is stable across different invocations
-	// of fontgen.
-	chs := make([]int, 0, len(d))
-	for ch, _ := range d {
-		chs = append(chs, int(ch))
-	}
-	sort.IntSlice(chs).Sort()
-
-	// convert from simple character encoding to packed bitfield
-	// NB fonts should be at most 32 pixels wide to fit in the uint32
-	//    (height is limited to uint8 255)
-	//
-	// This packed representation stores 1-4 glyphs in a single uint32 (per line).
-	// For efficiency, each glyph must be 8-bit aligned. Glyphs are stored "backwards"
-	// (leftmost pixel in LSB).
-	// Glyphs that will not fit in their entirety will be pushed to the next uint32.
-	//
-	// For example:
-	// An 8-pixel font can store 4 glyphs using one uint32 per line.
-	// A 9-pixel font can only store 2, because 9-bit values must be
-	// byte-aligned.
-	// A 17-pixel font can only store 1, because it is impossible to
-	// align two 17-bit values (totalling 34 bits) in 32.
-	//
-	// Lines are stored in consecutive uint32s.
-	//
-	//         24      16       8       0
-	//          |       |       |       |
-	// 0     DDDD    CCC     BBBB     A   == 0b00001111000011100000111100000100 == 0x0f0e0f04
-	// 1    D   D   C   C   B   B    A A  == 0b00010001000100010001000100001010 == 0x1111110a
-	// 2    D   D       C    BBBB   A   A == 0b00010001000000010000111100010001 == 0x11010f11
-	// 3    D   D   C   C   B   B   AAAAA == 0b00010001000100010001000100011111 == 0x1111111f
-	// 4     DDDD    CCC     BBBB   A   A == 0b00001111000011100000111100010001 == 0x0f0e0f11
-	// 5                            EEEEE == 0b00000000000000000000000000011111 == 0x0000001f
-	// 6                                E == 0b00000000000000000000000000000001 == 0x00000001
-	// 7                             EEEE == 0b00000000000000000000000000001111 == 0x0000000f
-	// 8                                E == 0b00000000000000000000000000000001 == 0x00000001
-	// 9                            EEEEE == 0b00000000000000000000000000011111 == 0x0000001f
-
-	u8PerCh := ((w - 1) >> 3) + 1 // 0-8 take up 1 byte, 9-16 take up 2, 17-24 take up 3, 24+ take up 4
-	chPerU32 := 4 / u8PerCh       // we can fit 4, 2 or 1 glyphs per u32
-	spacing := 4 / chPerU32       // we must skip 1, 2, or 4 8-bit units between each glyph start
-
-	costPerLine := (len(d) + chPerU32 - 1) / chPerU32 // #of whole u32 per horizontal line in font
-	costTotal := h * costPerLine                      // #of whole u32s required for the whole font
-
-	encoded := make([]uint32, costTotal)
-
-	// i8 tracks the number of 8-bit units we've skipped
-	var i8 int
-	for _, c := range chs {
-		matrix := d[rune(c)]
-
-		i32 := (i8 >> 2) * h // i32 is the index into encoded for the u32 for this char
-		dist := i8 & 0b11    // how many u8 units into the u32 we're offset
-		cm[rune(c)] = uint16((i32 << 2) | dist)
-
-		for y := 0; y < h; y++ {
-			line := encoded[i32+y]
-			var b uint32 = 1 << uint(8*dist)
-
-			if ld, hasLine := matrix[y]; hasLine {
-				for x := 0; x < w; x++ {
-					if len(ld) > x && ld[x] == 'X' {
-						line |= b
-					}
-					b <<= 1
-				}
-			}
+// fontMeta is the font metadata read from the metadata lines of a text font,
+// which is kept in the text output and generated fonts.
+var fontMeta struct {
+	Name, Copyright, Comment string
+}
 
-			encoded[i32+y] = line
+// filterRanges removes any characters outside the -range flag from allLetters,
+// and returns the new maximum character width.
+func filterRanges(allLetters map[rune]map[int]string, maxWidth int) (int, error) {
+	if *runeRanges == "" {
+		return maxWidth, nil
+	}
+	rs, err := pixfont.ParseRanges(*runeRanges)
+	if err != nil {
+		return maxWidth, err
+	}
+	maxWidth = 0
+	for r, l := range allLetters {
+		if !pixfont.InRanges(rs, r) {
+			delete(allLetters, r)
+			continue
+		}
+		for _, ln := range l {
+			if len(ln) > maxWidth {
+				maxWidth = len(ln)
+			}
 		}
-
-		i8 += spacing
 	}
+	return maxWidth, nil
+}
 
-	return encoded, cm
+// packFont takes a mostly textual representation of a pixel font and
+// packs it into a tight uint32 representation, returning that representation
+// plus a "mapping" from character code to encoded position. It fails if there
+// are too many glyphs for the mapping.
+func packFont(w, h int, d map[rune]map[int]string) ([]uint32, map[rune]uint16, error) {
+	return pixfont.Pack(w, h, d)
 }
 
-func generatePixFont(name string, w, h int, v bool, d map[rune]map[int]string) {
+// generatePixFont writes the font for the glyphs d to the file name, in the
+// language set by -lang.
+func generatePixFont(name string, w, h int, v bool, d map[rune]map[int]string) error {
 	template := `
 		package %s
 
@@ -144,63 +116,276 @@ func generatePixFont(name string, w, h int, v bool, d map[rune]map[int]string) {
 			charMap := %#v
 			data := %#v
 			Font = pixfont.NewPixFont(%d, %d, charMap, data)
-			Font.SetVariableWidth(%t)
+			Font.SetVariableWidth(%t)%s
 		}
 	`
 
-	encoded, cm := packFont(w, h, d)
+	encoded, cm, err := packFont(w, h, d)
+	if err != nil {
+		return err
+	}
 
 	fnt := pixfont.NewPixFont(uint8(w), uint8(h), cm, encoded)
 	fnt.SetVariableWidth(v)
+	fnt.Name, fnt.Copyright, fnt.Comment = fontMeta.Name, fontMeta.Copyright, fontMeta.Comment
 
-	f, err := os.OpenFile(name+".go", os.O_CREATE|os.O_RDWR, 0644)
+	f, err := createOutput(name)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		return
+		return err
+	}
+	defer f.Close()
+
+	if *outLang != "go" {
+		return exportPixFont(f, fnt)
+	}
+
+	pkg := *pkgName
+	if pkg == "" {
+		pkg = filepath.Base(name)
+		if name == "-" {
+			pkg = "font"
+		}
 	}
 
 	// draw a comment header using the new font
 	sd := &pixfont.StringDrawable{}
-	fnt.DrawString(sd, 0, 0, name, nil)
+	fnt.DrawString(sd, 0, 0, pkg, nil)
 	fmt.Fprintln(f, sd.PrefixString("// "))
+	for _, line := range keepLines {
+		fmt.Fprintln(f, line)
+	}
 
 	// create the code from the template and go fmt it
-	code := fmt.Sprintf(template, name, cm, encoded, w, h, v)
+	var meta strings.Builder
+	if fnt.Name != "" {
+		fmt.Fprintf(&meta, "\nFont.Name = %q", fnt.Name)
+	}
+	if fnt.Copyright != "" {
+		fmt.Fprintf(&meta, "\nFont.Copyright = %q", fnt.Copyright)
+	}
+	if fnt.Comment != "" {
+		fmt.Fprintf(&meta, "\nFont.Comment = %q", fnt.Comment)
+	}
+	// data is the font data as passed to the constructor
+	var data interface{} = encoded
+	if *rom {
+		data = romString(pixfont.DataString(encoded))
+		template = strings.Replace(template, "pixfont.NewPixFont", "pixfont.NewStringPixFont", 1)
+	}
+	var code string
+	if *sorted {
+		runes, offsets := pixfont.SortedCharMap(cm)
+		t := sortedTemplate
+		if *rom {
+			t = strings.Replace(t, "pixfont.NewSortedPixFont", "pixfont.NewSortedStringPixFont", 1)
+		}
+		code = fmt.Sprintf(t, pkg, runes, offsets, data, w, h, v, meta.String())
+	} else if *compress {
+		template = strings.Replace(template, "pixfont.NewPixFont", "pixfont.NewCompressedPixFont", 1)
+		code = fmt.Sprintf(template, pkg, cm, pixfont.CompressData(h, encoded, 0), w, h, v, meta.String())
+	} else {
+		code = fmt.Sprintf(template, pkg, cm, data, w, h, v, meta.String())
+	}
 	bcode, _ := format.Source([]byte(code))
-	fmt.Fprintln(f, string(bcode))
+	_, err = fmt.Fprintln(f, string(bcode))
+	return err
+}
 
-	f.Close()
+// sortedTemplate is the generated code for the -sorted flag. The slices are
+// package level variables so that TinyGo can keep them in flash.
+const sortedTemplate = `
+	package %s
+
+	import "github.com/pbnjay/pixfont"
+
+	var Font *pixfont.PixFont
+
+	var (
+		fontRunes   = %#v
+		fontOffsets = %#v
+		fontData    = %#v
+	)
+
+	func init() {
+		Font = pixfont.NewSortedPixFont(%d, %d, fontRunes, fontOffsets, fontData)
+		Font.SetVariableWidth(%t)%s
+	}
+`
+
+// romString is font data for the -rom flag, which is written as an ASCII-only
+// string literal.
+type romString string
+
+func (s romString) GoString() string {
+	return strconv.QuoteToASCII(string(s))
 }
 
-func processImage(filename string) (allLetters map[rune]map[int]string, maxWidth int) {
-	f, err := os.Open(filename)
-	if err != nil {
-		fmt.Fprint(os.Stderr, err.Error())
-		return nil, 0
+// openInput opens the named input file, or standard input if name is "-".
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return ioutil.NopCloser(os.Stdin), nil
 	}
-	img, _, err := image.Decode(f)
-	if err != nil {
-		fmt.Fprint(os.Stderr, err.Error())
-		return nil, 0
+	return os.Open(name)
+}
+
+// createOutput creates the source file for the package name, with the extension
+// for the -lang flag, or returns standard output if name is "-".
+func createOutput(name string) (io.WriteCloser, error) {
+	if name == "-" {
+		return nopWriteCloser{os.Stdout}, nil
 	}
-	if *width == 0 {
-		*width = img.Bounds().Dx() - *startX
+	return os.Create(name + langExt[*outLang])
+}
+
+// nopWriteCloser is an io.WriteCloser which doesn't close its Writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// langExt maps the -lang flag to the extension of the created file.
+var langExt = map[string]string{
+	"go":  ".go",
+	"js":  ".js",
+	"py":  ".py",
+	"rs":  ".rs",
+	"svg": ".svg",
+}
+
+// exportPixFont writes fnt to f as source code in a language other than Go.
+func exportPixFont(f io.Writer, fnt *pixfont.PixFont) error {
+	switch *outLang {
+	case "js":
+		return export.JS(f, fnt, "Font")
+	case "py":
+		return export.Python(f, fnt, "FONT")
+	case "rs":
+		return export.Rust(f, fnt)
+	case "svg":
+		return export.SVGFont(f, fnt, 0)
 	}
-	if *height == 0 {
-		*height = img.Bounds().Dy() - *startY
+	return fmt.Errorf("unknown language %q", *outLang)
+}
+
+// params controls how glyphs are extracted from an image, as set by the
+// command line flags or the -serve page.
+type params struct {
+	X, Y, W, H int
+	Alphabet   string
+	Variable   bool
+	Marker     bool
+	// Threshold is the difference in gray level from the background color
+	// above which a pixel is part of a glyph, or 0 to guess from the colors
+	// in the image.
+	Threshold int
+}
+
+// flagParams returns the extraction parameters set by the command line flags.
+func flagParams() *params {
+	return &params{
+		X: *startX, Y: *startY, W: *width, H: *height,
+		Alphabet:  *alphabet,
+		Variable:  *varWidth,
+		Marker:    *markers,
+		Threshold: *threshold,
 	}
-	allLetters = make(map[rune]map[int]string)
-	maxWidth = 0
+}
+
+// extractMarked extracts glyphs from the crop region using the marker row above
+// it, in which each glyph starts at a column with an ink pixel and ends before
+// the next one. Trailing blank columns are trimmed from each glyph, and blank
+// glyphs such as spaces are skipped so that they use the advance of a missing
+// glyph. Glyphs are assigned the runes of the alphabet in order.
+func extractMarked(ink func(x, y int) bool, p *params) (map[rune]map[int]string, int, error) {
+	if p.Y < 1 {
+		return nil, 0, fmt.Errorf("-marker needs a marker row above the crop region, use -y to set its top")
+	}
+	var starts []int
+	for x := p.X; x < p.X+p.W; x++ {
+		if ink(x, p.Y-1) {
+			starts = append(starts, x)
+		}
+	}
+	if len(starts) == 0 {
+		return nil, 0, fmt.Errorf("no glyph markers found in row %d", p.Y-1)
+	}
+	if n := utf8.RuneCountInString(p.Alphabet); n != len(starts) {
+		fmt.Fprintf(os.Stderr, "found %d glyph markers but the alphabet has %d characters\n", len(starts), n)
+	}
+
+	allLetters := make(map[rune]map[int]string)
+	maxWidth := 0
+	alpha := p.Alphabet
+	for i, x0 := range starts {
+		if alpha == "" {
+			break
+		}
+		r, nbytes := utf8.DecodeRuneInString(alpha)
+		alpha = alpha[nbytes:]
+
+		x1 := p.X + p.W
+		if i+1 < len(starts) {
+			x1 = starts[i+1]
+		}
+		rows := make([][]byte, p.H)
+		w := 0
+		for yy := range rows {
+			rows[yy] = make([]byte, x1-x0)
+			for xx := range rows[yy] {
+				rows[yy][xx] = ' '
+				if ink(x0+xx, p.Y+yy) {
+					rows[yy][xx] = 'X'
+					if xx+1 > w {
+						w = xx + 1
+					}
+				}
+			}
+		}
+		if w == 0 {
+			continue
+		}
+		letter := make(map[int]string, p.H)
+		for yy, row := range rows {
+			letter[yy] = string(row[:w])
+		}
+		allLetters[r] = letter
+		if w > maxWidth {
+			maxWidth = w
+		}
+	}
+	return allLetters, maxWidth, nil
+}
+
+// extractImage extracts glyphs from img as set by p, and returns them along
+// with the widest glyph's width. A zero width or height in p is set to extend
+// to the edge of the image.
+func extractImage(img image.Image, p *params) (map[rune]map[int]string, int, error) {
+	if p.W < 0 || p.H < 0 {
+		return nil, 0, fmt.Errorf("the crop region can't be %dx%d pixels", p.W, p.H)
+	}
+	if p.W == 0 {
+		p.W = img.Bounds().Dx() - p.X
+	}
+	if p.H == 0 {
+		p.H = img.Bounds().Dy() - p.Y
+	}
+	allLetters := make(map[rune]map[int]string)
+	maxWidth := 0
 
 	// generate a greyscale histogram of the image
 	pxc := 0
 	clrs := make(map[uint8]int)
+	var bg uint8 // most common gray level
 	for y := 0; y < img.Bounds().Dy(); y++ {
 		for x := 0; x < img.Bounds().Dx(); x++ {
 			c := img.At(x, y)
 			gc := color.GrayModel.Convert(c).(color.Gray)
 			clrs[gc.Y]++
 			pxc++
+			if clrs[gc.Y] > clrs[bg] {
+				bg = gc.Y
+			}
 		}
 	}
 	// find a threshold pixel count for what colors to ignore as background
@@ -218,20 +403,33 @@ func processImage(filename string) (allLetters map[rune]map[int]string, maxWidth
 		}
 	}
 
+	// ink reports whether the pixel at x,y is part of a glyph, rather than the
+	// background
+	ink := func(x, y int) bool {
+		gc := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+		if p.Threshold > 0 {
+			d := int(gc.Y) - int(bg)
+			return d > p.Threshold || -d > p.Threshold
+		}
+		return clrs[gc.Y] <= pxt
+	}
+
+	if p.Marker {
+		return extractMarked(ink, p)
+	}
+
 	// scan across the image in the crop region, saving pixels as you go.
 	// if at any point we see an "empty" column of pixels, we assume it
 	// is a character boundary and move to the next alphabet letter.
-	curAlpha := *alphabet
+	curAlpha := p.Alphabet
 	curWidth := 0
 	curLetter := make(map[int]string)
-	for x := *startX; x < *startX+*width; x++ {
+	for x := p.X; x < p.X+p.W; x++ {
 		curWidth++
 		isEmpty := true
 		ay := 0
-		for y := *startY; y < *startY+*height; y++ {
-			c := img.At(x, y)
-			gc := color.GrayModel.Convert(c).(color.Gray)
-			if clrs[gc.Y] <= pxt {
+		for y := p.Y; y < p.Y+p.H; y++ {
+			if ink(x, y) {
 				if _, haveDots := curLetter[ay]; !haveDots {
 					curLetter[ay] = strings.Repeat(" ", curWidth-1)
 				}
@@ -266,75 +464,107 @@ func processImage(filename string) (allLetters map[rune]map[int]string, maxWidth
 			curLetter = make(map[int]string)
 		}
 	}
+	return allLetters, maxWidth, nil
+}
+
+func processImage(filename string) (allLetters map[rune]map[int]string, maxWidth int) {
+	f, err := openInput(filename)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err.Error())
+		return nil, 0
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprint(os.Stderr, err.Error())
+		return nil, 0
+	}
+	p := flagParams()
+	allLetters, maxWidth, err = extractImage(img, p)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil, 0
+	}
+	*width, *height = p.W, p.H
+
+	maxWidth, err = filterRanges(allLetters, maxWidth)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil, 0
+	}
 
 	if *outName != "" {
 		return
 	}
+	writeText(os.Stdout, allLetters, maxWidth, p)
+	return
+}
 
-	// output a simple text representation of the extracted characters
-	for _, a := range *alphabet {
-		if l, found := allLetters[a]; found {
-			w := 0
-			for yy := 0; yy < *height; yy++ {
-				if len(l[yy]) > w {
-					w = len(l[yy])
-				}
+// writeText writes a simple text representation of the extracted characters
+// to w. Glyphs of fixed width fonts are centered in place.
+func writeText(w io.Writer, allLetters map[rune]map[int]string, maxWidth int, p *params) {
+	tf := &textfmt.Font{
+		Name:      fontMeta.Name,
+		Copyright: fontMeta.Copyright,
+		Comment:   fontMeta.Comment,
+		Width:     maxWidth,
+		Height:    p.H,
+		Order:     []rune(p.Alphabet),
+		Glyphs:    make(map[rune]map[int]string, len(allLetters)),
+	}
+	for _, a := range tf.Order {
+		l, found := allLetters[a]
+		if !found || tf.Glyphs[a] != nil {
+			continue
+		}
+		lw := 0
+		for yy := 0; yy < p.H; yy++ {
+			if len(l[yy]) > lw {
+				lw = len(l[yy])
 			}
+		}
 
-			leftPad := (maxWidth - w) / 2
-			if *varWidth {
-				leftPad = 0
-			}
-			for yy := 0; yy < *height; yy++ {
-				l[yy] = strings.Repeat(" ", leftPad) + l[yy]
-				fmt.Printf("%c  [%*s]\n", a, -maxWidth, l[yy])
-			}
+		leftPad := (maxWidth - lw) / 2
+		if p.Variable {
+			leftPad = 0
 		}
+		for yy := 0; yy < p.H; yy++ {
+			l[yy] = strings.Repeat(" ", leftPad) + l[yy]
+		}
+		tf.Glyphs[a] = l
+	}
+	if err := textfmt.Format(w, tf); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
 	}
-	return
 }
 
 func processText(filename string) (allLetters map[rune]map[int]string, maxWidth int) {
-	newalpha := ""
-	input, err := ioutil.ReadFile(filename)
+	f, err := openInput(filename)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		return nil, 0
 	}
-	allLetters = make(map[rune]map[int]string)
-	re := regexp.MustCompile(`[^\n]*\n`)
-	count := 0
-	hh, maxHeight := 0, 0
-	lastCh := rune(0)
-
-	for _, bline := range re.FindAll(input, -1) {
-		line := string(bline)
-		c, pixoffs := utf8.DecodeRuneInString(line)
-		pixoffs += 3
-		if lastCh != c {
-			count = 0
-			hh = len(allLetters[lastCh])
-			if hh > maxHeight {
-				maxHeight = hh
-			}
-			allLetters[c] = make(map[int]string)
-			newalpha += string(c)
-		}
-		ww := len(line) - (pixoffs + 2)
-		if ww > maxWidth {
-			maxWidth = ww
-		}
-		allLetters[c][count] = line[pixoffs : pixoffs+ww]
-		lastCh = c
-		count++
+	tf, err := textfmt.Parse(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil, 0
 	}
+	fontMeta.Name, fontMeta.Copyright, fontMeta.Comment = tf.Name, tf.Copyright, tf.Comment
+	allLetters, maxWidth = tf.Glyphs, tf.Width
 
-	*alphabet = newalpha
+	*alphabet = string(tf.Order)
 	if *width == 0 {
 		*width = maxWidth
 	}
 	if *height == 0 {
-		*height = maxHeight
+		*height = tf.Height
+	}
+
+	maxWidth, err = filterRanges(allLetters, maxWidth)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil, 0
 	}
 
 	if *outName != "" {
@@ -342,43 +572,100 @@ func processText(filename string) (allLetters map[rune]map[int]string, maxWidth
 	}
 
 	// output the same representation again, to allow user to verify it was parsed correctly
-	for _, a := range *alphabet {
-		if l, found := allLetters[a]; found {
-			w := 0
-			for yy := 0; yy < *height; yy++ {
-				if len(l[yy]) > w {
-					w = len(l[yy])
-				}
-			}
-
-			leftPad := (maxWidth - w) / 2
-			for yy := 0; yy < *height; yy++ {
-				l[yy] = strings.Repeat(" ", leftPad) + l[yy]
-				fmt.Printf("%c  [%*s]\n", a, -maxWidth, l[yy])
-			}
-		}
-	}
+	writeText(os.Stdout, allLetters, maxWidth, &params{H: *height, Alphabet: *alphabet})
 	return
 }
 
 func main() {
 	flag.Parse()
 
-	allLetters := make(map[rune]map[int]string)
-	maxWidth := 0
+	if *manifest != "" {
+		if err := runManifest(*manifest); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
 
-	if *imageName != "" {
-		allLetters, maxWidth = processImage(*imageName)
-	} else if *textName != "" {
-		allLetters, maxWidth = processText(*textName)
-	} else {
+	if *update != "" {
+		if err := runUpdate(*update); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if _, ok := langExt[*outLang]; !ok {
+		fmt.Fprintln(os.Stderr, "unknown -lang:", *outLang)
+		flag.Usage()
+		return
+	}
+
+	if *serveAddr != "" {
+		if *imageName == "" {
+			fmt.Fprintln(os.Stderr, "-serve needs an -img to extract from")
+			flag.Usage()
+			return
+		}
+		if err := serve(*serveAddr, *imageName); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *imageName == "" && *textName == "" {
 		fmt.Fprintln(os.Stderr, "-img or -txt should be provided")
 		flag.Usage()
 		return
 	}
+	generate()
+}
+
+// generate extracts the font from the -img or -txt flag, and writes the output
+// set by the other flags. It reports whether it succeeded.
+func generate() bool {
+	if *compress && (*sorted || *rom) {
+		fmt.Fprintln(os.Stderr, "-compress can't be used with -sorted or -rom")
+		return false
+	}
+	var allLetters map[rune]map[int]string
+	var maxWidth int
+	if *imageName != "" {
+		allLetters, maxWidth = processImage(*imageName)
+	} else {
+		allLetters, maxWidth = processText(*textName)
+	}
+	if allLetters == nil {
+		return false
+	}
 
 	if *outName != "" {
-		generatePixFont(*outName, maxWidth, *height, *varWidth, allLetters)
-		fmt.Fprintln(os.Stderr, "Created package file:", *outName+".go")
+		if err := generatePixFont(*outName, maxWidth, *height, *varWidth, allLetters); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return false
+		}
+		if *outName != "-" {
+			fmt.Fprintln(os.Stderr, "Created package file:", *outName+langExt[*outLang])
+		}
+	}
+
+	if *previewMode != "" {
+		encoded, cm, err := packFont(maxWidth, *height, allLetters)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return false
+		}
+		fnt := pixfont.NewPixFont(uint8(maxWidth), uint8(*height), cm, encoded)
+		fnt.SetVariableWidth(*varWidth)
+		// keep the generated code on stdout separate from the preview
+		out := os.Stdout
+		if *outName == "-" {
+			out = os.Stderr
+		}
+		if err := writePreview(out, *previewMode, fnt, *alphabet); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
 	}
+	return true
 }
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testGlyphs returns 3x3 glyphs for 'I' and 'T'.
+func testGlyphs() map[rune]map[int]string {
+	return map[rune]map[int]string{
+		'I': {0: "XXX", 1: " X ", 2: "XXX"},
+		'T': {0: "XXX", 1: " X ", 2: " X "},
+	}
+}
+
+// generated runs generatePixFont for testGlyphs in a temporary directory, and
+// returns the created file.
+func generated(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "fontgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "tiny")
+	if err := generatePixFont(name, 3, 3, false, testGlyphs()); err != nil {
+		t.Fatal(err)
+	}
+	src, err := ioutil.ReadFile(name + langExt[*outLang])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(src)
+}
+
+func TestGenerateMetadata(t *testing.T) {
+	defer func(lang string) { *outLang = lang }(*outLang)
+	defer func(meta struct{ Name, Copyright, Comment string }) { fontMeta = meta }(fontMeta)
+	fontMeta.Name, fontMeta.Copyright, fontMeta.Comment = "Tiny", "Public domain", "two\nlines"
+
+	*outLang = "go"
+	src := generated(t)
+	for _, want := range []string{
+		"package tiny\n",
+		"\tFont.Name = \"Tiny\"\n",
+		"\tFont.Copyright = \"Public domain\"\n",
+		"\tFont.Comment = \"two\\nlines\"\n",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q in:\n%s", want, src)
+		}
+	}
+
+	*outLang = "js"
+	src = generated(t)
+	if !strings.Contains(src, "// Name: Tiny\n// Copyright: Public domain\n// Comment: two\n// Comment: lines\n") {
+		t.Errorf("metadata missing from:\n%s", src)
+	}
+
+	fontMeta.Name, fontMeta.Copyright, fontMeta.Comment = "", "", ""
+	*outLang = "go"
+	if src := generated(t); strings.Contains(src, "Font.Name") || strings.Contains(src, "Font.Comment") {
+		t.Errorf("empty metadata is written:\n%s", src)
+	}
+}
+
+// textImage returns an image drawn by rows, with '#' for black pixels and
+// white for anything else.
+func textImage(rows ...string) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			img.SetGray(x, y, color.Gray{0xff})
+			if c == '#' {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+	return img
+}
+
+func TestExtractMarked(t *testing.T) {
+	// touching glyphs, a blank glyph and a glyph ending at the image edge
+	img := textImage(
+		" #   #  # # ",
+		" ######   ##",
+		" #  ## #  # ",
+		" ######   ##",
+		"            ", // mostly white, so the background is white
+		"            ",
+	)
+	p := &params{Y: 1, H: 3, Alphabet: "AB C", Marker: true, Threshold: 100}
+	letters, maxWidth, err := extractImage(img, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[rune]map[int]string{
+		'A': {0: "XXXX", 1: "X  X", 2: "XXXX"},
+		'B': {0: "XX ", 1: "X X", 2: "XX "},
+		'C': {0: "XX", 1: "X ", 2: "XX"},
+	}
+	if !reflect.DeepEqual(letters, want) || maxWidth != 4 {
+		t.Errorf("got %v, width %d\nwant %v, width 4", letters, maxWidth, want)
+	}
+
+	for _, p := range []*params{
+		{Y: 0, H: 3, Alphabet: "A", Marker: true, Threshold: 100},             // no marker row
+		{X: 2, W: 3, Y: 1, H: 3, Alphabet: "A", Marker: true, Threshold: 100}, // no markers
+	} {
+		if _, _, err := extractImage(img, p); err == nil {
+			t.Errorf("no error for %+v", p)
+		}
+	}
+}
+
+// saveFlags returns a function which restores the command line flags and font
+// metadata to their current values.
+func saveFlags() func() {
+	saved := make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) { saved[f.Name] = f.Value.String() })
+	meta := fontMeta
+	return func() {
+		for name, value := range saved {
+			flag.Set(name, value)
+		}
+		fontMeta = meta
+	}
+}
+
+// withStdio runs fn with standard input reading in, and returns what it wrote
+// to standard output.
+func withStdio(t *testing.T, in string, fn func()) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "fontgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stdin, err := os.Open(writeTemp(t, dir, "stdin", in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+
+	defer func(in, out *os.File) { os.Stdin, os.Stdout = in, out }(os.Stdin, os.Stdout)
+	os.Stdin, os.Stdout = stdin, stdout
+	fn()
+	out, err := ioutil.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// writeTemp writes a file in dir, and returns its path.
+func writeTemp(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// tinyText is a text font with glyphs for 'I' and 'T'.
+const tinyText = `# Name: Tiny
+I  [XXX]
+I  [ X ]
+I  [XXX]
+
+T  [XXX]
+T  [ X ]
+T  [ X ]
+`
+
+func TestPipeline(t *testing.T) {
+	defer saveFlags()()
+	flag.Set("txt", "-")
+	flag.Set("o", "-")
+	var ok bool
+	out := withStdio(t, tinyText, func() { ok = generate() })
+	if !ok {
+		t.Fatal("generate failed")
+	}
+	for _, want := range []string{"\npackage font\n", "pixfont.NewPixFont(3, 3,", "Font.Name = \"Tiny\""} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	flag.Set("pkg", "tiny")
+	out = withStdio(t, tinyText, func() { generate() })
+	if !strings.Contains(out, "\npackage tiny\n") {
+		t.Errorf("-pkg is not used:\n%s", out)
+	}
+
+	flag.Set("lang", "py")
+	out = withStdio(t, tinyText, func() { generate() })
+	if !strings.HasPrefix(out, "# Code generated by pixfont; DO NOT EDIT.\n# Name: Tiny\n") {
+		t.Errorf("python module is:\n%s", out)
+	}
+
+	// without -o, the parsed text font is written back out
+	flag.Set("o", "")
+	out = withStdio(t, tinyText, func() { generate() })
+	if !strings.HasPrefix(out, "# Name: Tiny\n") || !strings.Contains(out, "T  [ X ]\n") {
+		t.Errorf("text font is:\n%s", out)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	defer saveFlags()()
+	dir, err := ioutil.TempDir("", "fontgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTemp(t, dir, "tiny.txt", tinyText)
+	writeTemp(t, dir, "plain.txt", strings.Replace(tinyText, "# Name: Tiny\n", "", 1))
+	manifest := writeTemp(t, dir, "fonts.json", `{"fonts": [
+		{"txt": "tiny.txt", "output": "tiny", "sorted": true},
+		{"txt": "plain.txt", "output": "plain", "lang": "js"}
+	]}`)
+	if err := runManifest(manifest); err != nil {
+		t.Fatal(err)
+	}
+	src, err := ioutil.ReadFile(filepath.Join(dir, "tiny.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "pixfont.NewSortedPixFont(3, 3,") || !strings.Contains(string(src), `Font.Name = "Tiny"`) {
+		t.Errorf("tiny.go is:\n%s", src)
+	}
+	src, err = ioutil.ReadFile(filepath.Join(dir, "plain.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "export const Font = {") || strings.Contains(string(src), "Tiny") {
+		t.Errorf("plain.js is:\n%s", src)
+	}
+
+	for _, m := range []string{
+		`{"fonts": [`,
+		`{"fonts": []}`,
+		`{"fonts": [{"output": "x"}]}`,
+		`{"fonts": [{"txt": "tiny.txt", "img": "tiny.png", "output": "x"}]}`,
+		`{"fonts": [{"txt": "tiny.txt"}]}`,
+		`{"fonts": [{"txt": "tiny.txt", "output": "x", "lang": "c"}]}`,
+		`{"fonts": [{"txt": "missing.txt", "output": "x"}]}`,
+	} {
+		if err := runManifest(writeTemp(t, dir, "bad.json", m)); err == nil {
+			t.Errorf("no error for %s", m)
+		}
+	}
+	if err := runManifest(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("no error for a missing manifest")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	restore := saveFlags()
+	defer restore()
+	dir, err := ioutil.TempDir("", "fontgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTemp(t, dir, "tiny.txt", tinyText)
+	directives := "//fontgen:flags -txt tiny.txt\n//fontgen:flags -rom\n//go:generate fontgen -update $GOFILE\n"
+	path := writeTemp(t, dir, "tiny.go", directives+"package tinyfont\n")
+
+	if err := runUpdate(path); err != nil {
+		t.Fatal(err)
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{directives + "package tinyfont\n", "pixfont.NewStringPixFont(3, 3,"} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q in:\n%s", want, src)
+		}
+	}
+
+	// updating again keeps the directives once, and changes nothing
+	if err := runUpdate(path); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(src) {
+		t.Errorf("second update changed the file to:\n%s", again)
+	}
+
+	for _, src := range []string{
+		"package tinyfont\n",
+		"//fontgen:flags -v\npackage tinyfont\n",
+		"//fontgen:flags -txt \"tiny.txt\npackage tinyfont\n",
+		"//fontgen:flags -txt missing.txt\npackage tinyfont\n",
+	} {
+		restore()
+		if err := runUpdate(writeTemp(t, dir, "bad.go", src)); err == nil {
+			t.Errorf("no error for %q", src)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	args, err := splitArgs(` -a "A B\"C"  -x=1	-t "" `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"-a", `A B"C`, "-x=1", "-t", ""}; !reflect.DeepEqual(args, want) {
+		t.Errorf("got %q, want %q", args, want)
+	}
+	for _, s := range []string{`-a "AB`, `-a "\q"`} {
+		if _, err := splitArgs(s); err == nil {
+			t.Errorf("no error for %s", s)
+		}
+	}
+}
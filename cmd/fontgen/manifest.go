@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// manifestFont describes one font in a -manifest file. The fields match the
+// command line flags of the same names, and paths are relative to the
+// directory containing the manifest.
+type manifestFont struct {
+	Img       string `json:"img"`
+	Txt       string `json:"txt"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	W         int    `json:"w"`
+	H         int    `json:"h"`
+	Alphabet  string `json:"alphabet"`
+	Variable  bool   `json:"variable"`
+	Threshold int    `json:"threshold"`
+	Marker    bool   `json:"marker"`
+	Range     string `json:"range"`
+	Output    string `json:"output"`
+	Lang      string `json:"lang"`
+	Package   string `json:"pkg"`
+	Compress  bool   `json:"compress"`
+	Sorted    bool   `json:"sorted"`
+	ROM       bool   `json:"rom"`
+}
+
+// runManifest generates every font described by the manifest file filename,
+// which holds a JSON object with a "fonts" array, such as:
+//
+//	{"fonts": [
+//	    {"img": "minecraftia.png", "x": 1, "y": 20, "h": 8, "variable": true, "output": "minecraftia"},
+//	    {"txt": "unifont.txt", "range": "U+0020-007E", "output": "unifont/unifont", "pkg": "unifont"}
+//	]}
+//
+// Fonts are generated in order, stopping at the first which fails.
+func runManifest(filename string) error {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var m struct {
+		Fonts []manifestFont `json:"fonts"`
+	}
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	if len(m.Fonts) == 0 {
+		return fmt.Errorf("%s: no fonts listed", filename)
+	}
+
+	dir := filepath.Dir(filename)
+	rel := func(path string) string {
+		if path == "" || path == "-" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	for i, mf := range m.Fonts {
+		if (mf.Img == "") == (mf.Txt == "") {
+			return fmt.Errorf("%s: font %d needs one of img or txt", filename, i+1)
+		}
+		if mf.Output == "" {
+			return fmt.Errorf("%s: font %d needs an output", filename, i+1)
+		}
+		if mf.Lang == "" {
+			mf.Lang = "go"
+		}
+		if _, ok := langExt[mf.Lang]; !ok {
+			return fmt.Errorf("%s: font %d has unknown lang %q", filename, i+1, mf.Lang)
+		}
+		if mf.Alphabet == "" {
+			mf.Alphabet = flag.Lookup("a").DefValue
+		}
+
+		*imageName, *textName = rel(mf.Img), rel(mf.Txt)
+		*startX, *startY, *width, *height = mf.X, mf.Y, mf.W, mf.H
+		*alphabet, *varWidth, *threshold, *markers = mf.Alphabet, mf.Variable, mf.Threshold, mf.Marker
+		*runeRanges, *outName, *outLang = mf.Range, rel(mf.Output), mf.Lang
+		*pkgName, *compress, *sorted, *rom = mf.Package, mf.Compress, mf.Sorted, mf.ROM
+		fontMeta.Name, fontMeta.Copyright, fontMeta.Comment = "", "", ""
+
+		if !generate() {
+			return fmt.Errorf("%s: font %d could not be generated", filename, i+1)
+		}
+	}
+	return nil
+}
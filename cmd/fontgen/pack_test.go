@@ -166,7 +166,10 @@ var packTestCases = []*PackTestCase{
 func TestGlyphPacking(t *testing.T) {
 	for _, c := range packTestCases {
 		t.Run(fmt.Sprintf("%dx%d", c.Width, c.Height), func(t *testing.T) {
-			encoded, _ := packFont(c.Width, c.Height, c.Letters)
+			encoded, _, err := packFont(c.Width, c.Height, c.Letters)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if len(c.ExpectedEncoding) != len(encoded) {
 				t.Fatalf("Expected to find %d lines in encoding, but found %d", len(c.ExpectedEncoding), len(encoded))
 			}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/preview"
+)
+
+// previewScale enlarges specimens so that they are legible on high-DPI terminals.
+const previewScale = 2
+
+// scaledDrawable draws each pixel as a square block of pixels.
+type scaledDrawable struct {
+	img   *image.Paletted
+	scale int
+}
+
+func (s *scaledDrawable) Set(x, y int, c color.Color) {
+	for yy := 0; yy < s.scale; yy++ {
+		for xx := 0; xx < s.scale; xx++ {
+			s.img.Set(x*s.scale+xx, y*s.scale+yy, c)
+		}
+	}
+}
+
+// writePreview renders a specimen of the alphabet using the extracted font and
+// writes it to w using the named inline image protocol.
+func writePreview(w io.Writer, mode string, fnt *pixfont.PixFont, alpha string) error {
+	var enc func(io.Writer, image.Image) error
+	switch mode {
+	case "sixel":
+		enc = preview.Sixel
+	case "kitty":
+		enc = preview.Kitty
+	case "iterm2":
+		enc = preview.ITerm2
+	default:
+		return fmt.Errorf("unknown preview mode %q", mode)
+	}
+
+	return enc(w, specimen(fnt, alpha))
+}
+
+// specimen renders the alphabet using the extracted font, enlarged by
+// previewScale.
+func specimen(fnt *pixfont.PixFont, alpha string) image.Image {
+	// split the alphabet into lines of at most 32 characters
+	var lines []string
+	runes := []rune(alpha)
+	for len(runes) > 32 {
+		lines = append(lines, string(runes[:32]))
+		runes = runes[32:]
+	}
+	lines = append(lines, string(runes))
+
+	width := 0
+	for _, ln := range lines {
+		if lw := fnt.MeasureString(ln); lw > width {
+			width = lw
+		}
+	}
+	lineHeight := fnt.GetHeight() + 2
+	b := image.Rect(0, 0, (width+4)*previewScale, (len(lines)*lineHeight+2)*previewScale)
+	img := image.NewPaletted(b, color.Palette{color.White, color.Black})
+
+	sd := &scaledDrawable{img, previewScale}
+	for i, ln := range lines {
+		fnt.DrawString(sd, 2, 2+i*lineHeight, ln, color.Black)
+	}
+	return img
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// servePage is the -serve page. It reloads itself with the new parameters
+// shortly after any of them are changed.
+var servePage = template.Must(template.New("serve").Parse(`<!DOCTYPE html>
+<html><head><title>fontgen</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+img { image-rendering: pixelated; border: 1px solid #ccc; }
+label { display: inline-block; margin-right: 1em; }
+input[type=number] { width: 5em; }
+pre { background: #f4f4f4; padding: 1em; max-height: 30em; overflow: auto; }
+.error { color: #c00; }
+</style></head>
+<body>
+<h1>fontgen: {{.File}}</h1>
+<form method="get" oninput="clearTimeout(this.t); this.t = setTimeout(() => this.submit(), 500)">
+<p>
+<label>x <input type="number" name="x" value="{{.P.X}}"></label>
+<label>y <input type="number" name="y" value="{{.P.Y}}"></label>
+<label>w <input type="number" name="w" value="{{.P.W}}"></label>
+<label>h <input type="number" name="h" value="{{.P.H}}"></label>
+<label>threshold <input type="number" name="t" value="{{.P.Threshold}}" min="0" max="255"></label>
+<label><input type="checkbox" name="v" value="1" {{if .P.Variable}}checked{{end}}> variable width</label>
+<label><input type="checkbox" name="marker" value="1" {{if .P.Marker}}checked{{end}}> marker row</label>
+</p>
+<p><label>alphabet <input type="text" name="a" value="{{.P.Alphabet}}" size="80"></label></p>
+</form>
+<h2>Source</h2>
+<img src="/source" width="{{.SourceWidth}}">
+<h2>Extracted</h2>
+{{if .Error}}<p class="error">{{.Error}}</p>{{else}}
+<p><img src="/specimen?{{.Query}}"></p>
+<form method="post" action="/save?{{.Query}}"><input type="hidden" name="token" value="{{.Token}}"><button>Save {{.Output}}</button></form>
+<p><code>{{.Command}}</code></p>
+<pre>{{.Text}}</pre>
+{{end}}
+</body></html>
+`))
+
+// serve runs a web page on addr for adjusting the extraction parameters for the
+// image in filename interactively, showing the glyphs extracted each time they
+// change. Saving writes the output just as running fontgen with the same flags
+// would, and is only accepted from the page itself, which carries a token
+// made when the server starts, so that other sites can't write files.
+func serve(addr, filename string) error {
+	f, err := openInput(filename)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	var tok [16]byte
+	if _, err := rand.Read(tok[:]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Serving %s on http://%s/\n", filename, addr)
+	return http.ListenAndServe(addr, serveMux(filename, img, hex.EncodeToString(tok[:])))
+}
+
+// serveMux returns the handler for the -serve page for img, read from
+// filename, which only saves when a request has the given token.
+func serveMux(filename string, img image.Image, token string) *http.ServeMux {
+	// extract returns the glyphs for the parameters in the request's query,
+	// starting from those set on the command line.
+	extract := func(r *http.Request) (*params, map[rune]map[int]string, int, error) {
+		p := flagParams()
+		if q := r.URL.Query(); len(q) > 0 {
+			num := func(key string) int {
+				n, _ := strconv.Atoi(q.Get(key))
+				return n
+			}
+			p = &params{
+				X: num("x"), Y: num("y"), W: num("w"), H: num("h"),
+				Alphabet:  q.Get("a"),
+				Variable:  q.Get("v") != "",
+				Marker:    q.Get("marker") != "",
+				Threshold: num("t"),
+			}
+		}
+		if err := fitParams(p, img.Bounds()); err != nil {
+			return p, nil, 0, err
+		}
+		allLetters, maxWidth, err := extractImage(img, p)
+		if err == nil {
+			maxWidth, err = filterRanges(allLetters, maxWidth)
+		}
+		if err == nil && (maxWidth == 0 || maxWidth > 32) {
+			err = fmt.Errorf("glyphs must be 1 to 32 pixels wide, the widest found is %d", maxWidth)
+		}
+		return p, allLetters, maxWidth, err
+	}
+	makeFont := func(p *params, allLetters map[rune]map[int]string, maxWidth int) (*pixfont.PixFont, error) {
+		encoded, cm, err := packFont(maxWidth, p.H, allLetters)
+		if err != nil {
+			return nil, err
+		}
+		fnt := pixfont.NewPixFont(uint8(maxWidth), uint8(p.H), cm, encoded)
+		fnt.SetVariableWidth(p.Variable)
+		return fnt, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		p, allLetters, maxWidth, err := extract(r)
+		data := map[string]interface{}{
+			"File":        filename,
+			"P":           p,
+			"SourceWidth": img.Bounds().Dx() * previewScale,
+			"Query":       template.URL(r.URL.RawQuery),
+			"Output":      "to standard output",
+			"Command":     commandLine(filename, p),
+			"Token":       token,
+		}
+		if *outName != "" {
+			data["Output"] = *outName + langExt[*outLang]
+		}
+		if err != nil {
+			data["Error"] = err.Error()
+		} else {
+			var buf bytes.Buffer
+			writeText(&buf, allLetters, maxWidth, p)
+			data["Text"] = buf.String()
+		}
+		servePage.Execute(w, data)
+	})
+	mux.HandleFunc("/source", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, img)
+	})
+	mux.HandleFunc("/specimen", func(w http.ResponseWriter, r *http.Request) {
+		p, allLetters, maxWidth, err := extract(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fnt, err := makeFont(p, allLetters, maxWidth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, specimen(fnt, p.Alphabet))
+	})
+	mux.HandleFunc("/save", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "saving needs a POST request", http.StatusMethodNotAllowed)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.PostFormValue("token")), []byte(token)) != 1 {
+			http.Error(w, "saving is only allowed from the fontgen page", http.StatusForbidden)
+			return
+		}
+		p, allLetters, maxWidth, err := extract(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if *outName == "" {
+			writeText(os.Stdout, allLetters, maxWidth, p)
+			fmt.Fprintln(w, "Wrote the text representation to standard output.")
+		} else {
+			if err := generatePixFont(*outName, maxWidth, p.H, p.Variable, allLetters); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintln(w, "Created package file:", *outName+langExt[*outLang])
+		}
+		fmt.Fprintln(w, "\nTo do this again, run:\n\n"+commandLine(filename, p))
+		fmt.Fprintln(os.Stderr, "Saved with:", commandLine(filename, p))
+	})
+	return mux
+}
+
+// fitParams limits the crop region of p to the image bounds b, extending a zero
+// width or height to the edge of the image as extractImage would. It returns an
+// error if the region starts outside of the image or the other parameters are
+// out of range, so that a request can't make fontgen allocate more than the
+// image.
+func fitParams(p *params, b image.Rectangle) error {
+	if p.X < 0 || p.Y < 0 || p.X >= b.Dx() || p.Y >= b.Dy() {
+		return fmt.Errorf("the crop region starts at %d,%d, outside the %dx%d image", p.X, p.Y, b.Dx(), b.Dy())
+	}
+	if p.W < 0 || p.H < 0 {
+		return fmt.Errorf("the crop region can't be %dx%d pixels", p.W, p.H)
+	}
+	if p.W == 0 || p.X+p.W > b.Dx() {
+		p.W = b.Dx() - p.X
+	}
+	if p.H == 0 || p.Y+p.H > b.Dy() {
+		p.H = b.Dy() - p.Y
+	}
+	if p.H > 255 {
+		return fmt.Errorf("the crop region is %d pixels tall, fonts can be at most 255", p.H)
+	}
+	if p.Threshold < 0 || p.Threshold > 255 {
+		return fmt.Errorf("the threshold must be 0 to 255, not %d", p.Threshold)
+	}
+	return nil
+}
+
+// commandLine returns the fontgen command which extracts glyphs from filename
+// using p.
+func commandLine(filename string, p *params) string {
+	cmd := fmt.Sprintf("fontgen -img %q -x=%d -y=%d -w=%d -h=%d -a=%q", filename, p.X, p.Y, p.W, p.H, p.Alphabet)
+	if p.Threshold != 0 {
+		cmd += fmt.Sprintf(" -t=%d", p.Threshold)
+	}
+	if p.Variable {
+		cmd += " -v"
+	}
+	if p.Marker {
+		cmd += " -marker"
+	}
+	if *outName != "" {
+		cmd += " -o " + *outName
+	}
+	return cmd
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveImage returns a 9x5 image with glyphs for "IL" starting at 1,1.
+func serveImage() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 9, 5))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 1; y < 4; y++ {
+		img.SetGray(1, y, color.Gray{}) // I
+		img.SetGray(3, y, color.Gray{}) // L
+	}
+	img.SetGray(4, 3, color.Gray{})
+	img.SetGray(5, 3, color.Gray{})
+	return img
+}
+
+func TestFitParams(t *testing.T) {
+	b := image.Rect(0, 0, 100, 300)
+	for _, p := range []params{
+		{X: -1},
+		{Y: 300},
+		{W: -1},
+		{H: -8},
+		{Threshold: 256},
+		{Threshold: -1},
+		{H: 0}, // to the bottom of the image, taller than a font can be
+	} {
+		p := p
+		if err := fitParams(&p, b); err == nil {
+			t.Errorf("no error for %+v", p)
+		}
+	}
+
+	p := params{X: 90, Y: 10, W: 1000000, H: 1000000}
+	if err := fitParams(&p, image.Rect(0, 0, 100, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if p.W != 10 || p.H != 10 {
+		t.Errorf("crop region is %dx%d, want 10x10", p.W, p.H)
+	}
+}
+
+func TestServe(t *testing.T) {
+	defer func(name string) { *outName = name }(*outName)
+	dir, err := ioutil.TempDir("", "fontgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	*outName = filepath.Join(dir, "font")
+	srv := httptest.NewServer(serveMux("test.png", serveImage(), "secret"))
+	defer srv.Close()
+
+	for query, want := range map[string]int{
+		"x=1&y=1&h=3&a=IL":         http.StatusOK,
+		"x=1&y=1&h=-3&a=IL":        http.StatusBadRequest,
+		"x=1&y=1&w=-9&a=IL":        http.StatusBadRequest,
+		"x=1&y=1&h=100000000&a=IL": http.StatusOK,
+		"x=100&y=1&h=3&a=IL":       http.StatusBadRequest,
+	} {
+		resp, err := http.Get(srv.URL + "/specimen?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("specimen for %s: status %d, want %d", query, resp.StatusCode, want)
+		}
+	}
+
+	save := srv.URL + "/save?x=1&y=1&h=3&a=IL"
+	for token, want := range map[string]int{
+		"":       http.StatusForbidden,
+		"wrong":  http.StatusForbidden,
+		"secret": http.StatusOK,
+	} {
+		resp, err := http.PostForm(save, url.Values{"token": {token}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("save with token %q: status %d, want %d", token, resp.StatusCode, want)
+		}
+	}
+	src, err := ioutil.ReadFile(*outName + ".go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "pixfont.NewPixFont(3, 3,") {
+		t.Errorf("saved font is not 3x3:\n%s", src)
+	}
+}
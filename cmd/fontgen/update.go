@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// flagsDirective starts the comment lines in a Go file which hold the flags to
+// regenerate the font in it with -update.
+const flagsDirective = "//fontgen:flags "
+
+// keepLines are the directive lines of an -update file, which are written again
+// before the package clause of the regenerated code.
+var keepLines []string
+
+// runUpdate regenerates the font in the Go file filename in place, using the
+// flags in its //fontgen:flags directives, such as:
+//
+//	//fontgen:flags -img minecraftia.png -x=1 -y=20 -h=8 -v
+//	//go:generate fontgen -update $GOFILE
+//	package minecraftia
+//
+// so that go generate refreshes the font. Paths are relative to the directory
+// of filename, and flags given on the command line apply unless the directives
+// change them. The directives are kept in the regenerated file.
+func runUpdate(filename string) error {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var args []string
+	keepLines = nil
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, flagsDirective) {
+			a, err := splitArgs(line[len(flagsDirective):])
+			if err != nil {
+				return fmt.Errorf("%s: %v", filename, err)
+			}
+			args = append(args, a...)
+			keepLines = append(keepLines, line)
+		} else if strings.HasPrefix(line, "//go:generate ") {
+			keepLines = append(keepLines, line)
+		}
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("%s: no %s directive found", filename, strings.TrimSpace(flagsDirective))
+	}
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	if *imageName == "" && *textName == "" {
+		return fmt.Errorf("%s: the directive needs -img or -txt", filename)
+	}
+
+	dir := filepath.Dir(filename)
+	for _, path := range []*string{imageName, textName} {
+		if *path != "" && *path != "-" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+	if *pkgName == "" {
+		if f, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.PackageClauseOnly); err == nil {
+			*pkgName = f.Name.Name
+		}
+	}
+	*outName, *outLang = strings.TrimSuffix(filename, ".go"), "go"
+
+	if !generate() {
+		return fmt.Errorf("%s: the font could not be generated", filename)
+	}
+	return nil
+}
+
+// splitArgs splits s into arguments at spaces, like a shell. Double quoted
+// parts of an argument may contain spaces and Go escape sequences.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated quoted string in %q", s)
+			}
+			q, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("bad quoted string %s", s[i:j+1])
+			}
+			arg.WriteString(q)
+			inArg = true
+			i = j
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,84 @@
+// Command pixfont-banner prints text as large letters made of characters, like
+// figlet, using any of the bundled pixel fonts. It is useful for build scripts
+// and MOTDs:
+//
+//	pixfont-banner -font 7x13 -c '#' Hello world
+//
+// If no text is given on the command line, it is read from standard input.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/fonts/fixed"
+)
+
+var fonts = map[string]*pixfont.PixFont{
+	"8x8":  pixfont.Font8x8,
+	"7x13": fixed.Font7x13,
+}
+
+var (
+	fontName = flag.String("font", "8x8", "font to use ("+fontNames()+")")
+	fill     = flag.String("c", "#", "character to draw pixels with")
+	width    = flag.Int("w", 80, "maximum output width in columns (0 for no limit)")
+	varWidth = flag.Bool("v", false, "draw using variable width characters")
+	double   = flag.Bool("2", false, "draw each pixel as two characters, for a squarer look in terminals")
+)
+
+func fontNames() string {
+	names := make([]string, 0, len(fonts))
+	for name := range fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "USAGE: %s [flags] [text...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	fnt, ok := fonts[*fontName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown font %q, choose one of: %s\n", *fontName, fontNames())
+		os.Exit(1)
+	}
+	if len([]rune(*fill)) != 1 {
+		fmt.Fprintln(os.Stderr, "-c must be a single character")
+		os.Exit(1)
+	}
+	fnt.SetVariableWidth(*varWidth)
+
+	text := strings.Join(flag.Args(), " ")
+	if flag.NArg() == 0 {
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		text = strings.TrimRight(string(input), "\n")
+	}
+
+	maxWidth := *width
+	if maxWidth <= 0 {
+		maxWidth = int(^uint(0) >> 1)
+	}
+	if *double {
+		maxWidth /= 2
+	}
+	sd := &pixfont.StringDrawable{On: []rune(*fill)[0], DoubleWidth: *double}
+	fnt.DrawStringWrapped(sd, 0, 0, text, maxWidth, nil)
+	for _, line := range strings.Split(strings.TrimRight(sd.String(), "\n"), "\n") {
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+}
@@ -0,0 +1,42 @@
+package pixfont
+
+import "image/color"
+
+// Columns renders s and returns one bitmask per pixel column, the native frame
+// format of flip-dot and many persistence-of-vision displays. No intermediate
+// image is created.
+//
+// If msbTop is true, the top row of the font is the most significant bit of a
+// GetHeight()-bit value; otherwise the top row is bit 0. Fonts taller than 64
+// pixels are truncated to their top 64 rows.
+func (p *PixFont) Columns(s string, msbTop bool) []uint64 {
+	h := int(p.charHeight)
+	if h > 64 {
+		h = 64
+	}
+	cd := &columnDrawable{
+		cols:   make([]uint64, p.MeasureString(s)),
+		height: h,
+		msbTop: msbTop,
+	}
+	p.DrawString(cd, 0, 0, s, color.Black)
+	return cd.cols
+}
+
+// columnDrawable accumulates opaque pixels into column bitmasks.
+type columnDrawable struct {
+	cols   []uint64
+	height int
+	msbTop bool
+}
+
+func (c *columnDrawable) Set(x, y int, _ color.Color) {
+	if x < 0 || x >= len(c.cols) || y < 0 || y >= c.height {
+		return
+	}
+	if c.msbTop {
+		c.cols[x] |= 1 << uint(c.height-1-y)
+	} else {
+		c.cols[x] |= 1 << uint(y)
+	}
+}
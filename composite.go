@@ -0,0 +1,46 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// compositor is an image which draws onto dst with source-over blending and
+// color keying, as set by the Blend and ColorKey draw options.
+type compositor struct {
+	image.Image
+	dst   Drawable
+	blend bool
+	key   color.Color
+}
+
+// composite returns dr wrapped to draw as set by opts, or dr itself if no
+// compositing is needed. Both options need to read the destination, so they
+// have no effect unless dr is also an image.Image.
+func composite(dr Drawable, opts *DrawOptions) Drawable {
+	img, ok := dr.(image.Image)
+	if !ok || (!opts.Blend && opts.ColorKey == nil) {
+		return dr
+	}
+	return &compositor{Image: img, dst: dr, blend: opts.Blend, key: opts.ColorKey}
+}
+
+// Set implements Drawable.
+func (c *compositor) Set(x, y int, clr color.Color) {
+	if c.key != nil && sameColor(c.At(x, y), c.key) {
+		return
+	}
+	if c.blend {
+		blend(c.dst, x, y, clr)
+		return
+	}
+	c.dst.Set(x, y, clr)
+}
+
+// sameColor reports whether a and b are the same color once converted to
+// 16-bit premultiplied RGBA.
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
@@ -0,0 +1,98 @@
+package pixfont
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// defaultBlockLen is the approximate number of uint32s in each compressed block
+// if CompressData isn't given a block length.
+const defaultBlockLen = 1024
+
+// CompressedData is packed font data, as from Pack, split into blocks which are
+// compressed separately. Large fonts such as CJK or Unifont can be stored this
+// way in generated code to make binaries much smaller, while only the blocks
+// which are actually drawn need to be decompressed.
+type CompressedData struct {
+	// Len is the number of uint32s in the packed data.
+	Len int
+	// BlockLen is the number of uint32s in each block, except perhaps the last.
+	BlockLen int
+	// Blocks holds the deflate-compressed blocks, as little-endian uint32s.
+	Blocks []string
+}
+
+// CompressData compresses packed data from Pack for a font h pixels tall, for
+// use with NewCompressedPixFont. Blocks hold about blockLen uint32s each
+// (rounded up so that glyphs aren't split between blocks), or 1024 if blockLen
+// is zero. Smaller blocks decompress faster but compress less well.
+func CompressData(h int, d []uint32, blockLen int) *CompressedData {
+	if blockLen <= 0 {
+		blockLen = defaultBlockLen
+	}
+	// glyphs occupy h consecutive uint32s starting at a multiple of h
+	blockLen = (blockLen + h - 1) / h * h
+
+	cd := &CompressedData{Len: len(d), BlockLen: blockLen}
+	var buf bytes.Buffer
+	zw, _ := flate.NewWriter(&buf, flate.BestCompression)
+	for i := 0; i < len(d); i += blockLen {
+		end := i + blockLen
+		if end > len(d) {
+			end = len(d)
+		}
+		buf.Reset()
+		zw.Reset(&buf)
+		binary.Write(zw, binary.LittleEndian, d[i:end])
+		zw.Close()
+		cd.Blocks = append(cd.Blocks, buf.String())
+	}
+	return cd
+}
+
+// NewCompressedPixFont creates a new PixFont like NewPixFont, from packed data
+// compressed by CompressData. Each block is decompressed the first time one of
+// its glyphs is needed. NewCompressedPixFont panics if a block is corrupt when
+// it is decompressed.
+func NewCompressedPixFont(w, h uint8, cm map[rune]uint16, cd *CompressedData) *PixFont {
+	p := NewPixFont(w, h, cm, make([]uint32, cd.Len))
+	p.lazy = &lazyData{cd: cd, once: make([]sync.Once, len(cd.Blocks))}
+	return p
+}
+
+// lazyData tracks which blocks of a compressed font have been decompressed into
+// the font's data.
+type lazyData struct {
+	cd   *CompressedData
+	once []sync.Once
+}
+
+// load makes sure the block holding d[i] has been decompressed into d.
+func (l *lazyData) load(d []uint32, i int) {
+	b := i / l.cd.BlockLen
+	l.once[b].Do(func() {
+		start := b * l.cd.BlockLen
+		end := start + l.cd.BlockLen
+		if end > len(d) {
+			end = len(d)
+		}
+		zr := flate.NewReader(bytes.NewReader([]byte(l.cd.Blocks[b])))
+		block := make([]byte, 4*(end-start))
+		if _, err := io.ReadFull(zr, block); err != nil {
+			panic("pixfont: corrupt compressed font data: " + err.Error())
+		}
+		for j := range d[start:end] {
+			d[start+j] = binary.LittleEndian.Uint32(block[4*j:])
+		}
+	})
+}
+
+// loadAll decompresses every block of d.
+func (l *lazyData) loadAll(d []uint32) {
+	for i := 0; i < len(d); i += l.cd.BlockLen {
+		l.load(d, i)
+	}
+}
@@ -0,0 +1,82 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// ContrastMode selects how DrawStringOptions keeps text readable on unknown
+// backgrounds.
+type ContrastMode int
+
+const (
+	// ContrastNone draws text in the color it is given.
+	ContrastNone ContrastMode = iota
+	// ContrastBlackWhite draws text in black or white, whichever stands out
+	// more against the average brightness of the destination under the text.
+	// The color passed to DrawStringOptions is ignored.
+	ContrastBlackWhite
+	// ContrastOutline draws text in the color it is given, with an outline
+	// one (scaled) pixel wide in black or white, whichever stands out more
+	// against that color, so that it is readable on any background. The
+	// outline ignores the ColorFunc, Fill and Glyph options.
+	ContrastOutline
+)
+
+// luma returns the brightness of c from 0 to 255.
+func luma(c color.Color) int {
+	return int(color.GrayModel.Convert(c).(color.Gray).Y)
+}
+
+// contrasting returns black or white, whichever stands out more against the
+// brightness l.
+func contrasting(l int) color.Color {
+	if l >= 128 {
+		return color.Black
+	}
+	return color.White
+}
+
+// drawContrast draws s at x,y with the colors chosen by opts.AutoContrast, and
+// otherwise as set by opts, with sp pixels between characters.
+func (p *PixFont) drawContrast(dr Drawable, x, y int, s string, clr color.Color, opts *DrawOptions, scale, sp int) int {
+	o := *opts
+	o.AutoContrast = ContrastNone
+	o.Spacing = &sp
+
+	switch opts.AutoContrast {
+	case ContrastBlackWhite:
+		img, ok := dr.(image.Image)
+		if !ok {
+			break
+		}
+		r := image.Rect(x, y, x+p.measureString(s, sp)*scale, y+int(p.charHeight)*scale).Intersect(img.Bounds())
+		if r.Empty() {
+			break
+		}
+		sum := 0
+		for yy := r.Min.Y; yy < r.Max.Y; yy++ {
+			for xx := r.Min.X; xx < r.Max.X; xx++ {
+				sum += luma(img.At(xx, yy))
+			}
+		}
+		clr = contrasting(sum / (r.Dx() * r.Dy()))
+	case ContrastOutline:
+		if clr == nil {
+			break
+		}
+		outline := contrasting(luma(clr))
+		// the outline is a flat color, so options which color the text
+		// would paint over it
+		ol := o
+		ol.Debug, ol.ColorFunc, ol.Fill, ol.Glyph = nil, nil, nil, nil
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 {
+					p.DrawStringOptions(dr, x+dx*scale, y+dy*scale, s, outline, &ol)
+				}
+			}
+		}
+	}
+	return p.DrawStringOptions(dr, x, y, s, clr, &o)
+}
@@ -0,0 +1,71 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+	"sort"
+	"unicode/utf8"
+)
+
+// IndexToX returns the x offset, from the start of s as drawn by DrawString, of
+// a caret placed before the byte at index i of s. An i within a rune or its
+// combining marks gives the position before that rune, and an i at or past the
+// end of s gives the position after the last rune.
+//
+// If normalization is enabled, s should already be normalized, so that its
+// indexes match the string that is drawn.
+func (p *PixFont) IndexToX(s string, i int) int {
+	idx, xs := p.carets(s)
+	k := sort.SearchInts(idx, i+1) - 1
+	if k < 0 {
+		k = 0
+	}
+	return xs[k]
+}
+
+// XToIndex returns the byte index in s of the caret position nearest to the x
+// offset px from the start of s as drawn by DrawString, such as for a mouse
+// click. The result is always the start of a rune which isn't a combining mark,
+// or len(s).
+func (p *PixFont) XToIndex(s string, px int) int {
+	idx, xs := p.carets(s)
+	k := sort.SearchInts(xs, px)
+	if k == len(xs) {
+		return len(s)
+	}
+	if k > 0 && px-xs[k-1] < xs[k]-px {
+		k--
+	}
+	return idx[k]
+}
+
+// DrawHighlight fills the background behind the bytes [i,j) of s in clr, as for
+// selected text, where s is drawn at x,y by DrawString. The highlight covers
+// the same caret positions as IndexToX, and the full height of the font. It
+// should be drawn before the string, and returns the rectangle filled.
+func (p *PixFont) DrawHighlight(dr Drawable, x, y int, s string, i, j int, clr color.Color) image.Rectangle {
+	if i >= j {
+		return image.Rectangle{}
+	}
+	r := image.Rect(x+p.IndexToX(s, i), y, x+p.IndexToX(s, j), y+int(p.charHeight))
+	fillRect(setter(dr, clr), r, false)
+	return r
+}
+
+// carets returns the byte indexes in s where a caret can be placed, which are
+// the starts of runes that aren't combining marks and the end of s, along with
+// the x offset of each.
+func (p *PixFont) carets(s string) (idx, xs []int) {
+	pos, sp := 0, p.Spacing()
+	width := p.layout(s, sp, func(c rune, dx, dy int) int {
+		_, n := utf8.DecodeRuneInString(s[pos:])
+		if !p.combining[c] {
+			idx = append(idx, pos)
+			xs = append(xs, dx)
+		}
+		pos += n
+		_, w := p.drawRune(nil, 0, 0, c, sp)
+		return w
+	})
+	return append(idx, len(s)), append(xs, width)
+}
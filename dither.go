@@ -0,0 +1,41 @@
+package pixfont
+
+import "image/color"
+
+// bayer4x4 is the classic 4x4 ordered dithering threshold matrix.
+var bayer4x4 = [4][4]uint8{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// DitherDrawable wraps a 1-bit (monochrome) Drawable and maps arbitrary colors
+// to on/off pixels using ordered dithering. This lets "intensity" text, such as
+// a mid-grey label, be simulated on e-paper and OLED displays.
+//
+// The intensity of a color is its luminance (after alpha premultiplication), so
+// color.White is always on, color.Black and color.Transparent are always off,
+// and anything in between lights a proportional pattern of pixels.
+type DitherDrawable struct {
+	// Dst is the monochrome Drawable to draw onto.
+	Dst Drawable
+	// On is the color set for lit pixels.
+	On color.Color
+	// Off is the color set for unlit pixels. If Off is nil, unlit pixels are
+	// left as-is.
+	Off color.Color
+}
+
+// Set implements Drawable, setting the pixel at x,y in Dst to On or Off.
+func (d *DitherDrawable) Set(x, y int, c color.Color) {
+	gc := color.GrayModel.Convert(c).(color.Gray)
+
+	// scale the threshold to the center of each of the 16 intensity bands
+	t := uint16(bayer4x4[y&3][x&3])*16 + 8
+	if uint16(gc.Y) > t {
+		d.Dst.Set(x, y, d.On)
+	} else if d.Off != nil {
+		d.Dst.Set(x, y, d.Off)
+	}
+}
@@ -0,0 +1,102 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// EPaperMode selects the kind of e-paper panel an EPaper buffer targets.
+type EPaperMode int
+
+const (
+	// EPaperBW is a black and white panel using a single bit plane.
+	EPaperBW EPaperMode = iota
+	// EPaperGray4 is a 4-level grayscale panel. The gray level is split across
+	// two bit planes: the high bit in plane 0 and the low bit in plane 1.
+	EPaperGray4
+	// EPaperBWR is a black, white and red (or yellow) panel. Plane 0 holds black
+	// ink and plane 1 holds red ink.
+	EPaperBWR
+)
+
+// EPaper is a Drawable for 1-bit, 4-gray and black/white/red e-paper buffers. It
+// tracks the region modified since the last call to ResetDirty, so that text
+// updates can use a partial refresh instead of redrawing the whole panel.
+//
+// Each plane uses HorizontalLayout, and a set bit means ink (black, darker gray
+// or red) rather than a lit pixel. Panels which expect the opposite polarity can
+// simply invert the bytes while sending them.
+type EPaper struct {
+	Mode   EPaperMode
+	Planes [2]*Framebuffer
+
+	dirty image.Rectangle
+}
+
+// NewEPaper creates a new blank (white) EPaper buffer of the given size.
+func NewEPaper(w, h int, mode EPaperMode) *EPaper {
+	e := &EPaper{Mode: mode}
+	e.Planes[0] = NewFramebuffer(w, h, HorizontalLayout)
+	if mode != EPaperBW {
+		e.Planes[1] = NewFramebuffer(w, h, HorizontalLayout)
+	}
+	return e
+}
+
+// Set implements Drawable, mapping c onto the ink planes of the panel.
+func (e *EPaper) Set(x, y int, c color.Color) {
+	p := image.Point{x, y}
+	if !p.In(e.Planes[0].Bounds()) {
+		return
+	}
+	e.dirty = e.dirty.Union(image.Rectangle{p, p.Add(image.Point{1, 1})})
+
+	gc := color.GrayModel.Convert(c).(color.Gray)
+	switch e.Mode {
+	case EPaperBW:
+		e.Planes[0].SetBit(x, y, gc.Y < 0x80)
+	case EPaperGray4:
+		level := 3 - gc.Y>>6 // 0 = white, 3 = black
+		e.Planes[0].SetBit(x, y, level&2 != 0)
+		e.Planes[1].SetBit(x, y, level&1 != 0)
+	case EPaperBWR:
+		nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+		red := nc.A >= 0x80 && nc.R >= 0x80 && nc.G < 0x80 && nc.B < 0x80
+		e.Planes[0].SetBit(x, y, !red && gc.Y < 0x80)
+		e.Planes[1].SetBit(x, y, red)
+	}
+}
+
+// Clear resets the buffer to white and marks the whole panel as dirty.
+func (e *EPaper) Clear() {
+	for _, pl := range e.Planes {
+		if pl != nil {
+			pl.Clear()
+		}
+	}
+	e.dirty = e.Planes[0].Bounds()
+}
+
+// Dirty returns the bounding box of all pixels set since the last call to
+// ResetDirty. The rectangle is empty if nothing has changed.
+func (e *EPaper) Dirty() image.Rectangle {
+	return e.dirty
+}
+
+// DirtyAligned returns the dirty region widened so that its horizontal edges fall
+// on byte boundaries, as required by the partial refresh windows of most e-paper
+// controllers.
+func (e *EPaper) DirtyAligned() image.Rectangle {
+	if e.dirty.Empty() {
+		return e.dirty
+	}
+	r := e.dirty
+	r.Min.X &^= 7
+	r.Max.X = (r.Max.X + 7) &^ 7
+	return r.Intersect(image.Rect(0, 0, (e.Planes[0].Width+7)&^7, e.Planes[0].Height))
+}
+
+// ResetDirty clears the dirty region, typically after the panel is refreshed.
+func (e *EPaper) ResetDirty() {
+	e.dirty = image.Rectangle{}
+}
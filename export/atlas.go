@@ -0,0 +1,151 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// AtlasOptions controls how Atlas packs glyphs.
+type AtlasOptions struct {
+	// Padding is the number of transparent pixels around each glyph, which
+	// keeps texture filtering from bleeding between neighbors (default 1).
+	// Set it to -1 for no padding.
+	Padding int
+	// MaxSize is the largest width or height of the atlas (default 4096).
+	MaxSize int
+}
+
+// AtlasGlyph gives the location and metrics of a glyph in a texture atlas.
+type AtlasGlyph struct {
+	Rune rune `json:"rune"`
+	// X, Y, W and H give the glyph's character cell in the atlas, in pixels,
+	// without the padding.
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+	// U0, V0, U1 and V1 are the same rectangle as texture coordinates, from 0
+	// to 1, with V increasing downwards.
+	U0 float64 `json:"u0"`
+	V0 float64 `json:"v0"`
+	U1 float64 `json:"u1"`
+	V1 float64 `json:"v1"`
+	// Advance is the glyph's advance in pixels, as from MeasureRune.
+	Advance int `json:"advance"`
+}
+
+// AtlasInfo describes a texture atlas made by Atlas.
+type AtlasInfo struct {
+	Name string `json:"name,omitempty"`
+	// Width and Height are the size of the atlas texture, which are powers of
+	// two.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// LineHeight is the font height, and Spacing the number of pixels added
+	// after each glyph's advance, as by DrawString.
+	LineHeight int          `json:"lineHeight"`
+	Spacing    int          `json:"spacing"`
+	Glyphs     []AtlasGlyph `json:"glyphs"`
+}
+
+// WriteJSON writes the atlas metadata to w as indented JSON.
+func (a *AtlasInfo) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// Atlas packs the glyphs for runes into a single texture with power of two
+// sides, along with the position, texture coordinates and advance of each glyph,
+// so that game engines can upload one texture and draw pixfont text on the GPU.
+// Glyph pixels are opaque white and the rest is transparent, so that shaders
+// can tint the text. If runes is nil, every glyph in f is packed. If opts is
+// nil, the defaults are used.
+func Atlas(f *pixfont.PixFont, runes []rune, opts *AtlasOptions) (*image.NRGBA, *AtlasInfo, error) {
+	pad, maxSize := 1, 4096
+	if opts != nil && opts.Padding != 0 {
+		pad = opts.Padding
+		if pad < 0 {
+			pad = 0
+		}
+	}
+	if opts != nil && opts.MaxSize > 0 {
+		maxSize = opts.MaxSize
+	}
+	if runes == nil {
+		runes = f.Runes()
+	}
+	if len(runes) == 0 {
+		return nil, nil, fmt.Errorf("export: no glyphs to pack")
+	}
+
+	// choose the smallest atlas, preferring squarer ones, which fits a grid
+	// of padded character cells
+	cw := f.GetWidth() + 2*pad
+	ch := f.GetHeight() + 2*pad
+	w, h, cols := 0, 0, 0
+	for tw := pow2(cw); tw <= maxSize; tw *= 2 {
+		c := tw / cw
+		th := pow2(((len(runes) + c - 1) / c) * ch)
+		if th > maxSize {
+			continue
+		}
+		if w == 0 || tw*th < w*h || (tw*th == w*h && tw <= th) {
+			w, h, cols = tw, th, c
+		}
+	}
+	if w == 0 {
+		return nil, nil, fmt.Errorf("export: %d glyphs don't fit in a %dx%d atlas", len(runes), maxSize, maxSize)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	info := &AtlasInfo{
+		Name:       f.Name,
+		Width:      w,
+		Height:     h,
+		LineHeight: f.GetHeight(),
+		Spacing:    f.Spacing(),
+		Glyphs:     make([]AtlasGlyph, 0, len(runes)),
+	}
+	white := color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	for i, r := range runes {
+		m := f.GlyphMask(r)
+		if m == nil {
+			return nil, nil, fmt.Errorf("export: no glyph for %q", r)
+		}
+		_, adv := f.MeasureRune(r)
+		g := AtlasGlyph{
+			Rune:    r,
+			X:       (i%cols)*cw + pad,
+			Y:       (i/cols)*ch + pad,
+			W:       f.GetWidth(),
+			H:       f.GetHeight(),
+			Advance: adv,
+		}
+		g.U0, g.V0 = float64(g.X)/float64(w), float64(g.Y)/float64(h)
+		g.U1, g.V1 = float64(g.X+g.W)/float64(w), float64(g.Y+g.H)/float64(h)
+		for y := 0; y < g.H; y++ {
+			for x := 0; x < g.W; x++ {
+				if m.AlphaAt(x, y).A != 0 {
+					img.SetNRGBA(g.X+x, g.Y+y, white)
+				}
+			}
+		}
+		info.Glyphs = append(info.Glyphs, g)
+	}
+	return img, info, nil
+}
+
+// pow2 returns the smallest power of two which is at least n.
+func pow2(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
@@ -0,0 +1,77 @@
+// Package export converts PixFonts into formats used by other platforms and
+// languages, so that pixfont can stay the single source of truth for a font.
+package export
+
+import (
+	"fmt"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// TileFormat selects the byte layout of 2bpp 8x8 tiles.
+type TileFormat int
+
+const (
+	// NES tiles store the 8 bytes of bit plane 0 followed by the 8 bytes of bit
+	// plane 1.
+	NES TileFormat = iota
+	// GameBoy tiles interleave the two bit planes, one pair of bytes per row.
+	GameBoy
+)
+
+// CHR converts the glyphs for runes into 2bpp 8x8 tile data for use in homebrew
+// NES and Game Boy ROMs. Glyph pixels use color index idx (1-3) and all other
+// pixels use color index 0. If runes is nil, every glyph in f is converted.
+//
+// Glyphs larger than 8x8 are split into several consecutive tiles, left to right
+// then top to bottom. The returned index maps each rune to its first tile.
+func CHR(f *pixfont.PixFont, runes []rune, format TileFormat, idx uint8) ([]byte, map[rune]int, error) {
+	if idx < 1 || idx > 3 {
+		return nil, nil, fmt.Errorf("export: color index %d is not in 1-3", idx)
+	}
+	if runes == nil {
+		runes = f.Runes()
+	}
+
+	var tiles []byte
+	index := make(map[rune]int, len(runes))
+	for _, r := range runes {
+		m := f.GlyphMask(r)
+		if m == nil {
+			return nil, nil, fmt.Errorf("export: no glyph for %q", r)
+		}
+		if _, dup := index[r]; dup {
+			continue
+		}
+		index[r] = len(tiles) / 16
+
+		b := m.Bounds()
+		for ty := 0; ty < b.Dy(); ty += 8 {
+			for tx := 0; tx < b.Dx(); tx += 8 {
+				var tile [16]byte
+				for y := 0; y < 8; y++ {
+					var row byte
+					for x := 0; x < 8; x++ {
+						if m.AlphaAt(tx+x, ty+y).A != 0 {
+							row |= 0x80 >> uint(x)
+						}
+					}
+					var lo, hi byte
+					if idx&1 != 0 {
+						lo = row
+					}
+					if idx&2 != 0 {
+						hi = row
+					}
+					if format == GameBoy {
+						tile[y*2], tile[y*2+1] = lo, hi
+					} else {
+						tile[y], tile[y+8] = lo, hi
+					}
+				}
+				tiles = append(tiles, tile[:]...)
+			}
+		}
+	}
+	return tiles, index, nil
+}
@@ -0,0 +1,371 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/fonts/pico"
+)
+
+// picoFont returns the pico font's 'I' and 'T' glyphs, without its metadata,
+// as a copy the test is free to change.
+func picoFont(t *testing.T) *pixfont.PixFont {
+	t.Helper()
+	f, err := pico.Font3x5.Subset([]rune("IT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Name, f.Copyright, f.Comment = "", "", ""
+	return f
+}
+
+// dataWord matches a packed glyph row in exported source code.
+var dataWord = regexp.MustCompile(`0x([0-9a-f]{8}),`)
+
+// unpackSource rebuilds a w x h font from exported source code, using entry to
+// match the code point and offset of each charmap entry.
+func unpackSource(t *testing.T, src string, entry *regexp.Regexp, w, h uint8) *pixfont.PixFont {
+	t.Helper()
+	cm := make(map[rune]uint16)
+	for _, m := range entry.FindAllStringSubmatch(src, -1) {
+		r, err := strconv.ParseInt(m[1], 0, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		off, err := strconv.ParseUint(m[2], 10, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cm[rune(r)] = uint16(off)
+	}
+	var data []uint32
+	for _, m := range dataWord.FindAllStringSubmatch(src, -1) {
+		v, err := strconv.ParseUint(m[1], 16, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, uint32(v))
+	}
+	return pixfont.NewPixFont(w, h, cm, data)
+}
+
+// sameGlyphs reports an error for each glyph which differs between got and want.
+func sameGlyphs(t *testing.T, got, want *pixfont.PixFont) {
+	t.Helper()
+	if g, w := string(got.Runes()), string(want.Runes()); g != w {
+		t.Fatalf("exported runes are %q, want %q", g, w)
+	}
+	for _, r := range want.Runes() {
+		if !bytes.Equal(got.GlyphMask(r).Pix, want.GlyphMask(r).Pix) {
+			t.Errorf("exported glyph %q differs", r)
+		}
+	}
+}
+
+// checkContains reports an error for each of lines which is missing from src.
+func checkContains(t *testing.T, src string, lines ...string) {
+	t.Helper()
+	for _, ln := range lines {
+		if !strings.Contains(src, ln) {
+			t.Errorf("missing %q in:\n%s", ln, src)
+		}
+	}
+}
+
+func TestCHR(t *testing.T) {
+	f := picoFont(t)
+	tiles, index, err := CHR(f, []rune("TIT"), NES, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0xe0, 0x40, 0x40, 0x40, 0x40, 0, 0, 0, 0xe0, 0x40, 0x40, 0x40, 0x40, 0, 0, 0, // T
+		0xe0, 0x40, 0x40, 0x40, 0xe0, 0, 0, 0, 0xe0, 0x40, 0x40, 0x40, 0xe0, 0, 0, 0, // I
+	}
+	if !bytes.Equal(tiles, want) {
+		t.Errorf("NES tiles are % x\nwant % x", tiles, want)
+	}
+	if len(index) != 2 || index['T'] != 0 || index['I'] != 1 {
+		t.Errorf("tile index is %v", index)
+	}
+
+	tiles, _, err = CHR(f, []rune("T"), GameBoy, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []byte{0, 0xe0, 0, 0x40, 0, 0x40, 0, 0x40, 0, 0x40, 0, 0, 0, 0, 0, 0}
+	if !bytes.Equal(tiles, want) {
+		t.Errorf("Game Boy tiles are % x\nwant % x", tiles, want)
+	}
+
+	// a 9x9 glyph takes four tiles
+	data, cm, _ := pixfont.Pack(9, 9, map[rune]map[int]string{'o': {0: "X", 8: "        X"}})
+	tiles, _, err = CHR(pixfont.NewPixFont(9, 9, cm, data), nil, NES, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tiles) != 4*16 || tiles[0] != 0x80 || tiles[3*16] != 0x80 {
+		t.Errorf("large glyph tiles are % x", tiles)
+	}
+
+	for _, idx := range []uint8{0, 4} {
+		if _, _, err := CHR(f, nil, NES, idx); err == nil {
+			t.Errorf("no error for color index %d", idx)
+		}
+	}
+	if _, _, err := CHR(f, []rune("X"), NES, 1); err == nil {
+		t.Error("no error for a missing glyph")
+	}
+}
+
+func TestJS(t *testing.T) {
+	f := picoFont(t)
+	f.Name = "Test"
+	f.Comment = "two\nlines"
+	f.SetSpacing(2)
+	var buf bytes.Buffer
+	if err := JS(&buf, f, "myFont"); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	if !strings.HasPrefix(src, "// Code generated by pixfont; DO NOT EDIT.\n// Name: Test\n// Comment: two\n// Comment: lines\n") {
+		t.Errorf("header is wrong:\n%s", src)
+	}
+	checkContains(t, src,
+		"export const myFont = {\n  width: 3,\n  height: 5,\n  variable: false,\n  spacing: 2,\n  missing: 3,\n",
+		"export function drawRune(",
+		"export function drawString(",
+		"export function measureString(",
+	)
+	if strings.Contains(src, "Copyright") {
+		t.Error("empty copyright is written")
+	}
+	sameGlyphs(t, unpackSource(t, src, regexp.MustCompile(` (\d+): (\d+),`), 3, 5), f)
+}
+
+func TestPython(t *testing.T) {
+	f := picoFont(t)
+	f.Copyright = "Public domain"
+	var buf bytes.Buffer
+	if err := Python(&buf, f, "FONT"); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	if !strings.HasPrefix(src, "# Code generated by pixfont; DO NOT EDIT.\n# Copyright: Public domain\n\n") {
+		t.Errorf("header is wrong:\n%s", src)
+	}
+	checkContains(t, src,
+		"FONT = {\n    \"width\": 3,\n    \"height\": 5,\n    \"variable\": False,\n    \"spacing\": 1,\n    \"missing\": 3,\n",
+		"def draw_rune(",
+		"def draw_string(",
+		"def measure_string(",
+	)
+	sameGlyphs(t, unpackSource(t, src, regexp.MustCompile(` (\d+): (\d+),`), 3, 5), f)
+}
+
+func TestRust(t *testing.T) {
+	f := picoFont(t)
+	f.Name = "Test"
+	var buf bytes.Buffer
+	if err := Rust(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	if !strings.HasPrefix(src, "// Code generated by pixfont; DO NOT EDIT.\n// Name: Test\n\n") {
+		t.Errorf("header is wrong:\n%s", src)
+	}
+	checkContains(t, src,
+		"pub const WIDTH: i32 = 3;\n",
+		"pub const HEIGHT: i32 = 5;\n",
+		"pub const VARIABLE: bool = false;\n",
+		"pub const SPACING: i32 = 1;\n",
+		"pub const MISSING: i32 = 3;\n",
+		"pub static CHARMAP: [(u32, u16); 2] = [",
+		"pub fn draw_str<",
+		"pub fn measure_str(",
+	)
+	sameGlyphs(t, unpackSource(t, src, regexp.MustCompile(`\((0x[0-9a-f]+), (\d+)\),`), 3, 5), f)
+}
+
+func TestSDF(t *testing.T) {
+	f := picoFont(t)
+	atlas, glyphs, err := SDF(f, []rune("IT"), &SDFOptions{Scale: 2, Spread: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atlas.Rect != image.Rect(0, 0, 20, 14) {
+		t.Fatalf("atlas is %v, want 20x14", atlas.Rect)
+	}
+	want := []SDFGlyph{
+		{Rune: 'I', X: 0, Y: 0, W: 10, H: 14, Advance: 3},
+		{Rune: 'T', X: 10, Y: 0, W: 10, H: 14, Advance: 3},
+	}
+	if len(glyphs) != len(want) || glyphs[0] != want[0] || glyphs[1] != want[1] {
+		t.Fatalf("glyphs are %+v, want %+v", glyphs, want)
+	}
+	for _, g := range glyphs {
+		m := f.GlyphMask(g.Rune)
+		for y := 0; y < g.H; y++ {
+			for x := 0; x < g.W; x++ {
+				v := atlas.GrayAt(g.X+x, g.Y+y).Y
+				in := x >= 2 && y >= 2 && m.AlphaAt((x-2)/2, (y-2)/2).A != 0
+				if in != (v >= 128) {
+					t.Errorf("%q texel %d,%d is %d, inside is %v", g.Rune, x, y, v, in)
+				}
+			}
+		}
+	}
+	// half a texel inside the edge, and beyond the spread outside it
+	if v := atlas.GrayAt(4, 2).Y; v != 159 {
+		t.Errorf("edge texel is %d, want 159", v)
+	}
+	if v := atlas.GrayAt(0, 0).Y; v != 0 {
+		t.Errorf("corner texel is %d, want 0", v)
+	}
+
+	atlas, glyphs, err = SDF(f, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(glyphs) != 2 || glyphs[1].W != 32 || atlas.Rect.Dx() != 64 {
+		t.Errorf("default glyphs are %+v in a %v atlas", glyphs, atlas.Rect)
+	}
+
+	if _, _, err := SDF(f, []rune("X"), nil); err == nil {
+		t.Error("no error for a missing glyph")
+	}
+	if _, _, err := SDF(f, []rune{}, nil); err == nil {
+		t.Error("no error for no glyphs")
+	}
+}
+
+func TestAtlas(t *testing.T) {
+	f := picoFont(t)
+	f.Name = "Test"
+	img, info, err := Atlas(f, []rune("IT"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// two padded 5x7 cells fit 8x16 as well as 16x8, and the taller is chosen
+	if img.Rect != image.Rect(0, 0, 8, 16) || info.Width != 8 || info.Height != 16 {
+		t.Fatalf("atlas is %v, info is %dx%d; want 8x16", img.Rect, info.Width, info.Height)
+	}
+	if info.Name != "Test" || info.LineHeight != 5 || info.Spacing != 1 {
+		t.Errorf("info is %+v", info)
+	}
+	want := []AtlasGlyph{
+		{Rune: 'I', X: 1, Y: 1, W: 3, H: 5, U0: 1.0 / 8, V0: 1.0 / 16, U1: 4.0 / 8, V1: 6.0 / 16, Advance: 3},
+		{Rune: 'T', X: 1, Y: 8, W: 3, H: 5, U0: 1.0 / 8, V0: 8.0 / 16, U1: 4.0 / 8, V1: 13.0 / 16, Advance: 3},
+	}
+	if len(info.Glyphs) != 2 || info.Glyphs[0] != want[0] || info.Glyphs[1] != want[1] {
+		t.Errorf("glyphs are %+v\nwant %+v", info.Glyphs, want)
+	}
+	for _, tc := range []struct {
+		x, y   int
+		opaque bool
+	}{
+		{0, 0, false}, {1, 1, true}, {1, 2, false}, {1, 8, true}, {1, 9, false}, {2, 12, true},
+	} {
+		c := img.NRGBAAt(tc.x, tc.y)
+		if opaque := c == (color.NRGBA{0xff, 0xff, 0xff, 0xff}); opaque != tc.opaque || (!opaque && c.A != 0) {
+			t.Errorf("pixel %d,%d is %v", tc.x, tc.y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := info.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded AtlasInfo
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, info) {
+		t.Errorf("JSON decodes as %+v", decoded)
+	}
+
+	_, info, err = Atlas(f, nil, &AtlasOptions{Padding: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Width != 8 || info.Height != 8 || info.Glyphs[1].X != 3 {
+		t.Errorf("unpadded atlas is %dx%d with glyphs %+v", info.Width, info.Height, info.Glyphs)
+	}
+
+	for _, tc := range []struct {
+		runes []rune
+		opts  *AtlasOptions
+	}{
+		{[]rune("X"), nil},
+		{[]rune{}, nil},
+		{nil, &AtlasOptions{MaxSize: 4}},
+	} {
+		if _, _, err := Atlas(f, tc.runes, tc.opts); err == nil {
+			t.Errorf("no error for runes %q, options %+v", tc.runes, tc.opts)
+		}
+	}
+}
+
+func TestOutline(t *testing.T) {
+	data, cm, _ := pixfont.Pack(3, 3, map[rune]map[int]string{
+		'T':  {0: "XXX", 1: " X ", 2: " X "},
+		'O':  {0: "XXX", 1: "X X", 2: "XXX"},
+		'/':  {0: "X  ", 1: " X "},
+		' ':  {},
+		0x01: {0: "X"},
+	})
+	f := pixfont.NewPixFont(3, 3, cm, data)
+	for r, want := range map[rune][][]image.Point{
+		'T': {{{0, 0}, {3, 0}, {3, 1}, {2, 1}, {2, 3}, {1, 3}, {1, 1}, {0, 1}}},
+		// the hole runs counterclockwise
+		'O': {{{0, 0}, {3, 0}, {3, 3}, {0, 3}}, {{1, 1}, {1, 2}, {2, 2}, {2, 1}}},
+		// pixels touching at a corner are kept apart
+		'/': {{{0, 0}, {1, 0}, {1, 1}, {0, 1}}, {{1, 1}, {2, 1}, {2, 2}, {1, 2}}},
+		' ': nil,
+		'X': nil,
+	} {
+		if got := Outline(f, r); !reflect.DeepEqual(got, want) {
+			t.Errorf("outline of %q is %v, want %v", r, got, want)
+		}
+	}
+
+	if got, want := SVGPath(f, 'T'), "M0 0H3V1H2V3H1V1H0Z"; got != want {
+		t.Errorf("path is %q, want %q", got, want)
+	}
+	if got, want := SVGPath(f, 'O'), "M0 0H3V3H0ZM1 1V2H2V1Z"; got != want {
+		t.Errorf("path is %q, want %q", got, want)
+	}
+	if got := SVGPath(f, 'X'); got != "" {
+		t.Errorf("path of a missing glyph is %q", got)
+	}
+
+	f.Name, f.Copyright = "A&B", "Public domain"
+	var buf bytes.Buffer
+	if err := SVGFont(&buf, f, 10); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	checkContains(t, svg,
+		"<metadata>Public domain</metadata>\n",
+		"<font horiz-adv-x=\"40\">\n",
+		"<font-face font-family=\"A&amp;B\" units-per-em=\"30\" ascent=\"30\" descent=\"0\"/>\n",
+		// y increases upwards from the baseline, at the bottom of the cell
+		"<glyph unicode=\"&#x54;\" horiz-adv-x=\"40\" d=\"M0 30H30V20H20V0H10V20H0Z\"/>\n",
+		"<glyph unicode=\"&#x20;\" horiz-adv-x=\"40\"/>\n",
+	)
+	if strings.Contains(svg, "&#x1;") {
+		t.Errorf("control character written:\n%s", svg)
+	}
+	if err := xml.Unmarshal(buf.Bytes(), new(struct{})); err != nil {
+		t.Errorf("SVG font is not valid XML: %v", err)
+	}
+}
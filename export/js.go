@@ -0,0 +1,97 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// jsDrawFuncs renders a font exported by JS onto a canvas, pixel for pixel the
+// same as PixFont.DrawString.
+const jsDrawFuncs = `
+// drawRune draws a single code point at x,y using the context's current
+// fillStyle, and returns the horizontal advance. Missing glyphs are skipped.
+export function drawRune(ctx, font, x, y, c) {
+  const poff = font.charmap[c];
+  if (poff === undefined) {
+    return font.missing;
+  }
+  let w = font.variable ? 0 : font.width;
+  const pindex = poff >> 2;
+  const psub = (poff & 3) * 8;
+  for (let yy = 0; yy < font.height; yy++) {
+    const line = font.data[pindex + yy];
+    for (let xx = 0; xx < font.width; xx++) {
+      if ((line >>> (psub + xx)) & 1) {
+        ctx.fillRect(x + xx, y + yy, 1, 1);
+        if (xx >= w) {
+          w = xx + font.spacing;
+        }
+      }
+    }
+  }
+  return w;
+}
+
+// drawString draws s at x,y in the given color (or the current fillStyle if
+// color is omitted), and returns the x position following the string.
+export function drawString(ctx, font, x, y, s, color) {
+  if (color !== undefined) {
+    ctx.fillStyle = color;
+  }
+  for (const ch of s) {
+    x += drawRune(ctx, font, x, y, ch.codePointAt(0)) + font.spacing;
+  }
+  return x;
+}
+
+// measureString returns the width of s in pixels without drawing it.
+export function measureString(font, s) {
+  const nop = { fillRect() {} };
+  return drawString(nop, font, 0, 0, s);
+}
+`
+
+// JS writes f as a JavaScript module, so that the same pixel font can be drawn
+// on an HTML canvas in a web frontend and in a Go backend with identical
+// results. The module exports the font data as a constant with the given name,
+// along with drawRune, drawString and measureString functions:
+//
+//	import { myFont, drawString } from "./myfont.js";
+//	drawString(canvas.getContext("2d"), myFont, 10, 10, "Hello!", "#fff");
+//
+// The spacing of f is stored with the font.
+func JS(w io.Writer, f *pixfont.PixFont, name string) error {
+	pf, err := packFont(f)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "// Code generated by pixfont; DO NOT EDIT.\n")
+	writeMetadata(bw, f, "// ")
+	fmt.Fprintln(bw)
+	fmt.Fprintf(bw, "export const %s = {\n", name)
+	fmt.Fprintf(bw, "  width: %d,\n  height: %d,\n  variable: %t,\n  spacing: %d,\n  missing: %d,\n",
+		pf.width, pf.height, pf.variable, pf.spacing, pf.missing)
+	fmt.Fprintf(bw, "  charmap: {")
+	for i, r := range pf.runes {
+		if i%8 == 0 {
+			fmt.Fprintf(bw, "\n   ")
+		}
+		fmt.Fprintf(bw, " %d: %d,", r, pf.charmap[r])
+	}
+	fmt.Fprintf(bw, "\n  },\n")
+	fmt.Fprintf(bw, "  data: new Uint32Array([")
+	for i, v := range pf.data {
+		if i%8 == 0 {
+			fmt.Fprintf(bw, "\n   ")
+		}
+		fmt.Fprintf(bw, " 0x%08x,", v)
+	}
+	fmt.Fprintf(bw, "\n  ]),\n};\n")
+	fmt.Fprint(bw, jsDrawFuncs)
+	return bw.Flush()
+}
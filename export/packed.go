@@ -0,0 +1,78 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// packedFont is the packed representation of a PixFont, as written by the
+// source code exporters. It matches the layout created by pixfont.Pack.
+type packedFont struct {
+	width, height int
+	variable      bool
+	spacing       int
+	missing       int // advance for runes without a glyph
+	runes         []rune
+	charmap       map[rune]uint16
+	data          []uint32
+}
+
+func packFont(f *pixfont.PixFont) (*packedFont, error) {
+	pf := &packedFont{
+		width:    f.GetWidth(),
+		height:   f.GetHeight(),
+		variable: f.IsVariableWidth(),
+		spacing:  f.Spacing(),
+		runes:    f.Runes(),
+	}
+	pf.missing = pf.width
+	if pf.variable {
+		pf.missing = pf.width / 3
+		if pf.missing < 3 {
+			pf.missing = 3
+		}
+	}
+
+	glyphs := make(map[rune]map[int]string, len(pf.runes))
+	for _, r := range pf.runes {
+		m := f.GlyphMask(r)
+		lines := make(map[int]string, pf.height)
+		for y := 0; y < pf.height; y++ {
+			ln := make([]byte, pf.width)
+			for x := range ln {
+				ln[x] = ' '
+				if m.AlphaAt(x, y).A != 0 {
+					ln[x] = 'X'
+				}
+			}
+			lines[y] = string(ln)
+		}
+		glyphs[r] = lines
+	}
+	var err error
+	pf.data, pf.charmap, err = pixfont.Pack(pf.width, pf.height, glyphs)
+	if err != nil {
+		return nil, err
+	}
+	return pf, nil
+}
+
+// writeMetadata writes the name, copyright and comment of f as comment lines
+// starting with prefix, so that attribution is kept with the exported font.
+func writeMetadata(w io.Writer, f *pixfont.PixFont, prefix string) {
+	for _, m := range []struct{ key, value string }{
+		{"Name", f.Name},
+		{"Copyright", f.Copyright},
+		{"Comment", f.Comment},
+	} {
+		if m.value == "" {
+			continue
+		}
+		for _, ln := range strings.Split(m.value, "\n") {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, m.key, ln)
+		}
+	}
+}
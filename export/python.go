@@ -0,0 +1,99 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// pyDrawFuncs renders a font exported by Python, pixel for pixel the same as
+// PixFont.DrawString.
+const pyDrawFuncs = `
+
+def draw_rune(draw, font, x, y, c, fill=None):
+    """Draw the code point c at x,y and return the horizontal advance.
+
+    draw is a PIL ImageDraw.Draw, or anything with a compatible point method.
+    Missing glyphs are skipped.
+    """
+    poff = font["charmap"].get(c)
+    if poff is None:
+        return font["missing"]
+    width, data = font["width"], font["data"]
+    w = 0 if font["variable"] else width
+    pindex, psub = poff >> 2, (poff & 3) * 8
+    for yy in range(font["height"]):
+        line = data[pindex + yy] >> psub
+        for xx in range(width):
+            if (line >> xx) & 1:
+                if draw is not None:
+                    draw.point((x + xx, y + yy), fill=fill)
+                if xx >= w:
+                    w = xx + font["spacing"]
+    return w
+
+
+def draw_string(draw, font, x, y, s, fill=None):
+    """Draw the string s at x,y and return the x position following it."""
+    for ch in s:
+        x += draw_rune(draw, font, x, y, ord(ch), fill) + font["spacing"]
+    return x
+
+
+def measure_string(font, s):
+    """Return the width of the string s in pixels without drawing it."""
+    return draw_string(None, font, 0, 0, s)
+`
+
+// Python writes f as a Python module, for image pipelines which mix Go
+// services and Python scripts. The module defines the font data as a dict with
+// the given name, along with draw_rune, draw_string and measure_string
+// functions that draw onto a PIL ImageDraw:
+//
+//	from PIL import Image, ImageDraw
+//	import myfont
+//	img = Image.new("RGB", (100, 20))
+//	myfont.draw_string(ImageDraw.Draw(img), myfont.FONT, 2, 2, "Hello!", fill="white")
+//
+// The spacing of f is stored with the font.
+func Python(w io.Writer, f *pixfont.PixFont, name string) error {
+	pf, err := packFont(f)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# Code generated by pixfont; DO NOT EDIT.\n")
+	writeMetadata(bw, f, "# ")
+	fmt.Fprintln(bw)
+	fmt.Fprintf(bw, "%s = {\n", name)
+	fmt.Fprintf(bw, "    \"width\": %d,\n    \"height\": %d,\n    \"variable\": %s,\n    \"spacing\": %d,\n    \"missing\": %d,\n",
+		pf.width, pf.height, pyBool(pf.variable), pf.spacing, pf.missing)
+	fmt.Fprintf(bw, "    \"charmap\": {")
+	for i, r := range pf.runes {
+		if i%8 == 0 {
+			fmt.Fprintf(bw, "\n       ")
+		}
+		fmt.Fprintf(bw, " %d: %d,", r, pf.charmap[r])
+	}
+	fmt.Fprintf(bw, "\n    },\n")
+	fmt.Fprintf(bw, "    \"data\": [")
+	for i, v := range pf.data {
+		if i%8 == 0 {
+			fmt.Fprintf(bw, "\n       ")
+		}
+		fmt.Fprintf(bw, " 0x%08x,", v)
+	}
+	fmt.Fprintf(bw, "\n    ],\n}\n")
+	fmt.Fprint(bw, pyDrawFuncs)
+	return bw.Flush()
+}
+
+func pyBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
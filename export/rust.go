@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// rustDrawFuncs renders a font exported by Rust, pixel for pixel the same as
+// PixFont.DrawString.
+const rustDrawFuncs = `
+/// Returns the offset into DATA of the glyph for c, if the font has one.
+pub fn lookup(c: char) -> Option<u16> {
+    CHARMAP
+        .binary_search_by_key(&(c as u32), |&(r, _)| r)
+        .ok()
+        .map(|i| CHARMAP[i].1)
+}
+
+/// Draws c at x,y by calling set for each pixel of the glyph, and returns the
+/// horizontal advance. Missing glyphs are skipped.
+pub fn draw_char<F: FnMut(i32, i32)>(set: &mut F, x: i32, y: i32, c: char) -> i32 {
+    let poff = match lookup(c) {
+        Some(poff) => poff,
+        None => return MISSING,
+    };
+    let mut w = if VARIABLE { 0 } else { WIDTH };
+    let pindex = (poff >> 2) as usize;
+    let psub = ((poff & 3) * 8) as u32;
+    for yy in 0..HEIGHT {
+        let line = DATA[pindex + yy as usize] >> psub;
+        for xx in 0..WIDTH {
+            if (line >> xx) & 1 != 0 {
+                set(x + xx, y + yy);
+                if xx >= w {
+                    w = xx + SPACING;
+                }
+            }
+        }
+    }
+    w
+}
+
+/// Draws s at x,y by calling set for each pixel, and returns the x position
+/// following the string.
+pub fn draw_str<F: FnMut(i32, i32)>(set: &mut F, mut x: i32, y: i32, s: &str) -> i32 {
+    for c in s.chars() {
+        x += draw_char(set, x, y, c) + SPACING;
+    }
+    x
+}
+
+/// Returns the width of s in pixels without drawing it.
+pub fn measure_str(s: &str) -> i32 {
+    draw_str(&mut |_, _| {}, 0, 0, s)
+}
+`
+
+// Rust writes f as a Rust source file, for embedded projects which share font
+// assets between Go tools and Rust firmware. The file contains the font data
+// as static arrays, along with lookup, draw_char, draw_str and measure_str
+// functions which call back for each pixel, so it can be used as a module in
+// no_std crates:
+//
+//	mod myfont;
+//	myfont::draw_str(&mut |x, y| display.set_pixel(x, y, true), 0, 0, "Hello!");
+//
+// The spacing of f is stored with the font.
+func Rust(w io.Writer, f *pixfont.PixFont) error {
+	pf, err := packFont(f)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "// Code generated by pixfont; DO NOT EDIT.\n")
+	writeMetadata(bw, f, "// ")
+	fmt.Fprintln(bw)
+	fmt.Fprintf(bw, "/// Width of the character cell in pixels.\npub const WIDTH: i32 = %d;\n", pf.width)
+	fmt.Fprintf(bw, "/// Height of the character cell in pixels.\npub const HEIGHT: i32 = %d;\n", pf.height)
+	fmt.Fprintf(bw, "/// Whether glyphs are drawn using their own width.\npub const VARIABLE: bool = %t;\n", pf.variable)
+	fmt.Fprintf(bw, "/// Pixels between characters.\npub const SPACING: i32 = %d;\n", pf.spacing)
+	fmt.Fprintf(bw, "/// Advance for characters without a glyph.\npub const MISSING: i32 = %d;\n\n", pf.missing)
+
+	fmt.Fprintf(bw, "/// Code points with a glyph and their offsets into DATA, sorted by code point.\n")
+	fmt.Fprintf(bw, "pub static CHARMAP: [(u32, u16); %d] = [", len(pf.runes))
+	for i, r := range pf.runes {
+		if i%6 == 0 {
+			fmt.Fprintf(bw, "\n   ")
+		}
+		fmt.Fprintf(bw, " (0x%04x, %d),", r, pf.charmap[r])
+	}
+	fmt.Fprintf(bw, "\n];\n\n")
+
+	fmt.Fprintf(bw, "/// Packed glyph rows, with the leftmost pixel in the lowest bit.\n")
+	fmt.Fprintf(bw, "pub static DATA: [u32; %d] = [", len(pf.data))
+	for i, v := range pf.data {
+		if i%6 == 0 {
+			fmt.Fprintf(bw, "\n   ")
+		}
+		fmt.Fprintf(bw, " 0x%08x,", v)
+	}
+	fmt.Fprintf(bw, "\n];\n")
+	fmt.Fprint(bw, rustDrawFuncs)
+	return bw.Flush()
+}
@@ -0,0 +1,103 @@
+package export
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// SDFOptions controls the resolution of signed distance field textures.
+type SDFOptions struct {
+	// Scale is the number of texels per font pixel (default 8).
+	Scale int
+	// Spread is the largest distance, in texels, encoded in the field
+	// (default 4). Each glyph is padded by Spread texels on every side.
+	Spread int
+}
+
+// SDFGlyph gives the location and metrics of a glyph in an SDF atlas.
+type SDFGlyph struct {
+	Rune rune `json:"rune"`
+	// X, Y, W and H give the glyph's rectangle in the atlas, in texels,
+	// including the padding.
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+	// Advance is the glyph's advance in font pixels, as from MeasureRune.
+	Advance int `json:"advance"`
+}
+
+// SDF converts the glyphs for runes into signed distance fields packed into a
+// single atlas texture, so that GPU renderers can scale them smoothly while the
+// PixFont stays the single source of truth. If runes is nil, every glyph in f is
+// converted. If opts is nil, the defaults are used.
+//
+// Texel values are 128 on the glyph edge, increasing to 255 inside the glyph
+// and decreasing to 0 outside it, Spread texels away from the edge.
+func SDF(f *pixfont.PixFont, runes []rune, opts *SDFOptions) (*image.Gray, []SDFGlyph, error) {
+	scale, spread := 8, 4
+	if opts != nil && opts.Scale > 0 {
+		scale = opts.Scale
+	}
+	if opts != nil && opts.Spread > 0 {
+		spread = opts.Spread
+	}
+	if runes == nil {
+		runes = f.Runes()
+	}
+	if len(runes) == 0 {
+		return nil, nil, fmt.Errorf("export: no glyphs to convert")
+	}
+
+	cw := f.GetWidth()*scale + 2*spread
+	ch := f.GetHeight()*scale + 2*spread
+	cols := int(math.Ceil(math.Sqrt(float64(len(runes)))))
+	rows := (len(runes) + cols - 1) / cols
+	atlas := image.NewGray(image.Rect(0, 0, cols*cw, rows*ch))
+
+	glyphs := make([]SDFGlyph, 0, len(runes))
+	for i, r := range runes {
+		m := f.GlyphMask(r)
+		if m == nil {
+			return nil, nil, fmt.Errorf("export: no glyph for %q", r)
+		}
+		_, adv := f.MeasureRune(r)
+		g := SDFGlyph{Rune: r, X: (i % cols) * cw, Y: (i / cols) * ch, W: cw, H: ch, Advance: adv}
+
+		// inside reports whether texel x,y of the padded glyph is on
+		inside := func(x, y int) bool {
+			x, y = x-spread, y-spread
+			if x < 0 || y < 0 {
+				return false
+			}
+			return m.AlphaAt(x/scale, y/scale).A != 0
+		}
+		for y := 0; y < ch; y++ {
+			for x := 0; x < cw; x++ {
+				in := inside(x, y)
+				best := float64(spread)
+				for dy := -spread; dy <= spread; dy++ {
+					for dx := -spread; dx <= spread; dx++ {
+						if inside(x+dx, y+dy) == in {
+							continue
+						}
+						// the edge is halfway to the nearest texel of the other kind
+						if d := math.Hypot(float64(dx), float64(dy)) - 0.5; d < best {
+							best = d
+						}
+					}
+				}
+				if !in {
+					best = -best
+				}
+				v := 127.5 + best*127.5/float64(spread)
+				atlas.Pix[atlas.PixOffset(g.X+x, g.Y+y)] = uint8(math.Max(0, math.Min(255, math.Round(v))))
+			}
+		}
+		glyphs = append(glyphs, g)
+	}
+	return atlas, glyphs, nil
+}
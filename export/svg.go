@@ -0,0 +1,204 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Outline traces the pixels of the glyph for r into polygons, for converting a
+// pixel font into a vector font. Points are the corners of pixels, in font
+// pixels with y increasing downwards from the top of the character cell, and
+// only the corners where the outline turns are included. Outer boundaries run
+// clockwise on screen and the boundaries of holes counterclockwise, so that
+// the polygons fill the glyph with either the nonzero or the even-odd rule.
+// Pixels which only touch at a corner get separate polygons. Outline returns
+// nil if the font has no glyph for r or the glyph is blank.
+func Outline(f *pixfont.PixFont, r rune) [][]image.Point {
+	m := f.GlyphMask(r)
+	if m == nil {
+		return nil
+	}
+	on := func(x, y int) bool {
+		return image.Pt(x, y).In(m.Rect) && m.AlphaAt(x, y).A != 0
+	}
+
+	// the edges of each pixel which border a blank pixel, directed clockwise
+	// around the pixel, keyed by their start
+	edges := make(map[image.Point][]image.Point)
+	add := func(x0, y0, x1, y1 int) {
+		p := image.Pt(x0, y0)
+		edges[p] = append(edges[p], image.Pt(x1, y1))
+	}
+	for y := 0; y < m.Rect.Dy(); y++ {
+		for x := 0; x < m.Rect.Dx(); x++ {
+			if !on(x, y) {
+				continue
+			}
+			if !on(x, y-1) {
+				add(x, y, x+1, y)
+			}
+			if !on(x+1, y) {
+				add(x+1, y, x+1, y+1)
+			}
+			if !on(x, y+1) {
+				add(x+1, y+1, x, y+1)
+			}
+			if !on(x-1, y) {
+				add(x, y+1, x, y)
+			}
+		}
+	}
+
+	// chain the edges into closed loops, starting from the top-left corner of
+	// each so that the output is stable, and which is always a corner
+	var polys [][]image.Point
+	for len(edges) > 0 {
+		start := image.Point{}
+		first := true
+		for p := range edges {
+			if first || p.Y < start.Y || p.Y == start.Y && p.X < start.X {
+				start, first = p, false
+			}
+		}
+		var poly []image.Point
+		p, dir := start, image.Point{}
+		for {
+			next := takeEdge(edges, p, dir)
+			d := next.Sub(p)
+			if d != dir {
+				poly = append(poly, p)
+			}
+			p, dir = next, d
+			if p == start {
+				break
+			}
+		}
+		polys = append(polys, poly)
+	}
+	return polys
+}
+
+// takeEdge removes an edge starting at p from edges and returns its end. Where
+// two edges start at p, two pixels touch at a corner, and the right turn from
+// the incoming direction dir is taken to keep the pixels apart.
+func takeEdge(edges map[image.Point][]image.Point, p, dir image.Point) image.Point {
+	out := edges[p]
+	i := 0
+	if len(out) > 1 {
+		right := image.Pt(-dir.Y, dir.X) // clockwise on screen, with y down
+		for j, q := range out {
+			if q.Sub(p) == right {
+				i = j
+			}
+		}
+	}
+	next := out[i]
+	if len(out) == 1 {
+		delete(edges, p)
+	} else {
+		edges[p] = append(out[:i:i], out[i+1:]...)
+	}
+	return next
+}
+
+// SVGPath returns the outline of the glyph for r, as traced by Outline, as SVG
+// path data in font pixels with its origin at the top-left of the character
+// cell, such as "M1 0H3V2H1Z". It returns an empty string if the font has no
+// glyph for r or the glyph is blank.
+func SVGPath(f *pixfont.PixFont, r rune) string {
+	return svgPath(Outline(f, r), func(p image.Point) image.Point { return p })
+}
+
+// svgPath returns polygons as SVG path data, with each point transformed by tr.
+func svgPath(polys [][]image.Point, tr func(image.Point) image.Point) string {
+	var b strings.Builder
+	for _, poly := range polys {
+		for i, p := range poly {
+			p = tr(p)
+			if i == 0 {
+				fmt.Fprintf(&b, "M%d %d", p.X, p.Y)
+				continue
+			}
+			// outlines are made of horizontal and vertical lines
+			if prev := tr(poly[i-1]); prev.Y == p.Y {
+				fmt.Fprintf(&b, "H%d", p.X)
+			} else {
+				fmt.Fprintf(&b, "V%d", p.Y)
+			}
+		}
+		b.WriteString("Z")
+	}
+	return b.String()
+}
+
+// SVGFont writes every glyph of f to w as a font in the SVG font format, which
+// FontForge and other font editors can import and convert to TrueType or
+// OpenType. Each font pixel is unit font units square, 100 if unit is less
+// than 1, and the glyphs sit on the baseline from f.Metrics. Advances include
+// the spacing of f. Glyphs for control characters, which XML can't
+// represent, are left out.
+func SVGFont(w io.Writer, f *pixfont.PixFont, unit int) error {
+	if unit < 1 {
+		unit = 100
+	}
+	m := f.Metrics()
+	sp := f.Spacing()
+	name := f.Name
+	if name == "" {
+		name = "pixfont"
+	}
+	esc := func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	// SVG fonts have y increasing upwards from the baseline
+	tr := func(p image.Point) image.Point {
+		return image.Pt(p.X*unit, (m.Ascent-p.Y)*unit)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<?xml version=\"1.0\" standalone=\"no\"?>\n")
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\">\n")
+	if f.Copyright != "" || f.Comment != "" {
+		var lines []string
+		for _, ln := range strings.Split(strings.TrimSpace(f.Copyright+"\n"+f.Comment), "\n") {
+			lines = append(lines, esc(ln))
+		}
+		fmt.Fprintf(bw, "<metadata>%s</metadata>\n", strings.Join(lines, "\n"))
+	}
+	fmt.Fprintf(bw, "<defs>\n<font horiz-adv-x=\"%d\">\n", (f.GetWidth()+sp)*unit)
+	fmt.Fprintf(bw, "<font-face font-family=\"%s\" units-per-em=\"%d\" ascent=\"%d\" descent=\"%d\"/>\n",
+		esc(name), f.GetHeight()*unit, m.Ascent*unit, -m.Descent*unit)
+	fmt.Fprintf(bw, "<missing-glyph horiz-adv-x=\"%d\"/>\n", (f.GetWidth()+sp)*unit)
+	for _, r := range f.Runes() {
+		if !xmlChar(r) {
+			continue
+		}
+		_, adv := f.MeasureRune(r)
+		fmt.Fprintf(bw, "<glyph unicode=\"&#x%X;\" horiz-adv-x=\"%d\"", r, (adv+sp)*unit)
+		if d := svgPath(Outline(f, r), tr); d != "" {
+			fmt.Fprintf(bw, " d=\"%s\"", d)
+		}
+		fmt.Fprintf(bw, "/>\n")
+	}
+	fmt.Fprintf(bw, "</font>\n</defs>\n</svg>\n")
+	return bw.Flush()
+}
+
+// xmlChar returns true if r can appear in an XML document.
+func xmlChar(r rune) bool {
+	switch {
+	case r < 0x20:
+		return r == '\t' || r == '\n' || r == '\r'
+	case r >= 0xD800 && r <= 0xDFFF, r == 0xFFFE, r == 0xFFFF:
+		return false
+	}
+	return true
+}
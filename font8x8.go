@@ -27,8 +27,11 @@ var eightData = []uint32{0x18, 0xff0018, 0xff0018, 0xff001f, 0xff001f, 0xff0000,
 //            XXXX      XXXX    XX  XX      XX     XXXX    XX   XX   XXXX
 //
 var Font8x8 = &PixFont{
-	8, 8,
-	eightMap,
-	eightData,
-	8,
+	Name:         "8x8",
+	Copyright:    "Public domain VGA font by Marcel Sondaar / IBM",
+	charWidth:    8,
+	charHeight:   8,
+	charmap:      eightMap,
+	data:         eightData,
+	varCharWidth: 8,
 }
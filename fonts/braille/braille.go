@@ -0,0 +1,95 @@
+// Package braille provides a font which draws text as braille cells, for
+// tactile design mockups and previews of accessible signage.
+//
+// The font has a glyph for each Unicode braille pattern (U+2800 to U+28FF), and
+// for the printable ASCII characters using the North American Braille ASCII
+// mapping, in which each character stands for one 6-dot cell. Letters draw as
+// uncontracted (grade 1) braille, but note that Braille ASCII has no capital or
+// number signs of its own: digits draw as the cells of Braille ASCII '1'-'0',
+// which differ from the number sign plus a-j used in running text.
+package braille
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// brailleASCII lists the Braille ASCII character for each 6-dot pattern, in the
+// order of the Unicode braille patterns U+2800 to U+283F.
+const brailleASCII = " A1B'K2L@CIF/MSP\"E3H9O6R^DJG>NTQ,*5<-U8V.%[$+X!&;:4\\0Z7(_?W]#Y)="
+
+// Font draws braille cells with 2x2 pixel dots and 1 pixel between them.
+var Font = New(2, 1)
+
+// dotPos gives the column and row of each of the 8 dots, in the bit order of
+// the Unicode braille patterns.
+var dotPos = [8][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}, {0, 3}, {1, 3}}
+
+// New creates a braille font with square dots of the given size in pixels,
+// separated by gap pixels. Cells are 2 dots wide and 4 dots tall, to fit the
+// 8-dot patterns. New panics if the cells are too large for a PixFont.
+func New(dot, gap int) *pixfont.PixFont {
+	w, h := 2*dot+gap, 4*dot+3*gap
+	if w > 32 || h > 255 {
+		panic(fmt.Sprintf("braille: %dx%d cells are too large for a PixFont", w, h))
+	}
+	glyphs := make(map[rune]map[int]string, 256+len(brailleASCII))
+	for p := 0; p < 256; p++ {
+		glyphs[0x2800+rune(p)] = cell(uint8(p), dot, gap, w, h)
+	}
+	for p, c := range brailleASCII {
+		if c == ' ' {
+			continue
+		}
+		glyphs[c] = glyphs[0x2800+rune(p)]
+		if c >= '@' && c <= '^' {
+			glyphs[c+0x20] = glyphs[c] // Braille ASCII ignores case
+		}
+	}
+	encoded, cm, err := pixfont.Pack(w, h, glyphs)
+	if err != nil {
+		panic(err)
+	}
+	return pixfont.NewPixFont(uint8(w), uint8(h), cm, encoded)
+}
+
+// cell draws a braille pattern in the text format used by pixfont.Pack.
+func cell(pattern uint8, dot, gap, w, h int) map[int]string {
+	rows := make([][]byte, h)
+	for y := range rows {
+		rows[y] = []byte(strings.Repeat(" ", w))
+	}
+	for bit, pos := range dotPos {
+		if pattern&(1<<uint(bit)) == 0 {
+			continue
+		}
+		x0, y0 := pos[0]*(dot+gap), pos[1]*(dot+gap)
+		for y := y0; y < y0+dot; y++ {
+			for x := x0; x < x0+dot; x++ {
+				rows[y][x] = 'X'
+			}
+		}
+	}
+	m := make(map[int]string, h)
+	for y, row := range rows {
+		m[y] = string(row)
+	}
+	return m
+}
+
+// Cells converts ASCII text into Unicode braille patterns using the Braille
+// ASCII mapping, for example to produce the text of a sign. Characters without
+// a mapping are kept as they are.
+func Cells(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c >= '`' && c <= '~' {
+			c -= 0x20
+		}
+		if i := strings.IndexRune(brailleASCII, c); i >= 0 && c < 0x80 {
+			return 0x2800 + rune(i)
+		}
+		return c
+	}, s)
+}
@@ -0,0 +1,58 @@
+package braille
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+)
+
+func TestNew(t *testing.T) {
+	f := New(1, 0)
+	for s, want := range map[string]string{
+		"⠁": "X\n",
+		"⡇": "X\nX\nX\nX\n",
+		"⢸": " X\n X\n X\n X\n",
+		"⣿": "XX\nXX\nXX\nXX\n",
+	} {
+		sd := &pixfont.StringDrawable{}
+		f.DrawString(sd, 0, 0, s, nil)
+		if got := sd.String(); got != want {
+			t.Errorf("%U is\n%s\nwant\n%s", []rune(s)[0], got, want)
+		}
+	}
+
+	if w, h := Font.GetWidth(), Font.GetHeight(); w != 5 || h != 11 {
+		t.Errorf("default cells are %dx%d, want 5x11", w, h)
+	}
+	sd := &pixfont.StringDrawable{}
+	Font.DrawString(sd, 0, 0, "⠉", nil) // dots 1 and 4
+	if got, want := sd.String(), "XX XX\nXX XX\n"; got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Braille ASCII characters draw as their cells, ignoring case
+	for _, c := range "Aa1!z" {
+		cell := []rune(Cells(string(c)))[0]
+		if !bytes.Equal(Font.GlyphMask(c).Pix, Font.GlyphMask(cell).Pix) {
+			t.Errorf("%q doesn't draw as %U", c, cell)
+		}
+	}
+	// every pattern, the Braille ASCII characters, and lowercase '@' to '^'
+	if n := len(Font.Runes()); n != 256+63+31 {
+		t.Errorf("font has %d glyphs", n)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for cells too wide for a PixFont")
+		}
+	}()
+	New(16, 1)
+}
+
+func TestCells(t *testing.T) {
+	if got, want := Cells("Abc 1, é"), "⠁⠃⠉⠀⠂⠠⠀é"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,12 @@
+// Package fixed bundles pre-converted fonts from the public domain X11 misc-fixed
+// family, so that several sizes are available without running a converter.
+//
+// The misc-fixed fonts are "free of all restrictions": the original BDF sources
+// were released into the public domain by their authors. To add another size,
+// convert its BDF file with bdf2pixfont and generate the Go source with fontgen:
+//
+//	bdf2pixfont 6x13.bdf > 6x13.txt
+//	fontgen -txt 6x13.txt -o font6x13
+//
+// then rename the generated package and variable to match this package.
+package fixed
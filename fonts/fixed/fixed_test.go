@@ -0,0 +1,26 @@
+package fixed
+
+import (
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+)
+
+func TestFont7x13(t *testing.T) {
+	f := Font7x13
+	for r := ' '; r <= '~'; r++ {
+		if !f.HasGlyph(r) {
+			t.Errorf("no glyph for %q", r)
+		}
+	}
+	if got := f.MeasureString("AB"); got != 14 {
+		t.Errorf("two characters advance %d pixels, want 14", got)
+	}
+
+	sd := &pixfont.StringDrawable{}
+	f.DrawString(sd, 0, 0, "A", nil)
+	want := "\n\n  XX\n X  X\nX    X\nX    X\nX    X\nXXXXXX\nX    X\nX    X\nX    X\n"
+	if got := sd.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,26 @@
+//   
+//   
+//   XXX                       XXXXXX           X   XXXXXX
+//  X   X                X          X          XX        X
+//  X                    X         X          X X       X
+//  X      XXXX  X XXX  XXXX      X   X    X    X      X
+// XXXX   X    X XX   X  X        X    X  X     X     XXX
+//  X     X    X X    X  X       X      XX      X        X
+//  X     X    X X    X  X       X      XX      X        X
+//  X     X    X X    X  X   X  X      X  X     X   X    X
+//  X      XXXX  X    X   XXX   X     X    X  XXXXX  XXXX
+
+package fixed
+
+import "github.com/pbnjay/pixfont"
+
+// Font7x13 is the misc-fixed 7x13 font, covering printable ASCII.
+var Font7x13 *pixfont.PixFont
+
+func init() {
+	charMap := map[int32]uint16{32: 0x0, 33: 0x1, 34: 0x2, 35: 0x3, 36: 0x34, 37: 0x35, 38: 0x36, 39: 0x37, 40: 0x68, 41: 0x69, 42: 0x6a, 43: 0x6b, 44: 0x9c, 45: 0x9d, 46: 0x9e, 47: 0x9f, 48: 0xd0, 49: 0xd1, 50: 0xd2, 51: 0xd3, 52: 0x104, 53: 0x105, 54: 0x106, 55: 0x107, 56: 0x138, 57: 0x139, 58: 0x13a, 59: 0x13b, 60: 0x16c, 61: 0x16d, 62: 0x16e, 63: 0x16f, 64: 0x1a0, 65: 0x1a1, 66: 0x1a2, 67: 0x1a3, 68: 0x1d4, 69: 0x1d5, 70: 0x1d6, 71: 0x1d7, 72: 0x208, 73: 0x209, 74: 0x20a, 75: 0x20b, 76: 0x23c, 77: 0x23d, 78: 0x23e, 79: 0x23f, 80: 0x270, 81: 0x271, 82: 0x272, 83: 0x273, 84: 0x2a4, 85: 0x2a5, 86: 0x2a6, 87: 0x2a7, 88: 0x2d8, 89: 0x2d9, 90: 0x2da, 91: 0x2db, 92: 0x30c, 93: 0x30d, 94: 0x30e, 95: 0x30f, 96: 0x340, 97: 0x341, 98: 0x342, 99: 0x343, 100: 0x374, 101: 0x375, 102: 0x376, 103: 0x377, 104: 0x3a8, 105: 0x3a9, 106: 0x3aa, 107: 0x3ab, 108: 0x3dc, 109: 0x3dd, 110: 0x3de, 111: 0x3df, 112: 0x410, 113: 0x411, 114: 0x412, 115: 0x413, 116: 0x444, 117: 0x445, 118: 0x446, 119: 0x447, 120: 0x478, 121: 0x479, 122: 0x47a, 123: 0x47b, 124: 0x4ac, 125: 0x4ad, 126: 0x4ae, 65533: 0x4af}
+	data := []uint32{0x0, 0x0, 0x140800, 0x14140800, 0x14140800, 0x3e000800, 0x14000800, 0x3e000800, 0x14000800, 0x14000000, 0x800, 0x0, 0x0, 0x0, 0x0, 0x8002200, 0x8002508, 0x806123c, 0x9080a, 0x9081c, 0x60428, 0x29121e, 0x112908, 0x2e1100, 0x0, 0x0, 0x0, 0x0, 0x410, 0x808, 0x8120808, 0x80c1004, 0x3e3f1004, 0x80c1004, 0x8120808, 0x808, 0x410, 0x0, 0x0, 0x0, 0x0, 0x20000000, 0x20000000, 0x10000000, 0x10000000, 0x8003e00, 0x4000000, 0x4000000, 0x208001c, 0x21c000c, 0x80002, 0x0, 0x0, 0x0, 0x3f1e080c, 0x20210c12, 0x10210a21, 0x8200821, 0x1c100821, 0x200c0821, 0x20020821, 0x21010812, 0x1e3f3e0c, 0x0, 0x0, 0x0, 0x0, 0x3f1c3f10, 0x20020118, 0x10010114, 0x8011d12, 0x81d2311, 0x4232011, 0x421203f, 0x2212110, 0x21e1e10, 0x0, 0x0, 0x0, 0x0, 0x1e1e, 0x2121, 0x8082121, 0x1c1c3121, 0x8082e1e, 0x2021, 0x2021, 0x1c081021, 0xc1c0e1e, 0x2080000, 0x0, 0x0, 0x0, 0x1e020020, 0x21040010, 0x21080008, 0x20103f04, 0x10200002, 0x8100004, 0x8083f08, 0x40010, 0x8020020, 0x0, 0x0, 0x0, 0x0, 0x1e1f0c1e, 0x21221221, 0x1222121, 0x1222139, 0x11e2125, 0x1223f35, 0x1222129, 0x21222101, 0x1e1f211e, 0x0, 0x0, 0x0, 0x0, 0x1e3f3f1f, 0x21010122, 0x1010122, 0x1010122, 0x10f0f22, 0x39010122, 0x21010122, 0x31010122, 0x2e013f1f, 0x0, 0x0, 0x0, 0x0, 0x21383e21, 0x11100821, 0x9100821, 0x5100821, 0x310083f, 0x5100821, 0x9100821, 0x11110821, 0x210e3e21, 0x0, 0x0, 0x0, 0x0, 0x1e212101, 0x21213301, 0x21233301, 0x21252d01, 0x21292d01, 0x21312101, 0x21212101, 0x21212101, 0x1e21213f, 0x0, 0x0, 0x0, 0x0, 0x1e1f1e1f, 0x21212121, 0x1212121, 0x1212121, 0x1e1f211f, 0x20052101, 0x20092501, 0x21112901, 0x1e211e01, 0x2000, 0x0, 0x0, 0x0, 0x2121213e, 0x21212108, 0x21212108, 0x21122108, 0x2d122108, 0x2d122108, 0x330c2108, 0x330c2108, 0x210c1e08, 0x0, 0x0, 0x0, 0x1e000000, 0x23f2221, 0x2202221, 0x2101412, 0x2081412, 0x20c080c, 0x2040812, 0x2020812, 0x2010821, 0x23f0821, 0x1e000000, 0x0, 0x0, 0x1e00, 0x81002, 0x141002, 0x221004, 0x1004, 0x1008, 0x1010, 0x1010, 0x1020, 0x1020, 0x3f001e00, 0x0, 0x0, 0x4, 0x10008, 0x10000, 0x10000, 0x1e1d1e00, 0x21232000, 0x1213e00, 0x1212100, 0x21233100, 0x1e1d2e00, 0x0, 0x0, 0x0, 0x0, 0x1c0020, 0x220020, 0x20020, 0x2e021e2e, 0x110f2131, 0x11023f21, 0xe020121, 0x1022131, 0x1e021e2e, 0x21000000, 0x1e000000, 0x0, 0x0, 0x1000001, 0x1200801, 0x1000001, 0x11300c1d, 0x9200823, 0x7200821, 0x9200821, 0x11200821, 0x21223e21, 0x220000, 0x1c0000, 0x0, 0x0, 0xc, 0x8, 0x8, 0x1e1d1608, 0x21232a08, 0x21212a08, 0x21212a08, 0x21212a08, 0x1e21223e, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1e1d2e1d, 0x21223123, 0x6022121, 0x18023123, 0x21022e1d, 0x1e022001, 0x2001, 0x2001, 0x0, 0x0, 0x0, 0x2, 0x2, 0x2222210f, 0x22222102, 0x2a222102, 0x2a142102, 0x2a143122, 0x14082e1c, 0x0, 0x0, 0x0, 0x38000000, 0x4000000, 0x4000000, 0x4000000, 0x83f2121, 0x6102112, 0x808210c, 0x404310c, 0x4022e12, 0x43f2021, 0x38002100, 0x1e00, 0x0, 0xe00, 0x1c241008, 0x362a1008, 0x2a121008, 0x2e000808, 0x36003008, 0x36000808, 0x3e001008, 0x36001008, 0x1c001008, 0xe00, 0x0}
+	Font7x13 = pixfont.NewPixFont(6, 13, charMap, data)
+	Font7x13.SetVariableWidth(false)
+}
+
@@ -0,0 +1,9 @@
+// Package pico bundles a tiny 3x5 font in the style of fantasy consoles such as
+// PICO-8 and TIC-80, for retro game tooling and overlays where every pixel
+// counts. Glyphs advance 4 pixels, including the spacing, so a 128 pixel wide
+// screen fits 32 characters per line.
+//
+// The font was drawn for this package and is released into the public domain.
+// Its glyphs are upper case only: lowercase letters draw as capitals, so that
+// text needn't be converted first.
+package pico
@@ -0,0 +1,23 @@
+// XXX  XX XX  XXX XXX X X XXX
+// X   X X X X  X    X X X X
+// XX  X X X X  X   XX  X  XXX
+// X   X X X X  X    X X X   X
+// X   XX  X X  X  XXX X X XXX
+
+package pico
+
+import "github.com/pbnjay/pixfont"
+
+// Font3x5 is a 3x5 font covering printable ASCII, with lowercase letters drawn
+// as capitals.
+var Font3x5 *pixfont.PixFont
+
+func init() {
+	charMap := map[int32]uint16{32: 0x0, 33: 0x1, 34: 0x2, 35: 0x3, 36: 0x14, 37: 0x15, 38: 0x16, 39: 0x17, 40: 0x28, 41: 0x29, 42: 0x2a, 43: 0x2b, 44: 0x3c, 45: 0x3d, 46: 0x3e, 47: 0x3f, 48: 0x50, 49: 0x51, 50: 0x52, 51: 0x53, 52: 0x64, 53: 0x65, 54: 0x66, 55: 0x67, 56: 0x78, 57: 0x79, 58: 0x7a, 59: 0x7b, 60: 0x8c, 61: 0x8d, 62: 0x8e, 63: 0x8f, 64: 0xa0, 65: 0xa1, 66: 0xa2, 67: 0xa3, 68: 0xb4, 69: 0xb5, 70: 0xb6, 71: 0xb7, 72: 0xc8, 73: 0xc9, 74: 0xca, 75: 0xcb, 76: 0xdc, 77: 0xdd, 78: 0xde, 79: 0xdf, 80: 0xf0, 81: 0xf1, 82: 0xf2, 83: 0xf3, 84: 0x104, 85: 0x105, 86: 0x106, 87: 0x107, 88: 0x118, 89: 0x119, 90: 0x11a, 91: 0x11b, 92: 0x12c, 93: 0x12d, 94: 0x12e, 95: 0x12f, 96: 0x140, 97: 0x141, 98: 0x142, 99: 0x143, 100: 0x154, 101: 0x155, 102: 0x156, 103: 0x157, 104: 0x168, 105: 0x169, 106: 0x16a, 107: 0x16b, 108: 0x17c, 109: 0x17d, 110: 0x17e, 111: 0x17f, 112: 0x190, 113: 0x191, 114: 0x192, 115: 0x193, 116: 0x1a4, 117: 0x1a5, 118: 0x1a6, 119: 0x1a7, 120: 0x1b8, 121: 0x1b9, 122: 0x1ba, 123: 0x1bb, 124: 0x1cc, 125: 0x1cd, 126: 0x1ce}
+	data := []uint32{0x5050200, 0x7050200, 0x5000200, 0x7000000, 0x5000200, 0x2030507, 0x2030403, 0x70207, 0x50106, 0x70507, 0x50202, 0x2020401, 0x7070401, 0x2020401, 0x50202, 0x4000000, 0x2000000, 0x2000700, 0x2000002, 0x1020001, 0x7070307, 0x4040205, 0x6070205, 0x4010205, 0x7070707, 0x7010705, 0x4010105, 0x4070707, 0x4050404, 0x4070704, 0x707, 0x2020505, 0x707, 0x2020405, 0x1000407, 0x7010004, 0x4020702, 0x6040001, 0x20702, 0x2010004, 0x6070702, 0x1050505, 0x1030705, 0x1050501, 0x6070506, 0x6070703, 0x1010105, 0x1030305, 0x5010105, 0x7010703, 0x5070705, 0x5020205, 0x3020207, 0x5020205, 0x5030705, 0x6030701, 0x5050701, 0x5050501, 0x5050501, 0x3050507, 0x6070207, 0x1050505, 0x7030507, 0x4050301, 0x3050601, 0x5050507, 0x5050502, 0x5050502, 0x7070502, 0x7020602, 0x3070505, 0x1040505, 0x1020702, 0x1010405, 0x3070705, 0x20601, 0x50402, 0x402, 0x402, 0x7000604, 0x6070702, 0x1050504, 0x1030700, 0x1050500, 0x6070500, 0x6070703, 0x1010105, 0x1030305, 0x5010105, 0x7010703, 0x5070705, 0x5020205, 0x3020207, 0x5020205, 0x5030705, 0x6030701, 0x5050701, 0x5050501, 0x5050501, 0x3050507, 0x6070207, 0x1050505, 0x7030507, 0x4050301, 0x3050601, 0x5050507, 0x5050502, 0x5050502, 0x7070502, 0x7020602, 0x6070505, 0x2040505, 0x3020702, 0x2010405, 0x6070705, 0x302, 0x40202, 0x70602, 0x10202, 0x302}
+	Font3x5 = pixfont.NewPixFont(3, 5, charMap, data)
+	Font3x5.SetVariableWidth(false)
+	Font3x5.Name = "Pico 3x5"
+	Font3x5.Copyright = "Public domain"
+	Font3x5.Comment = "Uppercase 3x5 font in the style of fantasy consoles; lowercase letters draw as capitals."
+}
@@ -0,0 +1,34 @@
+package pico
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+)
+
+func TestFont3x5(t *testing.T) {
+	f := Font3x5
+	for r := ' '; r <= '~'; r++ {
+		if !f.HasGlyph(r) {
+			t.Errorf("no glyph for %q", r)
+		}
+	}
+	if got := f.MeasureString(strings.Repeat("W", 32)); got != 128 {
+		t.Errorf("32 characters advance %d pixels, want 128", got)
+	}
+
+	sd := &pixfont.StringDrawable{}
+	f.DrawString(sd, 0, 0, "A1", nil)
+	want := "XXX XX\nX X  X\nXXX  X\nX X  X\nX X XXX\n"
+	if got := sd.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	for r := 'a'; r <= 'z'; r++ {
+		if !bytes.Equal(f.GlyphMask(r).Pix, f.GlyphMask(r-'a'+'A').Pix) {
+			t.Errorf("%q doesn't draw as a capital", r)
+		}
+	}
+}
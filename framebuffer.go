@@ -0,0 +1,114 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// FramebufferLayout describes how the pixels of a Framebuffer are packed into bytes.
+type FramebufferLayout int
+
+const (
+	// PageLayout packs 8 vertical pixels into each byte (least significant bit at
+	// the top), with each 8-row page stored left to right. This is the native
+	// layout of SSD1306, SH1106 and ST7565 OLED/LCD controllers.
+	PageLayout FramebufferLayout = iota
+
+	// HorizontalLayout packs 8 horizontal pixels into each byte (most significant
+	// bit at the left), with each row padded to a whole number of bytes. This is
+	// the native layout of Sharp memory LCDs and most e-paper controllers.
+	HorizontalLayout
+)
+
+// Framebuffer is a Drawable backed by a packed monochrome (1 bit per pixel) byte
+// buffer, so that rendered text can be sent straight to a display controller
+// without per-pixel conversion. A pixel is lit when the luminance of the color
+// it is set to is at least 50%, so use color.White to draw and color.Black to
+// erase. Framebuffer also implements image.Image.
+type Framebuffer struct {
+	// Pix holds the packed pixel data, laid out according to Layout.
+	Pix    []byte
+	Layout FramebufferLayout
+	Width  int
+	Height int
+}
+
+// NewFramebuffer creates a new, cleared Framebuffer of the given size and layout.
+func NewFramebuffer(w, h int, layout FramebufferLayout) *Framebuffer {
+	var n int
+	switch layout {
+	case PageLayout:
+		n = w * ((h + 7) / 8)
+	case HorizontalLayout:
+		n = ((w + 7) / 8) * h
+	}
+	return &Framebuffer{
+		Pix:    make([]byte, n),
+		Layout: layout,
+		Width:  w,
+		Height: h,
+	}
+}
+
+// offset returns the byte index and bit mask for the pixel at x,y.
+func (f *Framebuffer) offset(x, y int) (int, byte) {
+	if f.Layout == PageLayout {
+		return (y/8)*f.Width + x, 1 << uint(y&7)
+	}
+	return y*((f.Width+7)/8) + x/8, 0x80 >> uint(x&7)
+}
+
+// Set implements Drawable, lighting or clearing the pixel at x,y depending on
+// the luminance of c. Pixels outside of the framebuffer are ignored.
+func (f *Framebuffer) Set(x, y int, c color.Color) {
+	gc := color.GrayModel.Convert(c).(color.Gray)
+	f.SetBit(x, y, gc.Y >= 0x80)
+}
+
+// SetBit lights or clears the pixel at x,y. Pixels outside of the framebuffer
+// are ignored.
+func (f *Framebuffer) SetBit(x, y int, on bool) {
+	if x < 0 || y < 0 || x >= f.Width || y >= f.Height {
+		return
+	}
+	i, mask := f.offset(x, y)
+	if on {
+		f.Pix[i] |= mask
+	} else {
+		f.Pix[i] &^= mask
+	}
+}
+
+// Bit reports whether the pixel at x,y is lit.
+func (f *Framebuffer) Bit(x, y int) bool {
+	if x < 0 || y < 0 || x >= f.Width || y >= f.Height {
+		return false
+	}
+	i, mask := f.offset(x, y)
+	return f.Pix[i]&mask != 0
+}
+
+// Clear turns off every pixel in the framebuffer.
+func (f *Framebuffer) Clear() {
+	for i := range f.Pix {
+		f.Pix[i] = 0
+	}
+}
+
+// ColorModel implements image.Image.
+func (f *Framebuffer) ColorModel() color.Model {
+	return color.GrayModel
+}
+
+// Bounds implements image.Image.
+func (f *Framebuffer) Bounds() image.Rectangle {
+	return image.Rect(0, 0, f.Width, f.Height)
+}
+
+// At implements image.Image, returning white for lit pixels and black otherwise.
+func (f *Framebuffer) At(x, y int) color.Color {
+	if f.Bit(x, y) {
+		return color.White
+	}
+	return color.Black
+}
@@ -0,0 +1,88 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// DrawStringGlow draws s like DrawString, surrounded by a halo of the glow color
+// extending up to radius pixels from the glyphs, for neon and retro styles. The
+// halo fades out with distance from the glyphs; use a translucent glow color to
+// make it fainter overall.
+//
+// If dr is also an image.Image, halo pixels are blended over its existing
+// contents. Otherwise they are set directly to the faded color.
+func (p *PixFont) DrawStringGlow(dr Drawable, x, y int, s string, clr, glow color.Color, radius int) int {
+	d := p.Prerender(s, clr)
+	if radius > 0 && len(d.points) > 0 {
+		// find the bounds of the glyphs, plus room for the halo
+		b := image.Rectangle{d.points[0], d.points[0].Add(image.Point{1, 1})}
+		for _, pt := range d.points {
+			b = b.Union(image.Rectangle{pt, pt.Add(image.Point{1, 1})})
+		}
+		b = b.Inset(-radius)
+
+		// squared distance to the nearest glyph pixel, or -1 if out of reach
+		w := b.Dx()
+		dist := make([]int, w*b.Dy())
+		for i := range dist {
+			dist[i] = -1
+		}
+		r2 := radius * radius
+		for _, pt := range d.points {
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					d2 := dx*dx + dy*dy
+					if d2 > r2 {
+						continue
+					}
+					i := (pt.Y+dy-b.Min.Y)*w + pt.X + dx - b.Min.X
+					if dist[i] < 0 || d2 < dist[i] {
+						dist[i] = d2
+					}
+				}
+			}
+		}
+
+		gr, gg, gb, ga := glow.RGBA()
+		for i, d2 := range dist {
+			if d2 <= 0 {
+				continue // out of reach, or under a glyph
+			}
+			f := (float64(radius) + 1 - math.Sqrt(float64(d2))) / float64(radius)
+			if f > 1 {
+				f = 1
+			}
+			c := color.RGBA64{
+				uint16(float64(gr) * f), uint16(float64(gg) * f),
+				uint16(float64(gb) * f), uint16(float64(ga) * f),
+			}
+			blend(dr, x+b.Min.X+i%w, y+b.Min.Y+i/w, c)
+		}
+	}
+	return d.Draw(dr, x, y)
+}
+
+// blend composites c over the pixel at x,y of dr if dr is also an image.Image,
+// and otherwise sets it to c.
+func blend(dr Drawable, x, y int, c color.Color) {
+	img, ok := dr.(image.Image)
+	_, _, _, a := c.RGBA()
+	if !ok || a == 0xffff {
+		dr.Set(x, y, c)
+		return
+	}
+	if a == 0 || !(image.Point{x, y}.In(img.Bounds())) {
+		return
+	}
+	sr, sg, sb, _ := c.RGBA()
+	dr0, dg, db, da := img.At(x, y).RGBA()
+	ia := 0xffff - a
+	dr.Set(x, y, color.RGBA64{
+		uint16(sr + dr0*ia/0xffff),
+		uint16(sg + dg*ia/0xffff),
+		uint16(sb + db*ia/0xffff),
+		uint16(a + da*ia/0xffff),
+	})
+}
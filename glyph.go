@@ -0,0 +1,87 @@
+package pixfont
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"image"
+	"sort"
+	"strings"
+)
+
+// Runes returns the runes which have a glyph in this PixFont, in ascending order.
+func (p *PixFont) Runes() []rune {
+	if p.sorted != nil {
+		return append([]rune(nil), p.sorted...)
+	}
+	rs := make([]rune, 0, len(p.charmap))
+	for r := range p.charmap {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i] < rs[j]
+	})
+	return rs
+}
+
+// GlyphMask returns the glyph for r as an alpha mask, with opaque pixels for
+// each pixel of the glyph. The mask bounds are always the full character cell,
+// (0,0) to (width,height). If r has no glyph, GlyphMask returns nil.
+func (p *PixFont) GlyphMask(r rune) *image.Alpha {
+	if !p.has(r) {
+		return nil
+	}
+	m := image.NewAlpha(image.Rect(0, 0, int(p.charWidth), int(p.charHeight)))
+	p.drawRune(func(x, y int) {
+		m.Pix[m.PixOffset(x, y)] = 0xff
+	}, 0, 0, r, p.Spacing())
+	return m
+}
+
+// glyphRows returns the glyph for c as rows of the full character cell, with an
+// 'X' for each opaque pixel, as for Pack.
+func (p *PixFont) glyphRows(c rune) map[int]string {
+	w, h := int(p.charWidth), int(p.charHeight)
+	rows := make([][]byte, h)
+	for y := range rows {
+		rows[y] = []byte(strings.Repeat(" ", w))
+	}
+	p.drawGlyph(func(x, y int) {
+		if x >= 0 && y >= 0 && x < w && y < h {
+			rows[y][x] = 'X'
+		}
+	}, 0, 0, c, p.Spacing())
+	glyph := make(map[int]string, h)
+	for y, row := range rows {
+		glyph[y] = string(row)
+	}
+	return glyph
+}
+
+// Hash returns a stable hex-encoded SHA-256 digest of the font's dimensions,
+// variable width setting, character map and glyph data. Caches, golden tests and
+// clients and servers can compare hashes to detect when a font has changed.
+func (p *PixFont) Hash() string {
+	h := sha256.New()
+	hdr := []byte{p.charWidth, p.charHeight, 0}
+	if p.IsVariableWidth() {
+		hdr[2] = 1
+	}
+	h.Write(hdr)
+
+	buf := make([]byte, 6)
+	for _, r := range p.Runes() {
+		binary.LittleEndian.PutUint32(buf, uint32(r))
+		poff, _ := p.lookup(r)
+		binary.LittleEndian.PutUint16(buf[4:], poff)
+		h.Write(buf)
+	}
+	if p.lazy != nil {
+		p.lazy.loadAll(p.data)
+	}
+	for i := 0; i < p.numWords(); i++ {
+		binary.LittleEndian.PutUint32(buf, p.word(i))
+		h.Write(buf[:4])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
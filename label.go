@@ -0,0 +1,72 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// Label describes the box that DrawLabel draws around its text.
+type Label struct {
+	// Color is the text color.
+	Color color.Color
+	// Background fills the box behind the text. If nil, the box is not filled.
+	Background color.Color
+	// Border draws a 1px border around the box. If nil, no border is drawn.
+	Border color.Color
+
+	// Padding is the number of pixels between the text and the box edge (or
+	// border, if present).
+	Padding int
+	// Rounded removes the corner pixels of the box for a softer appearance.
+	Rounded bool
+}
+
+// DrawLabel draws s inside a box described by l, with the top-left corner of the
+// box at x,y. DrawLabel returns the bounds of the box.
+func (p *PixFont) DrawLabel(dr Drawable, x, y int, s string, l Label) image.Rectangle {
+	sp := p.Spacing()
+	tw := p.measureString(s, sp)
+	if tw > 0 {
+		tw -= sp // no spacing after the last character
+	}
+	inset := l.Padding
+	if l.Border != nil {
+		inset++
+	}
+	r := image.Rect(x, y, x+tw+2*inset, y+int(p.charHeight)+2*inset)
+
+	if l.Background != nil {
+		fillRect(setter(dr, l.Background), r, l.Rounded)
+	}
+	if l.Border != nil {
+		set := setter(dr, l.Border)
+		inner := r.Inset(1)
+		for xx := r.Min.X; xx < r.Max.X; xx++ {
+			if l.Rounded && (xx == r.Min.X || xx == r.Max.X-1) {
+				continue
+			}
+			set(xx, r.Min.Y)
+			set(xx, r.Max.Y-1)
+		}
+		for yy := inner.Min.Y; yy < inner.Max.Y; yy++ {
+			set(r.Min.X, yy)
+			set(r.Max.X-1, yy)
+		}
+	}
+
+	p.drawString(dr, x+inset, y+inset, s, l.Color, sp)
+	return r
+}
+
+// fillRect calls set for every pixel within r. If rounded is true, the four corner
+// pixels are skipped.
+func fillRect(set func(x, y int), r image.Rectangle, rounded bool) {
+	for yy := r.Min.Y; yy < r.Max.Y; yy++ {
+		for xx := r.Min.X; xx < r.Max.X; xx++ {
+			if rounded && (yy == r.Min.Y || yy == r.Max.Y-1) && (xx == r.Min.X || xx == r.Max.X-1) {
+				continue
+			}
+			set(xx, yy)
+		}
+	}
+}
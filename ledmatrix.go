@@ -0,0 +1,112 @@
+package pixfont
+
+import "image/color"
+
+// LEDMatrix is a Drawable adapter for displays built from chained LED matrix
+// panels (HUB75, WS2812 and similar). It maps the logical x,y coordinates of the
+// assembled display onto the physical coordinates of the chain, so DrawString
+// can target the panels directly.
+//
+// Physically, the chain is treated as one long strip of panels laid end to end:
+// Panels*PanelWidth pixels wide and PanelHeight pixels tall, with the first panel
+// in the chain at the left. Logically, the panels are arranged in Rows rows,
+// filled left to right starting at the top.
+type LEDMatrix struct {
+	// Dst receives pixels in physical chain coordinates.
+	Dst Drawable
+
+	// PanelWidth and PanelHeight are the native dimensions of a single panel.
+	PanelWidth, PanelHeight int
+	// Panels is the number of panels in the chain.
+	Panels int
+	// Rows is the number of rows of panels in the logical display. Zero is
+	// treated as a single row.
+	Rows int
+
+	// Serpentine indicates the chain snakes back and forth between rows of
+	// panels, so panels on odd rows run right to left and are mounted upside
+	// down.
+	Serpentine bool
+	// Rotation is the clockwise rotation of every panel in degrees: 0, 90, 180
+	// or 270.
+	Rotation int
+	// ZigZag indicates that alternate rows of LEDs within each panel are wired in
+	// opposite directions (common for WS2812 panels). It only affects Index.
+	ZigZag bool
+}
+
+// Size returns the logical width and height of the assembled display.
+func (m *LEDMatrix) Size() (int, int) {
+	lw, lh := m.panelSize()
+	rows := m.rows()
+	return lw * (m.Panels / rows), lh * rows
+}
+
+func (m *LEDMatrix) rows() int {
+	if m.Rows < 1 {
+		return 1
+	}
+	return m.Rows
+}
+
+// panelSize returns the logical dimensions of a (possibly rotated) panel.
+func (m *LEDMatrix) panelSize() (int, int) {
+	if m.Rotation == 90 || m.Rotation == 270 {
+		return m.PanelHeight, m.PanelWidth
+	}
+	return m.PanelWidth, m.PanelHeight
+}
+
+// Map converts logical display coordinates to physical chain coordinates. The
+// final result is false if x,y is outside of the display.
+func (m *LEDMatrix) Map(x, y int) (int, int, bool) {
+	w, h := m.Size()
+	if x < 0 || y < 0 || x >= w || y >= h {
+		return 0, 0, false
+	}
+	lw, lh := m.panelSize()
+	cols := m.Panels / m.rows()
+
+	prow, pcol := y/lh, x/lw
+	lx, ly := x%lw, y%lh
+	if m.Serpentine && prow%2 == 1 {
+		pcol = cols - 1 - pcol
+		lx, ly = lw-1-lx, lh-1-ly
+	}
+	panel := prow*cols + pcol
+
+	// undo the panel rotation to get native panel coordinates
+	px, py := lx, ly
+	switch m.Rotation {
+	case 90:
+		px, py = ly, m.PanelHeight-1-lx
+	case 180:
+		px, py = m.PanelWidth-1-lx, m.PanelHeight-1-ly
+	case 270:
+		px, py = m.PanelWidth-1-ly, lx
+	}
+	return panel*m.PanelWidth + px, py, true
+}
+
+// Index returns the position along the LED strip of the pixel at logical x,y,
+// for addressable LED chains where each panel is a continuous strip. The final
+// result is false if x,y is outside of the display.
+func (m *LEDMatrix) Index(x, y int) (int, bool) {
+	px, py, ok := m.Map(x, y)
+	if !ok {
+		return 0, false
+	}
+	panel, px := px/m.PanelWidth, px%m.PanelWidth
+	if m.ZigZag && py%2 == 1 {
+		px = m.PanelWidth - 1 - px
+	}
+	return panel*m.PanelWidth*m.PanelHeight + py*m.PanelWidth + px, true
+}
+
+// Set implements Drawable, forwarding the pixel to Dst in physical coordinates.
+// Pixels outside of the display are ignored.
+func (m *LEDMatrix) Set(x, y int, c color.Color) {
+	if px, py, ok := m.Map(x, y); ok {
+		m.Dst.Set(px, py, c)
+	}
+}
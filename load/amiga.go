@@ -0,0 +1,181 @@
+package load
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pbnjay/pixfont"
+)
+
+const (
+	hunkHeader = 0x3F3
+	hunkCode   = 0x3E9
+	hunkData   = 0x3EA
+
+	// offset of struct TextFont within the font hunk: MOVEQ/RTS (4 bytes) followed
+	// by struct DiskFontHeader (54 bytes)
+	amigaTextFont = 4 + 54
+
+	amigaProportional = 0x20 // FPF_PROPORTIONAL
+)
+
+// Amiga reads a classic Amiga bitmap font. The reader should contain one size of
+// the font (e.g. "topaz/8" from the FONTS: drawer), not the ".font" contents
+// file. Each glyph is placed in a common cell at its offset from the kerning
+// table, and the cell is wide enough for every glyph and for every advance in
+// the spacing table. Proportional fonts become variable width PixFonts, whose
+// advances follow the pixels drawn, so the spacing table's advances and
+// negative kerning aren't kept.
+func Amiga(r io.Reader) (*pixfont.PixFont, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	hunk, err := amigaHunk(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(hunk) < amigaTextFont+52 {
+		return nil, errors.New("load: amiga font is truncated")
+	}
+
+	be := binary.BigEndian
+	tf := hunk[amigaTextFont:]
+	ysize := int(be.Uint16(tf[20:]))
+	flags := tf[23]
+	xsize := int(be.Uint16(tf[24:]))
+	loChar, hiChar := int(tf[32]), int(tf[33])
+	charData := int(be.Uint32(tf[34:]))
+	modulo := int(be.Uint16(tf[38:]))
+	charLoc := int(be.Uint32(tf[40:]))
+	charSpace := int(be.Uint32(tf[44:]))
+	charKern := int(be.Uint32(tf[48:]))
+
+	n := hiChar - loChar + 1
+	if hiChar < loChar || charLoc+4*n > len(hunk) || charData+modulo*ysize > len(hunk) {
+		return nil, errors.New("load: amiga font tables are out of range")
+	}
+	word := func(table, i int) int {
+		if table == 0 || table+2*i+2 > len(hunk) {
+			return 0
+		}
+		return int(int16(be.Uint16(hunk[table+2*i:])))
+	}
+
+	type glyph struct {
+		offset, width, kern, space int
+	}
+	glyphs := make(map[rune]glyph, n)
+	maxWidth := 0
+	for i := 0; i < n; i++ {
+		g := glyph{
+			offset: int(be.Uint16(hunk[charLoc+4*i:])),
+			width:  int(be.Uint16(hunk[charLoc+4*i+2:])),
+			kern:   word(charKern, i),
+			space:  xsize,
+		}
+		if charSpace != 0 {
+			g.space = word(charSpace, i)
+		}
+		if g.kern < 0 {
+			g.kern = 0 // pixfont can't draw to the left of the pen
+		}
+		if g.width == 0 {
+			continue
+		}
+		if w := g.kern + g.width; w > maxWidth {
+			maxWidth = w
+		}
+		if g.space > maxWidth {
+			maxWidth = g.space
+		}
+		glyphs[rune(loChar+i)] = g
+	}
+	if maxWidth > 32 {
+		return nil, fmt.Errorf("load: amiga font is %d pixels wide, at most 32 are supported", maxWidth)
+	}
+	if ysize > 255 {
+		return nil, fmt.Errorf("load: amiga font is %d pixels tall, at most 255 are supported", ysize)
+	}
+
+	bitmaps := make(map[rune]*bitmap, len(glyphs))
+	for r, g := range glyphs {
+		b := newBitmap(maxWidth, ysize)
+		for y := 0; y < ysize; y++ {
+			row := hunk[charData+y*modulo:]
+			for x := 0; x < g.width; x++ {
+				bit := g.offset + x
+				if bit/8 < modulo && row[bit/8]&(0x80>>uint(bit%8)) != 0 {
+					b.set(g.kern+x, y)
+				}
+			}
+		}
+		bitmaps[r] = b
+	}
+
+	return newFont(maxWidth, ysize, bitmaps, flags&amigaProportional != 0)
+}
+
+// amigaHunk returns the contents of the first code or data hunk of an AmigaDOS
+// load file. Data which is already a bare font hunk is returned as-is.
+func amigaHunk(data []byte) ([]byte, error) {
+	be := binary.BigEndian
+	if len(data) >= 4 && be.Uint32(data) == 0x70FF4E75 { // MOVEQ #-1,D0 : RTS
+		return data, nil
+	}
+
+	errTruncated := errors.New("load: amiga font hunk file is truncated")
+	pos := 0
+	long := func() (int, bool) {
+		if pos+4 > len(data) {
+			return 0, false
+		}
+		v := be.Uint32(data[pos:])
+		pos += 4
+		return int(v), true
+	}
+
+	if v, ok := long(); !ok || v != hunkHeader {
+		return nil, errors.New("load: not an amiga font (missing HUNK_HEADER)")
+	}
+	// skip resident library names
+	for {
+		n, ok := long()
+		if !ok {
+			return nil, errTruncated
+		}
+		if n == 0 {
+			break
+		}
+		pos += 4 * n
+	}
+	if _, ok := long(); !ok { // table size
+		return nil, errTruncated
+	}
+	first, ok1 := long()
+	last, ok2 := long()
+	if !ok1 || !ok2 || last < first {
+		return nil, errTruncated
+	}
+	pos += 4 * (last - first + 1) // hunk sizes
+
+	for pos < len(data) {
+		typ, ok := long()
+		if !ok {
+			break
+		}
+		typ &= 0x3FFFFFFF
+		if typ != hunkCode && typ != hunkData {
+			return nil, fmt.Errorf("load: unexpected amiga hunk type %#x", typ)
+		}
+		size, ok := long()
+		if !ok || pos+4*size > len(data) {
+			return nil, errTruncated
+		}
+		return data[pos : pos+4*size], nil
+	}
+	return nil, errTruncated
+}
@@ -0,0 +1,181 @@
+package load
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// bdfChar is a glyph from a BDF font, with its bitmap rows as hex strings.
+type bdfChar struct {
+	enc       int
+	advance   int
+	w, h      int
+	xoff, yof int
+	rows      []string
+}
+
+// BDF reads a font in the Glyph Bitmap Distribution Format used by X11, such
+// as the misc-fixed family. Glyphs are placed in a common cell as tall as the
+// font's bounding box, with the baseline where the bounding box puts it, and as
+// wide as the widest glyph or advance. The font's FONT name, COPYRIGHT property
+// and COMMENT lines are kept as its metadata. Glyphs without an encoding are
+// skipped.
+//
+// See https://adobe-type-tools.github.io/font-tech-notes/pdfs/5005.BDF_Spec.pdf
+func BDF(r io.Reader) (*pixfont.PixFont, error) {
+	var (
+		name, copyright string
+		comments        []string
+		bbox            []int
+		chars           []bdfChar
+		ch              *bdfChar
+		bitmapRows      = -1 // the number of bitmap rows still to read
+	)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if bitmapRows > 0 {
+			ch.rows = append(ch.rows, line)
+			bitmapRows--
+			continue
+		}
+		key, rest := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			key, rest = line[:i], strings.TrimSpace(line[i:])
+		}
+		nums := func(n int) ([]int, error) {
+			fs := strings.Fields(rest)
+			if len(fs) < n {
+				return nil, fmt.Errorf("load: bdf %s needs %d numbers", key, n)
+			}
+			v := make([]int, n)
+			for i := range v {
+				var err error
+				if v[i], err = strconv.Atoi(fs[i]); err != nil {
+					return nil, fmt.Errorf("load: bdf %s: %v", key, err)
+				}
+			}
+			return v, nil
+		}
+
+		var err error
+		switch key {
+		case "FONT":
+			name = rest
+		case "COMMENT":
+			if rest != "" {
+				comments = append(comments, strings.Trim(rest, `"`))
+			}
+		case "COPYRIGHT":
+			copyright = strings.Trim(rest, `"`)
+		case "FONTBOUNDINGBOX":
+			bbox, err = nums(4)
+		case "STARTCHAR":
+			ch = &bdfChar{enc: -1}
+		case "ENCODING":
+			var v []int
+			if v, err = nums(1); err == nil && ch != nil {
+				ch.enc = v[0]
+			}
+		case "DWIDTH":
+			var v []int
+			if v, err = nums(1); err == nil && ch != nil {
+				ch.advance = v[0]
+			}
+		case "BBX":
+			var v []int
+			if v, err = nums(4); err == nil && ch != nil {
+				ch.w, ch.h, ch.xoff, ch.yof = v[0], v[1], v[2], v[3]
+			}
+		case "BITMAP":
+			if ch == nil {
+				return nil, errors.New("load: bdf BITMAP outside a glyph")
+			}
+			if ch.w < 0 || ch.h < 0 {
+				return nil, fmt.Errorf("load: bdf glyph %d has a negative size", ch.enc)
+			}
+			bitmapRows = ch.h
+		case "ENDCHAR":
+			if ch != nil && ch.enc >= 0 {
+				chars = append(chars, *ch)
+			}
+			ch, bitmapRows = nil, -1
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if bitmapRows > 0 {
+		return nil, errors.New("load: bdf ends within a bitmap")
+	}
+	if bbox == nil {
+		return nil, errors.New("load: bdf has no FONTBOUNDINGBOX")
+	}
+	if len(chars) == 0 {
+		return nil, errors.New("load: bdf has no glyphs")
+	}
+
+	// lay the glyphs out in a common cell, shifting right if any glyph extends
+	// to the left of the pen
+	cellHeight, ascent := bbox[1], bbox[1]+bbox[3]
+	minX, cellWidth, variable := 0, 0, false
+	for _, c := range chars {
+		if c.xoff < minX {
+			minX = c.xoff
+		}
+		if c.advance != chars[0].advance {
+			variable = true
+		}
+	}
+	for _, c := range chars {
+		if w := c.xoff - minX + c.w; w > cellWidth {
+			cellWidth = w
+		}
+		if a := c.advance - minX; a > cellWidth {
+			cellWidth = a
+		}
+	}
+	if cellWidth > 32 {
+		return nil, fmt.Errorf("load: bdf is %d pixels wide, at most 32 are supported", cellWidth)
+	}
+	if cellHeight < 1 {
+		return nil, errors.New("load: bdf has no height")
+	}
+
+	bitmaps := make(map[rune]*bitmap, len(chars))
+	for _, c := range chars {
+		b := newBitmap(cellWidth, cellHeight)
+		top := ascent - c.yof - c.h
+		for y, row := range c.rows {
+			for x := 0; x < c.w; x++ {
+				i := x / 4
+				if i >= len(row) {
+					return nil, fmt.Errorf("load: bdf glyph %d has a short bitmap row %q", c.enc, row)
+				}
+				nibble, err := strconv.ParseUint(row[i:i+1], 16, 8)
+				if err != nil {
+					return nil, fmt.Errorf("load: bdf glyph %d has a bad bitmap row %q", c.enc, row)
+				}
+				if nibble&(8>>(x%4)) != 0 {
+					b.set(c.xoff-minX+x, top+y)
+				}
+			}
+		}
+		bitmaps[rune(c.enc)] = b
+	}
+	f, err := newFont(cellWidth, cellHeight, bitmaps, variable)
+	if err != nil {
+		return nil, err
+	}
+	f.Name, f.Copyright, f.Comment = name, copyright, strings.Join(comments, "\n")
+	return f, nil
+}
@@ -0,0 +1,247 @@
+package load
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// bmChar is a glyph from an AngelCode BMFont descriptor.
+type bmChar struct {
+	ID       int `json:"id" xml:"id,attr"`
+	X        int `json:"x" xml:"x,attr"`
+	Y        int `json:"y" xml:"y,attr"`
+	Width    int `json:"width" xml:"width,attr"`
+	Height   int `json:"height" xml:"height,attr"`
+	XOffset  int `json:"xoffset" xml:"xoffset,attr"`
+	YOffset  int `json:"yoffset" xml:"yoffset,attr"`
+	XAdvance int `json:"xadvance" xml:"xadvance,attr"`
+	Page     int `json:"page" xml:"page,attr"`
+}
+
+// bmDescriptor is the part of a BMFont descriptor needed to extract glyphs.
+// The JSON flavor stores pages as a list of file names, the others by id.
+type bmDescriptor struct {
+	Info struct {
+		Face string `json:"face" xml:"face,attr"`
+	} `json:"info" xml:"info"`
+	Common struct {
+		LineHeight int `json:"lineHeight" xml:"lineHeight,attr"`
+		Pages      int `json:"pages" xml:"pages,attr"`
+	} `json:"common" xml:"common"`
+	Pages []string `json:"pages" xml:"-"`
+	Chars []bmChar `json:"chars" xml:"chars>char"`
+
+	XMLPages []struct {
+		ID   int    `xml:"id,attr"`
+		File string `xml:"file,attr"`
+	} `json:"-" xml:"pages>page"`
+}
+
+// BMFont reads a font from an AngelCode BMFont descriptor, as created by many
+// game art tools. The text, XML and JSON (used by Phaser and pixi.js tooling)
+// flavors of the descriptor are all accepted. The pages function is called to
+// open each texture page named by the descriptor, usually relative to the
+// descriptor's directory:
+//
+//	f, err := load.BMFont(r, func(file string) (image.Image, error) {
+//		pf, err := os.Open(filepath.Join(dir, file))
+//		if err != nil {
+//			return nil, err
+//		}
+//		defer pf.Close()
+//		img, _, err := image.Decode(pf)
+//		return img, err
+//	})
+//
+// Glyph pixels are those which are both mostly opaque and light, matching the
+// white on transparent (or black) textures BMFont creates.
+func BMFont(r io.Reader, pages func(file string) (image.Image, error)) (*pixfont.PixFont, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var desc bmDescriptor
+	switch trimmed := bytes.TrimSpace(src); {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err = json.Unmarshal(trimmed, &desc)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		err = xml.Unmarshal(trimmed, &desc)
+		for _, p := range desc.XMLPages {
+			if err == nil {
+				err = desc.setPage(p.ID, p.File)
+			}
+		}
+	default:
+		err = desc.parseText(trimmed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load: bmfont: %v", err)
+	}
+	if len(desc.Chars) == 0 {
+		return nil, errors.New("load: bmfont has no glyphs")
+	}
+
+	// lay the glyphs out in a common cell, shifting right if any glyph extends
+	// to the left of the pen
+	minX, cellWidth, cellHeight := 0, 0, desc.Common.LineHeight
+	variable := false
+	for _, c := range desc.Chars {
+		if c.XOffset < minX {
+			minX = c.XOffset
+		}
+		if c.YOffset+c.Height > cellHeight {
+			cellHeight = c.YOffset + c.Height
+		}
+		if c.XAdvance != desc.Chars[0].XAdvance {
+			variable = true
+		}
+	}
+	for _, c := range desc.Chars {
+		if w := c.XOffset - minX + c.Width; w > cellWidth {
+			cellWidth = w
+		}
+	}
+	if cellWidth > 32 {
+		return nil, fmt.Errorf("load: bmfont is %d pixels wide, at most 32 are supported", cellWidth)
+	}
+	if cellHeight < 1 {
+		return nil, errors.New("load: bmfont has no height")
+	}
+	if cellHeight > 255 {
+		return nil, fmt.Errorf("load: bmfont is %d pixels tall, at most 255 are supported", cellHeight)
+	}
+
+	images := make(map[int]image.Image)
+	bitmaps := make(map[rune]*bitmap, len(desc.Chars))
+	for _, c := range desc.Chars {
+		img, ok := images[c.Page]
+		if !ok {
+			if c.Page < 0 || c.Page >= len(desc.Pages) {
+				return nil, fmt.Errorf("load: bmfont glyph %d uses undefined page %d", c.ID, c.Page)
+			}
+			img, err = pages(desc.Pages[c.Page])
+			if err != nil {
+				return nil, err
+			}
+			images[c.Page] = img
+		}
+
+		b := newBitmap(cellWidth, cellHeight)
+		for y := 0; y < c.Height; y++ {
+			for x := 0; x < c.Width; x++ {
+				px := color.NRGBAModel.Convert(img.At(c.X+x, c.Y+y)).(color.NRGBA)
+				gray := color.GrayModel.Convert(color.RGBA{px.R, px.G, px.B, 0xff}).(color.Gray)
+				if px.A >= 0x80 && gray.Y >= 0x80 {
+					b.set(c.XOffset-minX+x, c.YOffset+y)
+				}
+			}
+		}
+		bitmaps[rune(c.ID)] = b
+	}
+	f, err := newFont(cellWidth, cellHeight, bitmaps, variable)
+	if err != nil {
+		return nil, err
+	}
+	f.Name = desc.Info.Face
+	return f, nil
+}
+
+// bmMaxPages limits the page ids of descriptors which don't declare a count.
+const bmMaxPages = 256
+
+func (d *bmDescriptor) setPage(id int, file string) error {
+	limit := d.Common.Pages
+	if limit <= 0 {
+		limit = bmMaxPages
+	}
+	if id < 0 || id >= limit {
+		return fmt.Errorf("page id %d out of range", id)
+	}
+	for len(d.Pages) <= id {
+		d.Pages = append(d.Pages, "")
+	}
+	d.Pages[id] = file
+	return nil
+}
+
+// parseText parses the text flavor of a BMFont descriptor, which has one tag
+// per line followed by key=value pairs.
+func (d *bmDescriptor) parseText(src []byte) error {
+	s := bufio.NewScanner(bytes.NewReader(src))
+	for s.Scan() {
+		tag, attrs := bmTextAttrs(s.Text())
+		num := func(key string) int {
+			v, _ := strconv.Atoi(attrs[key])
+			return v
+		}
+		switch tag {
+		case "info":
+			d.Info.Face = attrs["face"]
+		case "common":
+			d.Common.LineHeight = num("lineHeight")
+			d.Common.Pages = num("pages")
+		case "page":
+			if err := d.setPage(num("id"), attrs["file"]); err != nil {
+				return err
+			}
+		case "char":
+			d.Chars = append(d.Chars, bmChar{
+				ID: num("id"), X: num("x"), Y: num("y"),
+				Width: num("width"), Height: num("height"),
+				XOffset: num("xoffset"), YOffset: num("yoffset"),
+				XAdvance: num("xadvance"), Page: num("page"),
+			})
+		}
+	}
+	return s.Err()
+}
+
+// bmTextAttrs splits a line of a text BMFont descriptor into its tag and
+// attributes. Quoted values may contain spaces.
+func bmTextAttrs(line string) (string, map[string]string) {
+	line = strings.TrimSpace(line)
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, nil
+	}
+	tag, rest := line[:i], line[i:]
+	attrs := make(map[string]string)
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			break
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+		var val string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			val, rest = rest[1:end+1], rest[end+1:]
+			rest = strings.TrimPrefix(rest, "\"")
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			val, rest = rest[:end], rest[end:]
+		}
+		attrs[key] = val
+	}
+	return tag, attrs
+}
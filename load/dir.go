@@ -0,0 +1,174 @@
+package load
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png" // glyph images
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Dir loads a font from a directory of PNG images, one glyph per file, as
+// artists often deliver them. Files are named either for the code point, like
+// "U+0041.png", or for the character itself, like "A.png". Other files are
+// ignored.
+//
+// The background of each image is its most common color, and any pixel that is
+// clearly different from it is part of the glyph. Rows which are blank in every
+// image are trimmed from the top and bottom of the font, and each glyph is
+// trimmed to its own left and right edges to create a variable width font.
+func Dir(path string) (*pixfont.PixFont, error) {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	masks := make(map[rune][][]bool)
+	height := 0
+	for _, fi := range entries {
+		name := fi.Name()
+		if fi.IsDir() || !strings.EqualFold(filepath.Ext(name), ".png") {
+			continue
+		}
+		r, ok := glyphFileRune(strings.TrimSuffix(name, filepath.Ext(name)))
+		if !ok {
+			continue
+		}
+		m, err := readGlyphImage(filepath.Join(path, name))
+		if err != nil {
+			return nil, err
+		}
+		if len(m) > height {
+			height = len(m)
+		}
+		masks[r] = m
+	}
+	if len(masks) == 0 {
+		return nil, errors.New("load: no glyph images found")
+	}
+
+	// trim rows that are empty in every glyph, and columns of each glyph
+	top, bottom := height, 0
+	lefts, widths := make(map[rune]int), make(map[rune]int)
+	cellWidth := 0
+	for r, m := range masks {
+		left, right := -1, -1
+		for y, row := range m {
+			for x, on := range row {
+				if !on {
+					continue
+				}
+				if y < top {
+					top = y
+				}
+				if y+1 > bottom {
+					bottom = y + 1
+				}
+				if left < 0 || x < left {
+					left = x
+				}
+				if x+1 > right {
+					right = x + 1
+				}
+			}
+		}
+		if left >= 0 {
+			lefts[r], widths[r] = left, right-left
+			if right-left > cellWidth {
+				cellWidth = right - left
+			}
+		}
+	}
+	if cellWidth == 0 {
+		return nil, errors.New("load: glyph images are all blank")
+	}
+	if cellWidth > 32 {
+		return nil, fmt.Errorf("load: glyphs are %d pixels wide, at most 32 are supported", cellWidth)
+	}
+
+	bitmaps := make(map[rune]*bitmap, len(masks))
+	for r, m := range masks {
+		b := newBitmap(cellWidth, bottom-top)
+		for y := top; y < bottom && y < len(m); y++ {
+			for x := 0; x < widths[r]; x++ {
+				if m[y][lefts[r]+x] {
+					b.set(x, y-top)
+				}
+			}
+		}
+		bitmaps[r] = b
+	}
+	return newFont(cellWidth, bottom-top, bitmaps, true)
+}
+
+// glyphFileRune returns the rune named by a glyph image's base file name.
+func glyphFileRune(name string) (rune, bool) {
+	if len(name) > 2 && (name[:2] == "U+" || name[:2] == "u+") {
+		v, err := strconv.ParseUint(name[2:], 16, 32)
+		return rune(v), err == nil && v <= utf8.MaxRune
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		return r, r != utf8.RuneError
+	}
+	return 0, false
+}
+
+// readGlyphImage decodes an image file into rows of glyph pixels.
+func readGlyphImage(filename string) ([][]bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("load: %s: %v", filename, err)
+	}
+
+	b := img.Bounds()
+	counts := make(map[color.NRGBA]int)
+	var bg color.NRGBA
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			counts[c]++
+			if counts[c] > counts[bg] {
+				bg = c
+			}
+		}
+	}
+	bgGray := color.GrayModel.Convert(bg).(color.Gray).Y
+
+	m := make([][]bool, b.Dy())
+	for y := range m {
+		m[y] = make([]bool, b.Dx())
+		for x := range m[y] {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			if absDiff(c.A, bg.A) >= 0x80 {
+				m[y][x] = true
+				continue
+			}
+			if c.A >= 0x80 {
+				gray := color.GrayModel.Convert(c).(color.Gray).Y
+				m[y][x] = absDiff(gray, bgGray) >= 0x40
+			}
+		}
+	}
+	return m, nil
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
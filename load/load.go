@@ -0,0 +1,77 @@
+// Package load reads bitmap fonts stored in other formats and converts them into
+// PixFonts, so that existing fonts can be used without first converting them
+// with fontgen.
+package load
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// bitmap is a simple glyph raster used while converting fonts.
+type bitmap struct {
+	w, h int
+	pix  []bool
+}
+
+func newBitmap(w, h int) *bitmap {
+	return &bitmap{w, h, make([]bool, w*h)}
+}
+
+func (b *bitmap) set(x, y int) {
+	if x >= 0 && y >= 0 && x < b.w && y < b.h {
+		b.pix[y*b.w+x] = true
+	}
+}
+
+// rows returns the textual representation of the bitmap used by pixfont.Pack.
+func (b *bitmap) rows() map[int]string {
+	m := make(map[int]string, b.h)
+	for y := 0; y < b.h; y++ {
+		var sb strings.Builder
+		for x := 0; x < b.w; x++ {
+			if b.pix[y*b.w+x] {
+				sb.WriteByte('X')
+			} else {
+				sb.WriteByte(' ')
+			}
+		}
+		m[y] = sb.String()
+	}
+	return m
+}
+
+// empty reports whether the bitmap has no opaque pixels.
+func (b *bitmap) empty() bool {
+	for _, on := range b.pix {
+		if on {
+			return false
+		}
+	}
+	return true
+}
+
+// newFont packs glyphs into a new PixFont with the given cell size. Empty glyphs
+// (such as spaces) are dropped, so that they use the advance of a missing glyph
+// rather than collapsing to nothing in variable width fonts. It returns an error
+// if the glyphs are too tall for a PixFont.
+func newFont(w, h int, glyphs map[rune]*bitmap, variable bool) (*pixfont.PixFont, error) {
+	if h > 255 {
+		return nil, fmt.Errorf("load: font is %d pixels tall, at most 255 are supported", h)
+	}
+	d := make(map[rune]map[int]string, len(glyphs))
+	for r, b := range glyphs {
+		if !b.empty() {
+			d[r] = b.rows()
+		}
+	}
+	encoded, cm, err := pixfont.Pack(w, h, d)
+	if err != nil {
+		return nil, err
+	}
+	f := pixfont.NewPixFont(uint8(w), uint8(h), cm, encoded)
+	f.SetVariableWidth(variable)
+	return f, nil
+}
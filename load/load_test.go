@@ -0,0 +1,1030 @@
+package load
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// glyphRows returns the glyph for r in f as rows with an 'X' for each opaque
+// pixel, trimmed on the right.
+func glyphRows(f *pixfont.PixFont, r rune) []string {
+	m := f.GlyphMask(r)
+	if m == nil {
+		return nil
+	}
+	var rows []string
+	for y := m.Rect.Min.Y; y < m.Rect.Max.Y; y++ {
+		var sb strings.Builder
+		for x := m.Rect.Min.X; x < m.Rect.Max.X; x++ {
+			if m.AlphaAt(x, y).A != 0 {
+				sb.WriteByte('X')
+			} else {
+				sb.WriteByte(' ')
+			}
+		}
+		rows = append(rows, strings.TrimRight(sb.String(), " "))
+	}
+	return rows
+}
+
+// checkGlyph reports an error if the glyph for r in f isn't want.
+func checkGlyph(t *testing.T, f *pixfont.PixFont, r rune, want ...string) {
+	t.Helper()
+	if got := glyphRows(f, r); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("glyph %q is %q, want %q", r, got, want)
+	}
+}
+
+// u8g2Writer writes the bit fields of u8g2 glyph data, least significant bit
+// first.
+type u8g2Writer struct {
+	data []byte
+	n    uint
+}
+
+func (w *u8g2Writer) bits(v, cnt int) {
+	for i := 0; i < cnt; i++ {
+		if w.n%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if v&(1<<uint(i)) != 0 {
+			w.data[len(w.data)-1] |= 1 << (w.n % 8)
+		}
+		w.n++
+	}
+}
+
+// encodeU8g2Glyph encodes rows, with an 'X' for each opaque pixel, as u8g2 glyph
+// data using 4 bit run lengths and 5 bit sizes.
+func encodeU8g2Glyph(rows []string) []byte {
+	w := &u8g2Writer{}
+	width := len(rows[0])
+	w.bits(width, 5)
+	w.bits(len(rows), 5)
+	w.bits(16, 5)       // x = 0
+	w.bits(16, 5)       // y = 0
+	w.bits(16+width, 5) // dx = width
+	pix := strings.Join(rows, "")
+	for i := 0; i < len(pix); {
+		a, b := 0, 0
+		for i < len(pix) && pix[i] != 'X' && a < 15 {
+			a, i = a+1, i+1
+		}
+		for i < len(pix) && pix[i] == 'X' && b < 15 {
+			b, i = b+1, i+1
+		}
+		w.bits(a, 4)
+		w.bits(b, 4)
+		w.bits(0, 1)
+	}
+	return w.data
+}
+
+// encodeU8g2 encodes glyphs of height h as a u8g2 font, with runes below 256
+// in the 8-bit table and the rest in the unicode table.
+func encodeU8g2(h int, glyphs map[rune][]string) []byte {
+	hdr := make([]byte, u8g2HeaderSize)
+	hdr[0] = byte(len(glyphs))
+	hdr[2], hdr[3] = 4, 4
+	hdr[4], hdr[5], hdr[6], hdr[7], hdr[8] = 5, 5, 5, 5, 5
+	hdr[10] = byte(h)
+
+	var low, high []byte
+	for r := rune(0); r < 0x10000; r++ {
+		rows, ok := glyphs[r]
+		if !ok {
+			continue
+		}
+		g := encodeU8g2Glyph(rows)
+		if r < 256 {
+			low = append(low, byte(r), byte(len(g)+2))
+			low = append(low, g...)
+		} else {
+			high = append(high, byte(r>>8), byte(r), byte(len(g)+3))
+			high = append(high, g...)
+		}
+	}
+	font := append(hdr, low...)
+	font = append(font, 0, 0)
+	if len(high) > 0 {
+		table := len(font) - u8g2HeaderSize
+		font[21], font[22] = byte(table>>8), byte(table)
+		font = append(font, 0, 4, 0xff, 0xff) // the offset to the first glyph
+		font = append(font, high...)
+		font = append(font, 0, 0, 0)
+	}
+	return font
+}
+
+// cArray returns data as the C source of an array initializer.
+func cArray(name string, data []byte) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "const uint8_t %s[] U8G2_FONT_SECTION(%q) = {", name, name)
+	for i, b := range data {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "0x%02x", b)
+	}
+	sb.WriteString("};\n")
+	return sb.String()
+}
+
+func TestU8g2(t *testing.T) {
+	data := encodeU8g2(3, map[rune][]string{
+		'I':    {" X ", " X ", " X "},
+		'L':    {"X  ", "X  ", "XXX"},
+		0x20AC: {" XX", "XX ", " XX"},
+	})
+	f, err := U8g2(strings.NewReader(cArray("u8g2_font_test", data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "u8g2_font_test" {
+		t.Errorf("name is %q", f.Name)
+	}
+	if f.GetWidth() != 3 || f.GetHeight() != 3 {
+		t.Errorf("font is %dx%d, want 3x3", f.GetWidth(), f.GetHeight())
+	}
+	checkGlyph(t, f, 'I', " X", " X", " X")
+	checkGlyph(t, f, 'L', "X", "X", "XXX")
+	checkGlyph(t, f, 0x20AC, " XX", "XX", " XX")
+
+	// the same data as a string literal
+	var lit strings.Builder
+	for _, b := range data {
+		fmt.Fprintf(&lit, "\\x%02x\"\"", b)
+	}
+	f, err = U8g2(strings.NewReader(`const uint8_t u8g2_font_test[] = "` + lit.String() + `";`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGlyph(t, f, 'L', "X", "X", "XXX")
+}
+
+func TestU8g2Malformed(t *testing.T) {
+	good := encodeU8g2(3, map[rune][]string{
+		'I':    {" X ", " X ", " X "},
+		0x20AC: {" XX", "XX ", " XX"},
+	})
+	unicodeGlyph := u8g2HeaderSize + (int(good[21])<<8 | int(good[22])) + 4
+	tests := []struct {
+		name string
+		edit func(b []byte) []byte
+	}{
+		{"header", func(b []byte) []byte { return b[:u8g2HeaderSize-1] }},
+		{"8-bit size 1", func(b []byte) []byte { b[u8g2HeaderSize+1] = 1; return b }},
+		{"8-bit truncated", func(b []byte) []byte { return b[:u8g2HeaderSize+3] }},
+		{"unicode size 1", func(b []byte) []byte { b[unicodeGlyph+2] = 1; return b }},
+		{"unicode size 2", func(b []byte) []byte { b[unicodeGlyph+2] = 2; return b }},
+		{"unicode truncated", func(b []byte) []byte { return b[:unicodeGlyph+4] }},
+		{"unicode table", func(b []byte) []byte { b[21] = 0xff; return b }},
+		{"huge glyph", func(b []byte) []byte {
+			b[4] = 16 // 16 bit widths, read from the glyph data
+			return b
+		}},
+		{"tall glyph", func(b []byte) []byte {
+			b[7] = 12 // 12 bit y offsets, pushing the glyph far below the baseline
+			return b
+		}},
+		{"no glyphs", func(b []byte) []byte {
+			b = b[:u8g2HeaderSize+2]
+			b[u8g2HeaderSize+1], b[21], b[22] = 0, 0, 0
+			return b
+		}},
+	}
+	for _, tt := range tests {
+		b := tt.edit(append([]byte(nil), good...))
+		if _, err := decodeU8g2(b); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestNewFontTooTall(t *testing.T) {
+	glyphs := map[rune]*bitmap{'A': newBitmap(1, 256)}
+	glyphs['A'].set(0, 255)
+	if _, err := newFont(1, 256, glyphs, false); err == nil {
+		t.Error("no error for a 256 pixel tall font")
+	}
+}
+
+// amigaFont returns a bare Amiga font hunk of fixed width glyphs from lo on.
+// Each glyph is given as rows with an 'X' for each opaque pixel.
+func amigaFont(lo byte, glyphs ...[]string) []byte {
+	be := binary.BigEndian
+	w, h := len(glyphs[0][0]), len(glyphs[0])
+	modulo := (w*len(glyphs) + 7) / 8
+	charLoc := amigaTextFont + 52
+	charData := charLoc + 4*len(glyphs)
+	hunk := make([]byte, charData+modulo*h)
+	be.PutUint32(hunk, 0x70FF4E75)
+
+	tf := hunk[amigaTextFont:]
+	be.PutUint16(tf[20:], uint16(h))
+	be.PutUint16(tf[24:], uint16(w))
+	tf[32], tf[33] = lo, lo+byte(len(glyphs)-1)
+	be.PutUint32(tf[34:], uint32(charData))
+	be.PutUint16(tf[38:], uint16(modulo))
+	be.PutUint32(tf[40:], uint32(charLoc))
+	for i, rows := range glyphs {
+		be.PutUint16(hunk[charLoc+4*i:], uint16(i*w))
+		be.PutUint16(hunk[charLoc+4*i+2:], uint16(w))
+		for y, row := range rows {
+			for x := range row {
+				if row[x] == 'X' {
+					bit := i*w + x
+					hunk[charData+y*modulo+bit/8] |= 0x80 >> uint(bit%8)
+				}
+			}
+		}
+	}
+	return hunk
+}
+
+// amigaHunkFile wraps a font hunk in an AmigaDOS load file.
+func amigaHunkFile(hunk []byte) []byte {
+	be := binary.BigEndian
+	for len(hunk)%4 != 0 {
+		hunk = append(hunk, 0)
+	}
+	var b []byte
+	for _, v := range []int{hunkHeader, 0, 1, 0, 0, len(hunk) / 4, hunkCode, len(hunk) / 4} {
+		b = append(b, 0, 0, 0, 0)
+		be.PutUint32(b[len(b)-4:], uint32(v))
+	}
+	return append(b, hunk...)
+}
+
+func TestAmiga(t *testing.T) {
+	font := amigaFont('A',
+		[]string{" X ", "X X", "XXX", "X X"},
+		[]string{"XX ", "XX ", "X X", "XX "},
+	)
+	for _, data := range [][]byte{font, amigaHunkFile(font)} {
+		f, err := Amiga(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.GetWidth() != 3 || f.GetHeight() != 4 || f.IsVariableWidth() {
+			t.Errorf("font is %dx%d, variable %v", f.GetWidth(), f.GetHeight(), f.IsVariableWidth())
+		}
+		checkGlyph(t, f, 'A', " X", "X X", "XXX", "X X")
+		checkGlyph(t, f, 'B', "XX", "XX", "X X", "XX")
+	}
+}
+
+func TestAmigaKerning(t *testing.T) {
+	be := binary.BigEndian
+	font := amigaFont('A',
+		[]string{" X ", "X X", "XXX", "X X"},
+		[]string{"XX ", "XX ", "X X", "XX "},
+	)
+	tf := font[amigaTextFont:]
+	tf[23] |= amigaProportional
+	be.PutUint32(tf[44:], uint32(len(font)))   // spacing
+	be.PutUint32(tf[48:], uint32(len(font)+4)) // kerning
+	for _, v := range []int16{5, 3, 1, -1} {
+		font = append(font, 0, 0)
+		be.PutUint16(font[len(font)-2:], uint16(v))
+	}
+
+	f, err := Amiga(bytes.NewReader(font))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cell fits A's advance of 5, and B's negative kerning is dropped
+	if f.GetWidth() != 5 || !f.IsVariableWidth() {
+		t.Errorf("font is %d wide, variable %v", f.GetWidth(), f.IsVariableWidth())
+	}
+	checkGlyph(t, f, 'A', "  X", " X X", " XXX", " X X")
+	checkGlyph(t, f, 'B', "XX", "XX", "X X", "XX")
+}
+
+func TestAmigaMalformed(t *testing.T) {
+	be := binary.BigEndian
+	good := amigaFont('A', []string{" X ", "X X", "XXX", "X X"})
+	tf := amigaTextFont
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated", good[:amigaTextFont+40]},
+		{"char data", func() []byte {
+			b := append([]byte(nil), good...)
+			be.PutUint32(b[tf+34:], 0xffff)
+			return b
+		}()},
+		{"char range", func() []byte {
+			b := append([]byte(nil), good...)
+			b[tf+33] = 'A' - 1
+			return b
+		}()},
+		{"tall", func() []byte {
+			b := append([]byte(nil), good...)
+			be.PutUint16(b[tf+20:], 300)
+			return append(b, make([]byte, 300)...)
+		}()},
+		{"no hunk header", []byte{0, 0, 0, 1, 0, 0, 0, 0}},
+		{"hunk truncated", amigaHunkFile(good)[:30]},
+		{"hunk type", func() []byte {
+			b := amigaHunkFile(good)
+			be.PutUint32(b[24:], 0x3F1)
+			return b
+		}()},
+	}
+	for _, tt := range tests {
+		if _, err := Amiga(bytes.NewReader(tt.data)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+// encodeBase85 encodes b in the ASCII85 variant used by FontForge.
+func encodeBase85(b []byte) string {
+	var sb strings.Builder
+	for len(b) > 0 {
+		n := len(b)
+		if n > 4 {
+			n = 4
+		}
+		var group [4]byte
+		copy(group[:], b[:n])
+		b = b[n:]
+		v := binary.BigEndian.Uint32(group[:])
+		if v == 0 && n == 4 {
+			sb.WriteByte('z')
+			continue
+		}
+		var digits [5]byte
+		for i := 4; i >= 0; i-- {
+			digits[i] = byte(v%85) + '!'
+			v /= 85
+		}
+		sb.Write(digits[:n+1])
+	}
+	return sb.String()
+}
+
+// sfdFont returns the source of a FontForge font with a bitmap strike
+// described by header, holding an 'A' of 3x4 pixels with its baseline 3 pixels
+// down.
+func sfdFont(header string) string {
+	data := encodeBase85([]byte{0x40, 0xa0, 0xe0, 0xa0})
+	return "SplineFontDB: 3.0\n" +
+		"FullName: Test Sans\n" +
+		"Copyright: (c) test\\nline two\n" +
+		"StartChar: A\nEncoding: 65 65 0\nEndChar\n" +
+		header + "\n" +
+		"BDFChar: 0 65 4 0 2 -1 2\n" + data + "\n" +
+		"EndBitmapFont\n"
+}
+
+func TestSFD(t *testing.T) {
+	for _, header := range []string{"BitmapFont: 4 1 3 1 1", "BitmapFont: 4 1 3 1"} {
+		f, err := SFD(strings.NewReader(sfdFont(header)), 0)
+		if err != nil {
+			t.Fatalf("%s: %v", header, err)
+		}
+		if f.Name != "Test Sans" || f.Copyright != "(c) test\nline two" {
+			t.Errorf("metadata is %q, %q", f.Name, f.Copyright)
+		}
+		if f.GetWidth() != 3 || f.GetHeight() != 4 {
+			t.Errorf("font is %dx%d, want 3x4", f.GetWidth(), f.GetHeight())
+		}
+		checkGlyph(t, f, 'A', " X", "X X", "XXX", "X X")
+	}
+	if _, err := SFD(strings.NewReader(sfdFont("BitmapFont: 4 1 3 1 1")), 5); err == nil {
+		t.Error("no error for a missing strike size")
+	}
+}
+
+func TestSFDMalformed(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"SplineFontDB: 3.0\n",
+		sfdFont("BitmapFont: 4 1"),
+		sfdFont("BitmapFont: 4 1 -3 1 1"),
+		sfdFont("BitmapFont: 4 1 0 0 1"),
+		sfdFont("BitmapFont: 4 1 200 100 1"),
+		sfdFont("BitmapFont: 4 1 3 1 8"),
+		"BitmapFont: 4 1 3 1 1\nEndBitmapFont\n",
+		strings.Replace(sfdFont("BitmapFont: 4 1 3 1 1"), "BDFChar: 0 65 4 0 2", "BDFChar: 0 65 4 0 40", 1),
+	} {
+		if _, err := SFD(strings.NewReader(src), 0); err == nil {
+			t.Errorf("no error for %q", src)
+		}
+	}
+}
+
+// writeGlyphPNG writes rows, with an 'X' for each black pixel on white, as a
+// PNG image named name in dir.
+func writeGlyphPNG(t *testing.T, dir, name string, rows ...string) {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x := range row {
+			if row[x] != 'X' {
+				img.SetGray(x, y, color.Gray{0xff})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tempDir returns a new temporary directory, and a function which removes it.
+func tempDir(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestDir(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	writeGlyphPNG(t, dir, "U+0041.png", "      ", " XX   ", "X  X  ", "XXXX  ", "      ")
+	writeGlyphPNG(t, dir, "i.png", "      ", "  X   ", "      ", "  X   ", "      ")
+	writeGlyphPNG(t, dir, "notes.png", "X")
+	if err := ioutil.WriteFile(filepath.Join(dir, "B.txt"), []byte("not a glyph"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Dir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.GetWidth() != 4 || f.GetHeight() != 3 || !f.IsVariableWidth() {
+		t.Errorf("font is %dx%d, variable %v", f.GetWidth(), f.GetHeight(), f.IsVariableWidth())
+	}
+	if got := string(f.Runes()); got != "Ai" {
+		t.Errorf("font has runes %q, want \"Ai\"", got)
+	}
+	checkGlyph(t, f, 'A', " XX", "X  X", "XXXX")
+	checkGlyph(t, f, 'i', "X", "", "X")
+}
+
+func TestDirMalformed(t *testing.T) {
+	for name, files := range map[string]map[string][]string{
+		"empty": {},
+		"blank": {"A.png": {"   ", "   "}},
+		"wide":  {"A.png": {strings.Repeat("X", 33)}},
+		"tall":  {"A.png": append(append([]string{"X"}, make([]string, 255)...), "X")},
+	} {
+		dir, cleanup := tempDir(t)
+		for file, rows := range files {
+			for i := range rows {
+				if rows[i] == "" {
+					rows[i] = " "
+				}
+			}
+			writeGlyphPNG(t, dir, file, rows...)
+		}
+		if _, err := Dir(dir); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		cleanup()
+	}
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(filepath.Join(dir, "A.png"), []byte("\x89PNG\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Dir(dir); err == nil {
+		t.Error("corrupt image: no error")
+	}
+	if _, err := Dir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("missing directory: no error")
+	}
+}
+
+// bmPage returns a BMFont texture page with white glyphs on a transparent
+// background: "A" at 0,0 and "i" at 4,0.
+func bmPage() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for y, row := range []string{" XX  X  ", "X  X    ", "XXXX X  ", "X  X X  "} {
+		for x := range row {
+			if row[x] == 'X' {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	img.Set(7, 3, color.Black) // dark pixels aren't part of a glyph
+	return img
+}
+
+func TestBMFont(t *testing.T) {
+	descs := map[string]string{
+		"text": `info face="Test Font" size=4
+common lineHeight=5 base=4
+page id=0 file="test_0.png"
+chars count=2
+char id=65 x=0 y=0 width=4 height=4 xoffset=0 yoffset=1 xadvance=5 page=0
+char id=105 x=5 y=0 width=1 height=4 xoffset=1 yoffset=1 xadvance=3 page=0
+`,
+		"xml": `<?xml version="1.0"?>
+<font>
+  <info face="Test Font" size="4"/>
+  <common lineHeight="5" base="4"/>
+  <pages><page id="0" file="test_0.png"/></pages>
+  <chars count="2">
+    <char id="65" x="0" y="0" width="4" height="4" xoffset="0" yoffset="1" xadvance="5" page="0"/>
+    <char id="105" x="5" y="0" width="1" height="4" xoffset="1" yoffset="1" xadvance="3" page="0"/>
+  </chars>
+</font>`,
+		"json": `{"info": {"face": "Test Font"}, "common": {"lineHeight": 5}, "pages": ["test_0.png"],
+"chars": [
+  {"id": 65, "x": 0, "y": 0, "width": 4, "height": 4, "xoffset": 0, "yoffset": 1, "xadvance": 5, "page": 0},
+  {"id": 105, "x": 5, "y": 0, "width": 1, "height": 4, "xoffset": 1, "yoffset": 1, "xadvance": 3, "page": 0}
+]}`,
+	}
+	for flavor, desc := range descs {
+		var opened []string
+		f, err := BMFont(strings.NewReader(desc), func(file string) (image.Image, error) {
+			opened = append(opened, file)
+			return bmPage(), nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", flavor, err)
+		}
+		if strings.Join(opened, ",") != "test_0.png" {
+			t.Errorf("%s: opened pages %q, want each once", flavor, opened)
+		}
+		if f.GetWidth() != 4 || f.GetHeight() != 5 || !f.IsVariableWidth() || f.Name != "Test Font" {
+			t.Errorf("%s: font %q is %dx%d, variable %v", flavor, f.Name, f.GetWidth(), f.GetHeight(), f.IsVariableWidth())
+		}
+		checkGlyph(t, f, 'A', "", " XX", "X  X", "XXXX", "X  X")
+		checkGlyph(t, f, 'i', "", " X", "", " X", " X")
+	}
+}
+
+func TestBMFontMalformed(t *testing.T) {
+	page := func(file string) (image.Image, error) {
+		return bmPage(), nil
+	}
+	const char = "char id=65 x=0 y=0 width=4 height=4 xoffset=0 yoffset=0 xadvance=5 page=0\n"
+	for name, desc := range map[string]string{
+		"no glyphs":            "info face=x\npage id=0 file=a.png\n",
+		"json":                 `{"chars": [`,
+		"xml":                  `<font><chars><char id="x"/></chars></font>`,
+		"page":                 "common lineHeight=4\n" + char,
+		"no height":            "common lineHeight=-4\npage id=0 file=a.png\nchar id=65 width=1 yoffset=-8\n",
+		"wide":                 "page id=0 file=a.png\nchar id=65 width=40 height=4\n",
+		"tall":                 "page id=0 file=a.png\nchar id=65 width=4 height=300\n",
+		"text negative page":   "page id=-1 file=a.png\n" + char,
+		"text huge page":       "page id=1000000000 file=a.png\n" + char,
+		"text undeclared page": "common lineHeight=4 pages=1\npage id=1 file=a.png\n" + char,
+		"xml negative page": `<font><pages><page id="-3" file="a.png"/></pages>` +
+			`<chars><char id="65" width="1" height="1"/></chars></font>`,
+		"xml huge page": `<font><pages><page id="1000000000" file="a.png"/></pages>` +
+			`<chars><char id="65" width="1" height="1"/></chars></font>`,
+		"xml undeclared page": `<font><common lineHeight="4" pages="1"/><pages><page id="2" file="a.png"/></pages>` +
+			`<chars><char id="65" width="1" height="1"/></chars></font>`,
+	} {
+		if _, err := BMFont(strings.NewReader(desc), page); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+
+	desc := "page id=0 file=a.png\n" + char
+	_, err := BMFont(strings.NewReader(desc), func(file string) (image.Image, error) {
+		return nil, os.ErrNotExist
+	})
+	if err != os.ErrNotExist {
+		t.Errorf("page error is %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+// testBDF is a BDF font with a descender, a glyph offset to the right and an
+// empty space.
+const testBDF = `STARTFONT 2.1
+COMMENT Test font
+COMMENT public domain
+FONT -Test-Tiny-Medium-R-Normal--6-60-75-75-C-50-ISO10646-1
+SIZE 6 75 75
+FONTBOUNDINGBOX 5 6 0 -2
+STARTPROPERTIES 2
+COPYRIGHT "Public domain"
+FONT_ASCENT 4
+ENDPROPERTIES
+CHARS 3
+STARTCHAR g
+ENCODING 103
+SWIDTH 500 0
+DWIDTH 5 0
+BBX 4 5 0 -2
+BITMAP
+70
+90
+70
+10
+E0
+ENDCHAR
+STARTCHAR i
+ENCODING 105
+DWIDTH 5 0
+BBX 1 4 2 0
+BITMAP
+80
+00
+80
+80
+ENDCHAR
+STARTCHAR space
+ENCODING 32
+DWIDTH 5 0
+BBX 0 0 0 0
+BITMAP
+ENDCHAR
+ENDFONT
+`
+
+func TestBDF(t *testing.T) {
+	f, err := BDF(strings.NewReader(testBDF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.GetWidth() != 5 || f.GetHeight() != 6 || f.IsVariableWidth() {
+		t.Errorf("font is %dx%d, variable %v, want 5x6 fixed", f.GetWidth(), f.GetHeight(), f.IsVariableWidth())
+	}
+	if f.Name != "-Test-Tiny-Medium-R-Normal--6-60-75-75-C-50-ISO10646-1" || f.Copyright != "Public domain" ||
+		f.Comment != "Test font\npublic domain" {
+		t.Errorf("metadata is %q, %q, %q", f.Name, f.Copyright, f.Comment)
+	}
+	checkGlyph(t, f, 'g', "", " XXX", "X  X", " XXX", "   X", "XXX")
+	checkGlyph(t, f, 'i', "  X", "", "  X", "  X", "", "")
+	if got := f.MeasureString("gi"); got != 12 {
+		t.Errorf("two glyphs advance %d pixels, want 12", got)
+	}
+}
+
+func TestBDFMalformed(t *testing.T) {
+	for name, desc := range map[string]string{
+		"empty":     "",
+		"no bbox":   strings.Replace(testBDF, "FONTBOUNDINGBOX 5 6 0 -2\n", "", 1),
+		"bad bbox":  strings.Replace(testBDF, "FONTBOUNDINGBOX 5 6 0 -2", "FONTBOUNDINGBOX 5 six 0 -2", 1),
+		"no glyphs": testBDF[:strings.Index(testBDF, "STARTCHAR")],
+		"bad row":   strings.Replace(testBDF, "70\n90", "z0\n90", 1),
+		"short row": strings.Replace(testBDF, "BBX 4 5 0 -2", "BBX 12 5 0 -2", 1),
+		"truncated": testBDF[:strings.Index(testBDF, "E0")],
+		"wide":      strings.Replace(testBDF, "DWIDTH 5 0\nBBX 1 4", "DWIDTH 40 0\nBBX 1 4", 1),
+		"height":    strings.Replace(testBDF, "FONTBOUNDINGBOX 5 6 0 -2", "FONTBOUNDINGBOX 5 0 0 -2", 1),
+	} {
+		if _, err := BDF(strings.NewReader(desc)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+// tdfFile returns a TheDraw font file with a font of the given type, with
+// glyph data for each character from '!' in glyphs.
+func tdfFile(name string, kind byte, glyphs map[byte][]byte) []byte {
+	b := append([]byte{0x13}, "TheDraw FONTS file\x1a"...)
+	hdr := make([]byte, 213)
+	copy(hdr, []byte{0x55, 0xaa, 0x00, 0xff})
+	hdr[4] = byte(len(name))
+	copy(hdr[5:17], name)
+	hdr[21] = kind
+	var data []byte
+	for i := 0; i < 94; i++ {
+		off := 0xffff
+		if g, ok := glyphs[byte('!'+i)]; ok {
+			off = len(data)
+			data = append(data, g...)
+		}
+		binary.LittleEndian.PutUint16(hdr[25+i*2:], uint16(off))
+	}
+	binary.LittleEndian.PutUint16(hdr[23:], uint16(len(data)))
+	return append(append(b, hdr...), data...)
+}
+
+func TestTheDraw(t *testing.T) {
+	block := tdfFile("BLOCKY", tdfBlock, map[byte][]byte{
+		'A': {2, 2, 0xdb, 0xdf, '\r', 0xdb, 0xdc, 0},
+		'B': {1, 1, 0xb0, 0}, // too light to be drawn
+	})
+	colors := tdfFile("COLORS", tdfColor, map[byte][]byte{
+		'A': {2, 2, 0xdb, 0x07, 0xdf, 0x07, '\r', 0xdb, 0x07, ' ', 0x70, 0},
+	})
+	file := append(block, colors[20:]...)
+
+	names, err := TheDrawFonts(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "BLOCKY,COLORS" {
+		t.Errorf("fonts are %q", names)
+	}
+	for _, tt := range []struct {
+		name, want string
+		rows       []string
+	}{
+		{"", "BLOCKY", []string{"XX", "X", "X", "XX"}},
+		{"COLORS", "COLORS", []string{"XX", "X", "XX", "XX"}},
+	} {
+		f, err := TheDraw(bytes.NewReader(file), tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name != tt.want || f.GetWidth() != 2 || f.GetHeight() != 4 {
+			t.Errorf("font %q is %dx%d, want %q", f.Name, f.GetWidth(), f.GetHeight(), tt.want)
+		}
+		checkGlyph(t, f, 'A', tt.rows...)
+	}
+	f, _ := TheDraw(bytes.NewReader(block), "")
+	checkGlyph(t, f, 'B')
+}
+
+func TestTheDrawMalformed(t *testing.T) {
+	good := tdfFile("BLOCKY", tdfBlock, map[byte][]byte{'A': {1, 1, 0xdb, 0}})
+	tests := map[string][]byte{
+		"empty":     nil,
+		"signature": append([]byte{0x13}, "TheDraw FONT  file\x1a"...),
+		"no fonts":  good[:20],
+		"truncated": good[:len(good)-1],
+		"type": func() []byte {
+			b := append([]byte(nil), good...)
+			b[20+21] = 3
+			return b
+		}(),
+		"no glyphs": tdfFile("EMPTY", tdfBlock, nil),
+		"wide":      tdfFile("WIDE", tdfBlock, map[byte][]byte{'A': {33, 1, 0xdb, 0}}),
+		"tall":      tdfFile("TALL", tdfBlock, map[byte][]byte{'A': {1, 128, 0xdb, 0}}),
+	}
+	for name, data := range tests {
+		if _, err := TheDraw(bytes.NewReader(data), ""); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if _, err := TheDraw(bytes.NewReader(good), "OTHER"); err == nil {
+		t.Error("missing font: no error")
+	}
+}
+
+// otFile returns an OpenType font file with the given tables.
+func otFile(tables map[string][]byte) []byte {
+	be := binary.BigEndian
+	var tags []string
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	hdr := make([]byte, 12+16*len(tags))
+	be.PutUint32(hdr, 0x00010000)
+	be.PutUint16(hdr[4:], uint16(len(tags)))
+	var data []byte
+	for i, tag := range tags {
+		rec := hdr[12+16*i:]
+		copy(rec, tag)
+		be.PutUint32(rec[8:], uint32(len(hdr)+len(data)))
+		be.PutUint32(rec[12:], uint32(len(tables[tag])))
+		data = append(data, tables[tag]...)
+	}
+	return append(hdr, data...)
+}
+
+// otTables returns the tables of an OpenType font named "Test" with two 4 ppem
+// glyphs, 'A' stored with byte-aligned image data and its own metrics, and
+// 'B' with bit-aligned data and metrics in the index, and a second 8 ppem
+// strike with a bit depth of 2.
+func otTables() map[string][]byte {
+	be := binary.BigEndian
+	u16 := func(b []byte, vs ...int) []byte {
+		for _, v := range vs {
+			b = append(b, 0, 0)
+			be.PutUint16(b[len(b)-2:], uint16(v))
+		}
+		return b
+	}
+	u32 := func(b []byte, vs ...int) []byte {
+		for _, v := range vs {
+			b = append(b, 0, 0, 0, 0)
+			be.PutUint32(b[len(b)-4:], uint32(v))
+		}
+		return b
+	}
+
+	// format 4 cmap mapping 'A' and 'B' to glyphs 1 and 2
+	cmap := u16(nil, 0, 1)
+	cmap = u16(cmap, 3, 1)
+	cmap = u32(cmap, 12)
+	cmap = u16(cmap, 4, 32, 0, 4, 4, 1, 0) // format, length, language, segments
+	cmap = u16(cmap, 'B', 0xffff, 0)       // ends, padding
+	cmap = u16(cmap, 'A', 0xffff)          // starts
+	cmap = u16(cmap, 1-'A', 1)             // deltas
+	cmap = u16(cmap, 0, 0)                 // range offsets
+
+	ebdt := u32(nil, 0x00020000)
+	ebdt = append(ebdt, 3, 4, 0, 3, 5, 0x60, 0x90, 0xf0) // A: metrics, rows
+	ebdt = append(ebdt, 0xec)                            // B: 11 10 11
+
+	strike := func(ppem, depth byte, subtables int) []byte {
+		r := make([]byte, 48)
+		be.PutUint32(r[0:], 8+48*2) // subtables follow both strikes
+		be.PutUint32(r[8:], uint32(subtables))
+		r[16], r[17] = 3, 0xff // ascender, descender
+		r[44], r[45], r[46] = ppem, ppem, depth
+		return r
+	}
+	eblc := u32(nil, 0x00020000, 2)
+	eblc = append(eblc, strike(4, 1, 2)...)
+	eblc = append(eblc, strike(8, 2, 0)...)
+	eblc = u16(eblc, 1, 1)
+	eblc = u32(eblc, 16)
+	eblc = u16(eblc, 2, 2)
+	eblc = u32(eblc, 16+16)
+	eblc = u16(eblc, 1, 1) // index format 1, image format 1
+	eblc = u32(eblc, 4, 0, 8)
+	eblc = u16(eblc, 2, 5) // index format 2, image format 5
+	eblc = u32(eblc, 12, 1)
+	eblc = append(eblc, 3, 2, 0, 3, 3, 0, 0, 0)
+
+	name := u16(nil, 0, 1, 18)
+	name = u16(name, 3, 1, 0x409, 4, 8, 0)
+	name = u16(name, 'T', 'e', 's', 't')
+
+	return map[string][]byte{"cmap": cmap, "EBDT": ebdt, "EBLC": eblc, "name": name}
+}
+
+func TestOpenType(t *testing.T) {
+	data := otFile(otTables())
+	sizes, err := OpenTypeStrikes(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(sizes) != "[4 8]" {
+		t.Errorf("strikes are %v, want [4 8]", sizes)
+	}
+	for _, ppem := range []int{0, 4} {
+		f, err := OpenType(bytes.NewReader(data), ppem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name != "Test" || f.GetWidth() != 4 || f.GetHeight() != 4 || !f.IsVariableWidth() {
+			t.Errorf("font %q is %dx%d, variable %v", f.Name, f.GetWidth(), f.GetHeight(), f.IsVariableWidth())
+		}
+		checkGlyph(t, f, 'A', " XX", "X  X", "XXXX", "")
+		checkGlyph(t, f, 'B', "XX", "X", "XX", "")
+	}
+}
+
+func TestOpenTypeMalformed(t *testing.T) {
+	good := otFile(otTables())
+	for n := 0; n < len(good); n++ {
+		if _, err := OpenType(bytes.NewReader(good[:n]), 0); err == nil {
+			t.Fatalf("truncated to %d bytes: no error", n)
+		}
+	}
+	if _, err := OpenType(bytes.NewReader(good), 8); err == nil {
+		t.Error("2-bit strike: no error")
+	}
+	if _, err := OpenType(bytes.NewReader(good), 12); err == nil {
+		t.Error("missing strike: no error")
+	}
+	for _, tag := range []string{"cmap", "EBLC", "EBDT"} {
+		tables := otTables()
+		delete(tables, tag)
+		if _, err := OpenType(bytes.NewReader(otFile(tables)), 0); err == nil {
+			t.Errorf("no %s table: no error", tag)
+		}
+	}
+
+	// glyphs without height, on a strike without height
+	tables := otTables()
+	eblc, ebdt := tables["EBLC"], tables["EBDT"]
+	eblc[8+16], eblc[8+17] = 0xfe, 0 // ascender, descender
+	ebdt[4], ebdt[7] = 0, 0xfe       // height, bearing
+	eblc[len(eblc)-8], eblc[len(eblc)-5] = 0, 0xfe
+	if _, err := OpenType(bytes.NewReader(otFile(tables)), 0); err == nil {
+		t.Error("strike without height: no error")
+	}
+}
+
+func TestWatchFont(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "font.txt")
+	write := func(s string) {
+		if err := ioutil.WriteFile(path, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the loader names the font after the file contents, or fails for "bad"
+	// and for files caught half-written
+	loader := func(r io.Reader) (*pixfont.PixFont, error) {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 || string(b) == "bad" {
+			return nil, fmt.Errorf("bad font")
+		}
+		f := pixfont.NewPixFont(1, 1, map[rune]uint16{}, nil)
+		f.Name = string(b)
+		return f, nil
+	}
+	// wait polls until cond is true
+	wait := func(what string, cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+
+	if _, err := WatchFont(path, loader, time.Millisecond); err == nil {
+		t.Error("no error for a missing file")
+	}
+	write("bad")
+	if _, err := WatchFont(path, loader, time.Millisecond); err == nil {
+		t.Error("no error for a font which doesn't load")
+	}
+
+	write("one")
+	w, err := WatchFont(path, loader, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if name := w.Font().Name; name != "one" {
+		t.Fatalf("font is %q, want one", name)
+	}
+	write("two!") // a new size, in case the mod time doesn't change
+	wait("the font to reload", func() bool { return w.Font().Name == "two!" })
+	if err := w.Err(); err != nil {
+		t.Errorf("error after reloading: %v", err)
+	}
+
+	write("bad")
+	wait("the reload to fail", func() bool { return w.Err() != nil })
+	if name := w.Font().Name; name != "two!" {
+		t.Errorf("font after a failed reload is %q, want the previous font", name)
+	}
+
+	w.Close()
+	w.Close() // closing twice is fine
+	write("three")
+	time.Sleep(20 * time.Millisecond)
+	if name := w.Font().Name; name != "two!" {
+		t.Errorf("font reloaded after Close: %q", name)
+	}
+}
+
+func TestWatchBDF(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, "font.bdf")
+	if err := ioutil.WriteFile(path, []byte(testBDF), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := WatchFont(path, BDF, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	checkGlyph(t, w.Font(), 'i', "  X", "", "  X", "  X", "", "")
+
+	// a taller i, written to a temporary file and renamed so that the watcher
+	// never sees it half-written
+	tall := strings.Replace(testBDF, "BBX 1 4 2 0\nBITMAP\n", "BBX 1 5 2 -1\nBITMAP\n80\n", 1)
+	if err := ioutil.WriteFile(path+".new", []byte(tall), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".new", path); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); glyphRows(w.Font(), 'i')[1] == ""; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the font to reload")
+		}
+	}
+	checkGlyph(t, w.Font(), 'i', "  X", "  X", "", "  X", "  X", "")
+	if err := w.Err(); err != nil {
+		t.Errorf("error after reloading: %v", err)
+	}
+}
@@ -0,0 +1,477 @@
+package load
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"unicode/utf16"
+
+	"github.com/pbnjay/pixfont"
+)
+
+var errOTTruncated = errors.New("load: opentype data is truncated")
+
+// otFont provides bounds-checked access to the tables of an OpenType font.
+type otFont struct {
+	tables map[string][]byte
+}
+
+func parseOpenType(data []byte) (*otFont, error) {
+	be := binary.BigEndian
+	if len(data) < 12 {
+		return nil, errOTTruncated
+	}
+	base := 0
+	if string(data[:4]) == "ttcf" {
+		// font collection: use the first font
+		if len(data) < 16 {
+			return nil, errOTTruncated
+		}
+		base = int(be.Uint32(data[12:]))
+		if base+12 > len(data) {
+			return nil, errOTTruncated
+		}
+	}
+
+	f := &otFont{tables: make(map[string][]byte)}
+	numTables := int(be.Uint16(data[base+4:]))
+	for i := 0; i < numTables; i++ {
+		rec := base + 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errOTTruncated
+		}
+		off, length := int(be.Uint32(data[rec+8:])), int(be.Uint32(data[rec+12:]))
+		if off < 0 || off+length > len(data) {
+			return nil, errOTTruncated
+		}
+		f.tables[string(data[rec:rec+4])] = data[off : off+length]
+	}
+	return f, nil
+}
+
+// table returns the first of the named tables present in the font.
+func (f *otFont) table(names ...string) []byte {
+	for _, n := range names {
+		if t, ok := f.tables[n]; ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// glyphRunes parses the cmap table, returning a map from glyph ID to the lowest
+// rune that maps to it.
+func (f *otFont) glyphRunes() (map[int]rune, error) {
+	be := binary.BigEndian
+	cmap := f.table("cmap")
+	if len(cmap) < 4 {
+		return nil, errors.New("load: opentype font has no cmap table")
+	}
+
+	// prefer full unicode (format 12) subtables, then BMP (format 4)
+	best, bestFormat := -1, 0
+	for i := 0; i < int(be.Uint16(cmap[2:])); i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			return nil, errOTTruncated
+		}
+		platform, encoding := be.Uint16(cmap[rec:]), be.Uint16(cmap[rec+2:])
+		off := int(be.Uint32(cmap[rec+4:]))
+		if off+2 > len(cmap) || !(platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))) {
+			continue
+		}
+		format := int(be.Uint16(cmap[off:]))
+		if (format == 12 || format == 4) && format > bestFormat {
+			best, bestFormat = off, format
+		}
+	}
+	if best < 0 {
+		return nil, errors.New("load: opentype font has no unicode cmap")
+	}
+
+	m := make(map[int]rune)
+	add := func(gid int, r rune) {
+		if old, ok := m[gid]; gid != 0 && (!ok || r < old) {
+			m[gid] = r
+		}
+	}
+	sub := cmap[best:]
+	if bestFormat == 12 {
+		if len(sub) < 16 {
+			return nil, errOTTruncated
+		}
+		n := int(be.Uint32(sub[12:]))
+		for i := 0; i < n; i++ {
+			g := 16 + 12*i
+			if g+12 > len(sub) {
+				return nil, errOTTruncated
+			}
+			start, end, gid := be.Uint32(sub[g:]), be.Uint32(sub[g+4:]), be.Uint32(sub[g+8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				add(int(gid+c-start), rune(c))
+			}
+		}
+		return m, nil
+	}
+
+	if len(sub) < 14 {
+		return nil, errOTTruncated
+	}
+	segs := int(be.Uint16(sub[6:])) / 2
+	ends, starts := 14, 16+2*segs
+	deltas, ranges := starts+2*segs, starts+4*segs
+	if ranges+2*segs > len(sub) {
+		return nil, errOTTruncated
+	}
+	for i := 0; i < segs; i++ {
+		end, start := int(be.Uint16(sub[ends+2*i:])), int(be.Uint16(sub[starts+2*i:]))
+		delta, rangeOff := int(be.Uint16(sub[deltas+2*i:])), int(be.Uint16(sub[ranges+2*i:]))
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			gid := (c + delta) & 0xFFFF
+			if rangeOff != 0 {
+				addr := ranges + 2*i + rangeOff + 2*(c-start)
+				if addr+2 > len(sub) {
+					continue
+				}
+				if gid = int(be.Uint16(sub[addr:])); gid != 0 {
+					gid = (gid + delta) & 0xFFFF
+				}
+			}
+			add(gid, rune(c))
+		}
+	}
+	return m, nil
+}
+
+// otStrike is a BitmapSize record from the EBLC table.
+type otStrike struct {
+	record        []byte
+	ascender, ppm int
+	descender     int
+}
+
+func (f *otFont) strikes() ([]otStrike, error) {
+	be := binary.BigEndian
+	eblc := f.table("EBLC", "bloc")
+	if len(eblc) < 8 || f.table("EBDT", "bdat") == nil {
+		return nil, errors.New("load: font has no embedded bitmaps (EBLC/EBDT tables)")
+	}
+	var ss []otStrike
+	for i := 0; i < int(be.Uint32(eblc[4:])); i++ {
+		rec := 8 + 48*i
+		if rec+48 > len(eblc) {
+			return nil, errOTTruncated
+		}
+		r := eblc[rec : rec+48]
+		ss = append(ss, otStrike{
+			record:    r,
+			ascender:  int(int8(r[16])),
+			descender: int(int8(r[17])),
+			ppm:       int(r[45]),
+		})
+	}
+	return ss, nil
+}
+
+// OpenTypeStrikes returns the sizes (in pixels per em) of the embedded bitmap
+// strikes in an OpenType or TrueType font.
+func OpenTypeStrikes(r io.Reader) ([]int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseOpenType(data)
+	if err != nil {
+		return nil, err
+	}
+	ss, err := f.strikes()
+	if err != nil {
+		return nil, err
+	}
+	sizes := make([]int, len(ss))
+	for i, s := range ss {
+		sizes[i] = s.ppm
+	}
+	return sizes, nil
+}
+
+// OpenType extracts an embedded bitmap strike (from the EBLC/EBDT tables) of an
+// OpenType or TrueType font and converts it to a PixFont. Many older fonts, CJK
+// fonts in particular, include hand-tuned bitmaps for small sizes. The strike
+// with the given pixels per em is converted; if ppem is 0 the first strike is
+// used. Only 1-bit strikes are supported, and composite glyphs are skipped.
+func OpenType(r io.Reader, ppem int) (*pixfont.PixFont, error) {
+	be := binary.BigEndian
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseOpenType(data)
+	if err != nil {
+		return nil, err
+	}
+	runes, err := f.glyphRunes()
+	if err != nil {
+		return nil, err
+	}
+	ss, err := f.strikes()
+	if err != nil {
+		return nil, err
+	}
+	var strike *otStrike
+	for i := range ss {
+		if ppem == 0 || ss[i].ppm == ppem {
+			strike = &ss[i]
+			break
+		}
+	}
+	if strike == nil {
+		return nil, fmt.Errorf("load: no %d ppem bitmap strike found", ppem)
+	}
+	if depth := strike.record[46]; depth != 1 {
+		return nil, fmt.Errorf("load: strike has bit depth %d, only 1-bit strikes are supported", depth)
+	}
+
+	type otGlyph struct {
+		format        int
+		data          []byte
+		width, height int
+		bearingX      int
+		bearingY      int
+		advance       int
+	}
+	glyphs := make(map[rune]*otGlyph)
+
+	eblc, ebdt := f.table("EBLC", "bloc"), f.table("EBDT", "bdat")
+	arrayOff := int(be.Uint32(strike.record[0:]))
+	for i := 0; i < int(be.Uint32(strike.record[8:])); i++ {
+		ent := arrayOff + 8*i
+		if ent+8 > len(eblc) {
+			return nil, errOTTruncated
+		}
+		first, last := int(be.Uint16(eblc[ent:])), int(be.Uint16(eblc[ent+2:]))
+		sub := arrayOff + int(be.Uint32(eblc[ent+4:]))
+		if sub+8 > len(eblc) || last < first {
+			return nil, errOTTruncated
+		}
+		indexFormat, imageFormat := int(be.Uint16(eblc[sub:])), int(be.Uint16(eblc[sub+2:]))
+		imageOff := int(be.Uint32(eblc[sub+4:]))
+		hdr := sub + 8
+
+		// collect the location of each glyph's image data
+		type loc struct{ gid, start, end int }
+		var locs []loc
+		var metrics []byte
+		switch indexFormat {
+		case 1, 3:
+			size := 4
+			if indexFormat == 3 {
+				size = 2
+			}
+			off := func(j int) int {
+				p := hdr + size*j
+				if p+size > len(eblc) {
+					return -1
+				}
+				if size == 2 {
+					return int(be.Uint16(eblc[p:]))
+				}
+				return int(be.Uint32(eblc[p:]))
+			}
+			for g := first; g <= last; g++ {
+				a, b := off(g-first), off(g-first+1)
+				if a < 0 || b < 0 {
+					return nil, errOTTruncated
+				}
+				if b > a {
+					locs = append(locs, loc{g, a, b})
+				}
+			}
+		case 2, 5:
+			if hdr+12 > len(eblc) {
+				return nil, errOTTruncated
+			}
+			size := int(be.Uint32(eblc[hdr:]))
+			metrics = eblc[hdr+4 : hdr+12]
+			if indexFormat == 2 {
+				for g := first; g <= last; g++ {
+					locs = append(locs, loc{g, (g - first) * size, (g - first + 1) * size})
+				}
+				break
+			}
+			if hdr+16 > len(eblc) {
+				return nil, errOTTruncated
+			}
+			n := int(be.Uint32(eblc[hdr+12:]))
+			for j := 0; j < n; j++ {
+				p := hdr + 16 + 2*j
+				if p+2 > len(eblc) {
+					return nil, errOTTruncated
+				}
+				locs = append(locs, loc{int(be.Uint16(eblc[p:])), j * size, (j + 1) * size})
+			}
+		case 4:
+			if hdr+4 > len(eblc) {
+				return nil, errOTTruncated
+			}
+			n := int(be.Uint32(eblc[hdr:]))
+			for j := 0; j < n; j++ {
+				p := hdr + 4 + 4*j
+				if p+8 > len(eblc) {
+					return nil, errOTTruncated
+				}
+				locs = append(locs, loc{int(be.Uint16(eblc[p:])), int(be.Uint16(eblc[p+2:])), int(be.Uint16(eblc[p+6:]))})
+			}
+		default:
+			continue // unknown index format
+		}
+
+		for _, l := range locs {
+			r, ok := runes[l.gid]
+			if !ok {
+				continue
+			}
+			a, b := imageOff+l.start, imageOff+l.end
+			if a < 0 || b > len(ebdt) || a > b {
+				return nil, errOTTruncated
+			}
+			g := &otGlyph{format: imageFormat, data: ebdt[a:b]}
+
+			// read the glyph metrics, from the image data or the index
+			var m []byte
+			switch imageFormat {
+			case 1, 2:
+				if len(g.data) < 5 {
+					continue
+				}
+				m, g.data = g.data[:5], g.data[5:]
+			case 6, 7:
+				if len(g.data) < 8 {
+					continue
+				}
+				m, g.data = g.data[:8], g.data[8:]
+			case 5:
+				m = metrics
+			default:
+				continue // composite or unsupported image format
+			}
+			if m == nil {
+				continue
+			}
+			g.height, g.width = int(m[0]), int(m[1])
+			g.bearingX, g.bearingY = int(int8(m[2])), int(int8(m[3]))
+			g.advance = int(m[4])
+			glyphs[r] = g
+		}
+	}
+	if len(glyphs) == 0 {
+		return nil, errors.New("load: bitmap strike has no glyphs")
+	}
+
+	ascender := strike.ascender
+	cellHeight := strike.ascender - strike.descender
+	minX, cellWidth := 0, 0
+	variable := false
+	firstAdvance := -1
+	for _, g := range glyphs {
+		if g.bearingX < minX {
+			minX = g.bearingX
+		}
+		if top := ascender - g.bearingY; top < 0 {
+			// glyph extends above the strike ascender; grow the cell upward
+			cellHeight -= top
+			ascender -= top
+		}
+		if firstAdvance >= 0 && g.advance != firstAdvance {
+			variable = true
+		}
+		firstAdvance = g.advance
+	}
+	for _, g := range glyphs {
+		if w := g.bearingX - minX + g.width; w > cellWidth {
+			cellWidth = w
+		}
+		if b := ascender - g.bearingY + g.height; b > cellHeight {
+			cellHeight = b
+		}
+	}
+	if cellWidth > 32 {
+		return nil, fmt.Errorf("load: strike is %d pixels wide, at most 32 are supported", cellWidth)
+	}
+	if cellHeight < 1 {
+		return nil, errors.New("load: bitmap strike has no height")
+	}
+
+	bitmaps := make(map[rune]*bitmap, len(glyphs))
+	for r, g := range glyphs {
+		b := newBitmap(cellWidth, cellHeight)
+		bitAligned := g.format == 2 || g.format == 5 || g.format == 7
+		stride := (g.width + 7) / 8
+		for y := 0; y < g.height; y++ {
+			for x := 0; x < g.width; x++ {
+				bit := y*stride*8 + x
+				if bitAligned {
+					bit = y*g.width + x
+				}
+				if bit/8 < len(g.data) && g.data[bit/8]&(0x80>>uint(bit%8)) != 0 {
+					b.set(g.bearingX-minX+x, ascender-g.bearingY+y)
+				}
+			}
+		}
+		bitmaps[r] = b
+	}
+	fnt, err := newFont(cellWidth, cellHeight, bitmaps, variable)
+	if err != nil {
+		return nil, err
+	}
+	fnt.Name, fnt.Copyright = f.names()
+	return fnt, nil
+}
+
+// names returns the full name and copyright notice from the name table, if
+// present.
+func (f *otFont) names() (name, copyright string) {
+	be := binary.BigEndian
+	t := f.table("name")
+	if len(t) < 6 {
+		return "", ""
+	}
+	count, strOff := int(be.Uint16(t[2:])), int(be.Uint16(t[4:]))
+	for i := 0; i < count; i++ {
+		rec := 6 + 12*i
+		if rec+12 > len(t) {
+			break
+		}
+		platform, encoding := be.Uint16(t[rec:]), be.Uint16(t[rec+2:])
+		id := be.Uint16(t[rec+6:])
+		length, off := int(be.Uint16(t[rec+8:])), strOff+int(be.Uint16(t[rec+10:]))
+		if off+length > len(t) || (id != 0 && id != 4) {
+			continue
+		}
+		raw := t[off : off+length]
+
+		var s string
+		switch {
+		case platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10)):
+			// UTF-16BE
+			u := make([]uint16, len(raw)/2)
+			for j := range u {
+				u[j] = be.Uint16(raw[2*j:])
+			}
+			s = string(utf16.Decode(u))
+		case platform == 1 && encoding == 0:
+			// Mac Roman, which matches ASCII for names of interest
+			s = string(raw)
+		default:
+			continue
+		}
+		if id == 0 && copyright == "" {
+			copyright = s
+		} else if id == 4 && name == "" {
+			name = s
+		}
+	}
+	return name, copyright
+}
@@ -0,0 +1,183 @@
+package load
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// SFD reads a bitmap strike from a FontForge .sfd source file, which is how many
+// pixel font designers publish their fonts. The strike with the given pixel size
+// is converted; if pixelSize is 0, the first strike in the file is used.
+//
+// Glyphs are mapped to runes using the Unicode values in the file's glyph
+// definitions. Reference glyphs (BDFRefChar) are not supported and are skipped.
+func SFD(r io.Reader, pixelSize int) (*pixfont.PixFont, error) {
+	type sfdChar struct {
+		origPos, enc, width    int
+		xmin, xmax, ymin, ymax int
+		data                   []byte
+	}
+
+	unicodes := make(map[int]rune) // orig_pos => unicode
+	var name, copyright string
+	var chars []*sfdChar
+	var ascent, descent int
+	inStrike, found := false, false
+	var cur *sfdChar
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.HasPrefix(line, "FullName: ") && !inStrike:
+			name = strings.TrimPrefix(line, "FullName: ")
+		case strings.HasPrefix(line, "Copyright: ") && !inStrike:
+			copyright = strings.Replace(strings.TrimPrefix(line, "Copyright: "), `\n`, "\n", -1)
+		case strings.HasPrefix(line, "Encoding: ") && !inStrike:
+			var enc, uni, pos int
+			if n, _ := fmt.Sscanf(line, "Encoding: %d %d %d", &enc, &uni, &pos); n == 3 && uni >= 0 {
+				unicodes[pos] = rune(uni)
+			}
+
+		case strings.HasPrefix(line, "BitmapFont: "):
+			var size, count, a, d, depth int
+			n, _ := fmt.Sscanf(line, "BitmapFont: %d %d %d %d %d", &size, &count, &a, &d, &depth)
+			if n < 4 {
+				return nil, fmt.Errorf("load: bad sfd strike header %q", line)
+			}
+			if n == 4 {
+				depth = 1 // older files have no depth
+			}
+			if !found && (pixelSize == 0 || size == pixelSize) {
+				if depth != 1 {
+					return nil, fmt.Errorf("load: sfd strike %d has depth %d, only 1-bit strikes are supported", size, depth)
+				}
+				if a+d < 1 || a+d > 255 {
+					return nil, fmt.Errorf("load: sfd strike %d is %d pixels tall, it must be 1 to 255", size, a+d)
+				}
+				ascent, descent = a, d
+				inStrike, found = true, true
+			}
+
+		case strings.HasPrefix(line, "EndBitmapFont"):
+			inStrike = false
+			cur = nil
+
+		case !inStrike:
+			continue
+
+		case strings.HasPrefix(line, "BDFChar: "):
+			cur = &sfdChar{}
+			fmt.Sscanf(line, "BDFChar: %d %d %d %d %d %d %d", &cur.origPos, &cur.enc,
+				&cur.width, &cur.xmin, &cur.xmax, &cur.ymin, &cur.ymax)
+			chars = append(chars, cur)
+
+		case strings.HasPrefix(line, "BDFRefChar: "):
+			cur = nil
+
+		case cur != nil:
+			// bitmap data may continue over several lines
+			cur.data = append(cur.data, strings.TrimSpace(line)...)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("load: no %d pixel bitmap strike found", pixelSize)
+	}
+	if len(chars) == 0 {
+		return nil, errors.New("load: bitmap strike has no glyphs")
+	}
+
+	// find the cell size, shifting glyphs right if any extend left of the pen
+	minX, cellWidth, cellHeight := 0, 0, ascent+descent
+	variable := false
+	for _, c := range chars {
+		if c.xmin < minX && c.xmax >= c.xmin {
+			minX = c.xmin
+		}
+		if c.width != chars[0].width {
+			variable = true
+		}
+	}
+	for _, c := range chars {
+		if w := c.xmax - minX + 1; w > cellWidth {
+			cellWidth = w
+		}
+	}
+	if cellWidth > 32 {
+		return nil, fmt.Errorf("load: sfd strike is %d pixels wide, at most 32 are supported", cellWidth)
+	}
+
+	bitmaps := make(map[rune]*bitmap, len(chars))
+	for _, c := range chars {
+		r, ok := unicodes[c.origPos]
+		if !ok {
+			r = rune(c.enc)
+		}
+		b := newBitmap(cellWidth, cellHeight)
+		if c.xmax >= c.xmin && c.ymax >= c.ymin {
+			raw := decodeBase85(c.data)
+			stride := (c.xmax-c.xmin)/8 + 1
+			for y := 0; y <= c.ymax-c.ymin; y++ {
+				for x := 0; x <= c.xmax-c.xmin; x++ {
+					i := y*stride + x/8
+					if i < len(raw) && raw[i]&(0x80>>uint(x%8)) != 0 {
+						b.set(c.xmin-minX+x, ascent-1-c.ymax+y)
+					}
+				}
+			}
+		}
+		bitmaps[r] = b
+	}
+	f, err := newFont(cellWidth, cellHeight, bitmaps, variable)
+	if err != nil {
+		return nil, err
+	}
+	f.Name, f.Copyright = name, copyright
+	return f, nil
+}
+
+// decodeBase85 decodes the ASCII85 variant used by FontForge for bitmap data,
+// where 'z' represents four zero bytes.
+func decodeBase85(src []byte) []byte {
+	var out []byte
+	var group [5]byte
+	n := 0
+	flush := func(count int) {
+		var v uint32
+		for i := 0; i < 5; i++ {
+			v = v*85 + uint32(group[i])
+		}
+		b := []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+		out = append(out, b[:count]...)
+	}
+	for _, c := range src {
+		switch {
+		case c == 'z' && n == 0:
+			out = append(out, 0, 0, 0, 0)
+		case c >= '!' && c <= 'u':
+			group[n] = c - '!'
+			n++
+			if n == 5 {
+				flush(4)
+				n = 0
+			}
+		}
+	}
+	if n > 1 {
+		// pad a partial group with the highest digit, then drop the padding bytes
+		for i := n; i < 5; i++ {
+			group[i] = 84
+		}
+		flush(n - 1)
+	}
+	return out
+}
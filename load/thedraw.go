@@ -0,0 +1,192 @@
+package load
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// TheDraw font types.
+const (
+	tdfOutline = 0
+	tdfBlock   = 1
+	tdfColor   = 2
+)
+
+// tdfFont is a single font within a TheDraw font file.
+type tdfFont struct {
+	name    string
+	kind    byte
+	offsets [94]uint16 // '!' through '~', 0xffff if undefined
+	data    []byte
+}
+
+// tdfHalves returns which halves of a character cell are covered by a CP437
+// character from a TheDraw font.
+func tdfHalves(ch byte) (top, bottom bool) {
+	switch ch {
+	case ' ', 0xff, 0xb0, '@', '&': // blanks and the lightest shade
+		return false, false
+	case 0xdf: // ▀
+		return true, false
+	case 0xdc: // ▄
+		return false, true
+	}
+	// full, half and dark shaded blocks, and any other character
+	return true, true
+}
+
+// TheDrawFonts returns the names of the fonts in a TheDraw .tdf font file.
+func TheDrawFonts(r io.Reader) ([]string, error) {
+	fonts, err := readTDF(r)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(fonts))
+	for i, f := range fonts {
+		names[i] = f.name
+	}
+	return names, nil
+}
+
+// TheDraw reads a font from a TheDraw .tdf font file, the ANSI-art fonts used
+// for BBS-style banners. If name is empty, the first font in the file is used.
+//
+// Each character cell of the font becomes one pixel wide and two pixels tall,
+// so that the half block characters are kept. In block and outline fonts a
+// pixel is set wherever a block covers it, and in color fonts wherever its
+// color is not black. Only the printable ASCII characters are defined.
+func TheDraw(r io.Reader, name string) (*pixfont.PixFont, error) {
+	fonts, err := readTDF(r)
+	if err != nil {
+		return nil, err
+	}
+	var font *tdfFont
+	for _, f := range fonts {
+		if name == "" || f.name == name {
+			font = f
+			break
+		}
+	}
+	if font == nil {
+		return nil, fmt.Errorf("load: no TheDraw font named %q", name)
+	}
+
+	type tdfGlyph struct {
+		w, h int
+		pix  [][2]bool // per cell: top, bottom
+	}
+	glyphs := make(map[rune]*tdfGlyph)
+	cellWidth, cellHeight := 0, 0
+	for i, off := range font.offsets {
+		if off == 0xffff || int(off)+2 > len(font.data) {
+			continue
+		}
+		d := font.data[off:]
+		g := &tdfGlyph{w: int(d[0]), h: int(d[1])}
+		g.pix = make([][2]bool, g.w*g.h)
+		x, y := 0, 0
+		for p := 2; p < len(d) && d[p] != 0; p++ {
+			ch := d[p]
+			if ch == '\r' {
+				x, y = 0, y+1
+				continue
+			}
+			top, bottom := tdfHalves(ch)
+			if font.kind == tdfColor {
+				p++
+				if p >= len(d) {
+					break
+				}
+				fg, bg := d[p]&0x0f, (d[p]>>4)&0x07
+				top, bottom = (top && fg != 0) || (!top && bg != 0), (bottom && fg != 0) || (!bottom && bg != 0)
+			}
+			if x < g.w && y < g.h {
+				g.pix[y*g.w+x] = [2]bool{top, bottom}
+			}
+			x++
+		}
+		if g.w > cellWidth {
+			cellWidth = g.w
+		}
+		if g.h*2 > cellHeight {
+			cellHeight = g.h * 2
+		}
+		glyphs[rune('!'+i)] = g
+	}
+	if len(glyphs) == 0 {
+		return nil, errors.New("load: TheDraw font has no glyphs")
+	}
+	if cellWidth > 32 {
+		return nil, fmt.Errorf("load: TheDraw font is %d pixels wide, at most 32 are supported", cellWidth)
+	}
+
+	bitmaps := make(map[rune]*bitmap, len(glyphs))
+	for r, g := range glyphs {
+		b := newBitmap(cellWidth, cellHeight)
+		for i, halves := range g.pix {
+			x, y := i%g.w, i/g.w
+			if halves[0] {
+				b.set(x, y*2)
+			}
+			if halves[1] {
+				b.set(x, y*2+1)
+			}
+		}
+		bitmaps[r] = b
+	}
+	f, err := newFont(cellWidth, cellHeight, bitmaps, true)
+	if err != nil {
+		return nil, err
+	}
+	f.Name = font.name
+	return f, nil
+}
+
+// readTDF splits a TheDraw font file into its fonts.
+func readTDF(r io.Reader) ([]*tdfFont, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 20 || data[0] != 0x13 || string(data[1:19]) != "TheDraw FONTS file" {
+		return nil, errors.New("load: not a TheDraw font file")
+	}
+
+	var fonts []*tdfFont
+	marker := []byte{0x55, 0xaa, 0x00, 0xff}
+	pos := 20
+	for pos+213 <= len(data) && bytes.Equal(data[pos:pos+4], marker) {
+		hdr := data[pos : pos+213]
+		nameLen := int(hdr[4])
+		if nameLen > 12 {
+			nameLen = 12
+		}
+		f := &tdfFont{
+			name: string(hdr[5 : 5+nameLen]),
+			kind: hdr[21],
+		}
+		if f.kind > tdfColor {
+			return nil, fmt.Errorf("load: unknown TheDraw font type %d", f.kind)
+		}
+		size := int(hdr[23]) | int(hdr[24])<<8
+		for i := range f.offsets {
+			f.offsets[i] = uint16(hdr[25+i*2]) | uint16(hdr[26+i*2])<<8
+		}
+		pos += 213
+		if pos+size > len(data) {
+			return nil, errors.New("load: TheDraw font data is truncated")
+		}
+		f.data = data[pos : pos+size]
+		fonts = append(fonts, f)
+		pos += size
+	}
+	if len(fonts) == 0 {
+		return nil, errors.New("load: TheDraw file has no fonts")
+	}
+	return fonts, nil
+}
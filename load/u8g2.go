@@ -0,0 +1,294 @@
+package load
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// u8g2HeaderSize is the size of the u8g2 font header (U8G2_FONT_DATA_STRUCT_SIZE).
+const u8g2HeaderSize = 23
+
+// U8g2 reads a font in the compressed format used by the u8g2 Arduino library,
+// so that fonts curated for small displays can be reused in Go host software.
+// The reader should contain the C source of a single font, e.g.:
+//
+//	const uint8_t u8g2_font_5x7_tr[] U8G2_FONT_SECTION("u8g2_font_5x7_tr") = "\x01\x00..." ...;
+//
+// Both string literal and {0x01, 0x02, ...} array initializers are accepted.
+func U8g2(r io.Reader) (*pixfont.PixFont, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err := cArrayData(string(src))
+	if err != nil {
+		return nil, err
+	}
+	f, err := decodeU8g2(data)
+	if err != nil {
+		return nil, err
+	}
+	if m := u8g2Name.FindStringSubmatch(string(src)); m != nil {
+		f.Name = m[1]
+	}
+	return f, nil
+}
+
+// u8g2Name matches the name of the font's array declaration.
+var u8g2Name = regexp.MustCompile(`(\w+)\s*\[\s*\]`)
+
+// cArrayData extracts the bytes of the first initialized array in C source.
+func cArrayData(src string) ([]byte, error) {
+	eq := strings.Index(src, "=")
+	if eq < 0 {
+		return nil, errors.New("load: no array initializer found")
+	}
+	src = strings.TrimSpace(src[eq+1:])
+	if strings.HasPrefix(src, "{") {
+		end := strings.Index(src, "}")
+		if end < 0 {
+			return nil, errors.New("load: unterminated array initializer")
+		}
+		var data []byte
+		for _, f := range strings.Split(src[1:end], ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			v, err := strconv.ParseUint(f, 0, 8)
+			if err != nil {
+				return nil, fmt.Errorf("load: bad array element %q", f)
+			}
+			data = append(data, byte(v))
+		}
+		return data, nil
+	}
+
+	// one or more adjacent string literals, terminated by a semicolon
+	var data []byte
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == ';':
+			return data, nil
+		case c != '"':
+			continue
+		}
+		for i++; i < len(src) && src[i] != '"'; i++ {
+			if src[i] != '\\' {
+				data = append(data, src[i])
+				continue
+			}
+			i++
+			if i >= len(src) {
+				break
+			}
+			switch c := src[i]; {
+			case c >= '0' && c <= '7':
+				v := 0
+				for n := 0; n < 3 && i < len(src) && src[i] >= '0' && src[i] <= '7'; n++ {
+					v = v*8 + int(src[i]-'0')
+					i++
+				}
+				i--
+				data = append(data, byte(v))
+			case c == 'x':
+				v := 0
+				for i+1 < len(src) && strings.IndexByte("0123456789abcdefABCDEF", src[i+1]) >= 0 {
+					i++
+					d, _ := strconv.ParseUint(src[i:i+1], 16, 8)
+					v = v*16 + int(d)
+				}
+				data = append(data, byte(v))
+			case c == 'n':
+				data = append(data, '\n')
+			case c == 't':
+				data = append(data, '\t')
+			case c == 'r':
+				data = append(data, '\r')
+			default:
+				data = append(data, c)
+			}
+		}
+	}
+	return data, nil
+}
+
+// u8g2Decoder reads the variable width bit fields of u8g2 glyph data.
+type u8g2Decoder struct {
+	data   []byte
+	pos    int
+	bitPos uint
+}
+
+func (d *u8g2Decoder) unsigned(cnt uint8) int {
+	var val uint
+	for i := uint8(0); i < cnt; i++ {
+		if d.pos < len(d.data) && d.data[d.pos]&(1<<d.bitPos) != 0 {
+			val |= 1 << i
+		}
+		d.bitPos++
+		if d.bitPos == 8 {
+			d.bitPos = 0
+			d.pos++
+		}
+	}
+	return int(val)
+}
+
+func (d *u8g2Decoder) signed(cnt uint8) int {
+	if cnt == 0 {
+		return 0
+	}
+	return d.unsigned(cnt) - 1<<(cnt-1)
+}
+
+type u8g2Glyph struct {
+	w, h, x, y, dx int
+	pix            []bool
+}
+
+func decodeU8g2(font []byte) (*pixfont.PixFont, error) {
+	if len(font) < u8g2HeaderSize {
+		return nil, errors.New("load: u8g2 font is truncated")
+	}
+	hdr := font[:u8g2HeaderSize]
+	bitsPer0, bitsPer1 := hdr[2], hdr[3]
+	bitsW, bitsH, bitsX, bitsY, bitsDX := hdr[4], hdr[5], hdr[6], hdr[7], hdr[8]
+	maxHeight := int(hdr[10])
+	yOffset := int(int8(hdr[12]))
+	startUnicode := int(hdr[21])<<8 | int(hdr[22])
+
+	// decode returns nil for glyphs too large for a PixFont, whose size
+	// fields could otherwise ask for an arbitrarily large allocation
+	decode := func(data []byte) *u8g2Glyph {
+		d := &u8g2Decoder{data: data}
+		g := &u8g2Glyph{
+			w: d.unsigned(bitsW), h: d.unsigned(bitsH),
+			x: d.signed(bitsX), y: d.signed(bitsY), dx: d.signed(bitsDX),
+		}
+		if g.w > 32 || g.h > 255 {
+			return nil
+		}
+		g.pix = make([]bool, g.w*g.h)
+		if g.w == 0 || g.h == 0 {
+			return g
+		}
+		i := 0
+		for i < len(g.pix) && d.pos < len(d.data) {
+			a := d.unsigned(bitsPer0)
+			b := d.unsigned(bitsPer1)
+			for {
+				i += a
+				for n := 0; n < b && i < len(g.pix); n++ {
+					g.pix[i] = true
+					i++
+				}
+				if d.unsigned(1) == 0 || i >= len(g.pix) {
+					break
+				}
+			}
+		}
+		return g
+	}
+
+	glyphs := make(map[rune]*u8g2Glyph)
+	// glyphs with 8-bit encodings: encoding, size, data...
+	pos := u8g2HeaderSize
+	for pos+2 <= len(font) && font[pos+1] != 0 {
+		size := int(font[pos+1])
+		if size < 2 {
+			return nil, fmt.Errorf("load: u8g2 glyph %d has invalid size %d", font[pos], size)
+		}
+		if pos+size > len(font) {
+			return nil, errors.New("load: u8g2 glyph data is truncated")
+		}
+		g := decode(font[pos+2 : pos+size])
+		if g == nil {
+			return nil, fmt.Errorf("load: u8g2 glyph %d is too large", font[pos])
+		}
+		glyphs[rune(font[pos])] = g
+		pos += size
+	}
+
+	// glyphs with 16-bit encodings follow a lookup table, whose first entry
+	// gives the offset to the first glyph
+	if startUnicode != 0 {
+		table := u8g2HeaderSize + startUnicode
+		if table+4 > len(font) {
+			return nil, errors.New("load: u8g2 unicode table is truncated")
+		}
+		pos = table + (int(font[table])<<8 | int(font[table+1]))
+		for pos+3 <= len(font) {
+			enc := rune(font[pos])<<8 | rune(font[pos+1])
+			size := int(font[pos+2])
+			if enc == 0 || size == 0 {
+				break
+			}
+			if size < 3 {
+				return nil, fmt.Errorf("load: u8g2 glyph U+%04X has invalid size %d", enc, size)
+			}
+			if pos+size > len(font) {
+				return nil, errors.New("load: u8g2 glyph data is truncated")
+			}
+			g := decode(font[pos+3 : pos+size])
+			if g == nil {
+				return nil, fmt.Errorf("load: u8g2 glyph U+%04X is too large", enc)
+			}
+			glyphs[enc] = g
+			pos += size
+		}
+	}
+	if len(glyphs) == 0 {
+		return nil, errors.New("load: u8g2 font has no glyphs")
+	}
+
+	// lay the glyphs out in a common cell, shifting right if any glyph extends
+	// to the left of the pen
+	baseline := maxHeight + yOffset
+	minX, cellWidth, cellHeight := 0, 0, maxHeight
+	for _, g := range glyphs {
+		if g.x < minX {
+			minX = g.x
+		}
+		if top := baseline - g.h - g.y; top+g.h > cellHeight {
+			cellHeight = top + g.h
+		}
+	}
+	variable := false
+	firstDX := -1
+	for _, g := range glyphs {
+		if w := g.x - minX + g.w; w > cellWidth {
+			cellWidth = w
+		}
+		if firstDX >= 0 && g.dx != firstDX {
+			variable = true
+		}
+		firstDX = g.dx
+	}
+	if cellWidth > 32 {
+		return nil, fmt.Errorf("load: u8g2 font is %d pixels wide, at most 32 are supported", cellWidth)
+	}
+	if cellHeight > 255 {
+		return nil, fmt.Errorf("load: u8g2 font is %d pixels tall, at most 255 are supported", cellHeight)
+	}
+
+	bitmaps := make(map[rune]*bitmap, len(glyphs))
+	for r, g := range glyphs {
+		b := newBitmap(cellWidth, cellHeight)
+		top := baseline - g.h - g.y
+		for i, on := range g.pix {
+			if on {
+				b.set(g.x-minX+i%g.w, top+i/g.w)
+			}
+		}
+		bitmaps[r] = b
+	}
+	return newFont(cellWidth, cellHeight, bitmaps, variable)
+}
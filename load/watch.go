@@ -0,0 +1,124 @@
+package load
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// DefaultWatchInterval is how often a FontWatcher checks its file for changes
+// when no interval is given.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// FontWatcher holds a font loaded from a file, and reloads it whenever the file
+// changes. It is meant for development, so that a font being edited can be seen
+// in a running program without rebuilding it.
+type FontWatcher struct {
+	path   string
+	loader func(io.Reader) (*pixfont.PixFont, error)
+	font   atomic.Value // *pixfont.PixFont
+
+	mu      sync.Mutex
+	err     error
+	modTime time.Time
+	size    int64
+
+	stop chan struct{}
+	done chan struct{} // closed when poll returns
+	once sync.Once
+}
+
+// WatchFont loads the font at path using loader, such as BDF or U8g2, and
+// checks the file for changes every interval (DefaultWatchInterval if zero).
+// Loaders which take more arguments can be wrapped in a closure:
+//
+//	w, err := load.WatchFont("myfont.otb", func(r io.Reader) (*pixfont.PixFont, error) {
+//		return load.OpenType(r, 12)
+//	}, 0)
+//
+// An error is returned if the font can't be loaded initially. If a later reload
+// fails, such as while the file is half-written, the previous font is kept and
+// the error is available from Err.
+func WatchFont(path string, loader func(io.Reader) (*pixfont.PixFont, error), interval time.Duration) (*FontWatcher, error) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &FontWatcher{path: path, loader: loader, stop: make(chan struct{}), done: make(chan struct{})}
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+	go w.poll(interval)
+	return w, nil
+}
+
+// Font returns the most recently loaded font. Callers should call Font each
+// time they draw rather than keeping the result, to see reloaded fonts.
+func (w *FontWatcher) Font() *pixfont.PixFont {
+	return w.font.Load().(*pixfont.PixFont)
+}
+
+// Err returns the error from the last reload, or nil if it succeeded.
+func (w *FontWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close stops watching the file, waiting for a reload in progress to finish.
+// The last loaded font remains available.
+func (w *FontWatcher) Close() {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+func (w *FontWatcher) poll(interval time.Duration) {
+	defer close(w.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+			fi, err := os.Stat(w.path)
+			w.mu.Lock()
+			changed := err == nil && (!fi.ModTime().Equal(w.modTime) || fi.Size() != w.size)
+			w.mu.Unlock()
+			if changed {
+				w.reload()
+			}
+		}
+	}
+}
+
+// reload loads the font from the file, swapping it in if successful.
+func (w *FontWatcher) reload() error {
+	f, err := os.Open(w.path)
+	if err == nil {
+		defer f.Close()
+	}
+	var fi os.FileInfo
+	if err == nil {
+		fi, err = f.Stat()
+	}
+	var fnt *pixfont.PixFont
+	if err == nil {
+		fnt, err = w.loader(f)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+	if fi != nil {
+		// don't retry a broken file until it changes again
+		w.modTime, w.size = fi.ModTime(), fi.Size()
+	}
+	if err == nil {
+		w.font.Store(fnt)
+	}
+	return err
+}
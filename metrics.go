@@ -0,0 +1,43 @@
+package pixfont
+
+// FontMetrics describes the vertical layout of a PixFont in pixels, so that its
+// text can be aligned with text drawn by other renderers.
+type FontMetrics struct {
+	// Ascent is the distance from the top of the character cell to the
+	// baseline, and Descent from the baseline to the bottom of the cell.
+	Ascent, Descent int
+	// LineGap is the extra space between lines, beyond Ascent and Descent.
+	LineGap int
+	// CapHeight and XHeight are the heights above the baseline of the capital
+	// letter H and the lowercase x, or 0 if the font has no glyph for them.
+	CapHeight, XHeight int
+}
+
+// Metrics returns the metrics of the font, computed from its glyphs. The
+// baseline is taken from the bottom of the glyph for H, X or 0, the first that
+// the font has, or the bottom of the cell if it has none of them. Lines drawn by
+// DrawStringWrapped are the font height apart, so LineGap is always 0.
+func (p *PixFont) Metrics() FontMetrics {
+	base := p.baseline()
+	return FontMetrics{
+		Ascent:    base,
+		Descent:   int(p.charHeight) - base,
+		CapHeight: p.heightAbove(base, 'H'),
+		XHeight:   p.heightAbove(base, 'x'),
+	}
+}
+
+// heightAbove returns the height of the glyph for c above the baseline base, or
+// 0 if the font has no glyph for c.
+func (p *PixFont) heightAbove(base int, c rune) int {
+	if !p.has(c) {
+		return 0
+	}
+	top := base
+	p.drawRune(func(x, y int) {
+		if y < top {
+			top = y
+		}
+	}, 0, 0, c, p.Spacing())
+	return base - top
+}
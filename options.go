@@ -0,0 +1,359 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// DrawOptions changes how DrawStringOptions draws a string. The zero value
+// draws the same as DrawString.
+type DrawOptions struct {
+	// Debug, if non-nil, outlines the advance cell of each glyph, and the box
+	// of the whole string one pixel outside it, in this color. The outlines are
+	// drawn under the text, and make spacing problems visible while developing
+	// a font.
+	Debug color.Color
+
+	// Spacing, if non-nil, is the number of pixels between characters instead
+	// of the font's spacing, for a single string drawn tighter or looser
+	// without changing the font.
+	Spacing *int
+
+	// Scale draws each pixel of the font as a Scale by Scale block of pixels.
+	// Values below 2 draw at the font's own size.
+	Scale int
+	// Smooth softens the staircase edges of scaled text by partly filling the
+	// concave corners between pixels with half-transparent pixels. It has no
+	// effect unless Scale is at least 2.
+	Smooth bool
+	// Scale2x enlarges text with the Scale2x (EPX) algorithm instead of
+	// plain blocks, which keeps the pixel art look while rounding off
+	// diagonals. It is applied once for each factor of 2 in Scale, and the
+	// rest of the scale uses blocks, so it has no effect on odd scales.
+	Scale2x bool
+
+	// Subpixel is an experimental mode for text shown on LCD displays, which
+	// draws each column of the font into one red, green or blue subpixel, so
+	// that the text is a third as wide. It only works when drawing onto an
+	// image.Image with opaque pixels in RGB order, and the Scale, Smooth,
+	// ColorFunc and Fill options are ignored.
+	Subpixel bool
+
+	// ColorFunc, if non-nil, gives the color of each pixel of the text instead
+	// of the color passed to DrawStringOptions. It is called with the pixel's
+	// position relative to the top-left of the string, and the index of the
+	// rune being drawn, counting from 0.
+	ColorFunc func(x, y, i int) color.Color
+	// Fill, if non-nil, colors the text by sampling this image instead of
+	// using a flat color, for textured lettering. Fill is repeated to cover
+	// the text, with its top-left corner at the top-left of the string, or at
+	// 0,0 of the destination if FillAbsolute is true so that the texture lines
+	// up across several strings. Fill is ignored if ColorFunc is set.
+	Fill         image.Image
+	FillAbsolute bool
+
+	// Blend draws translucent colors with source-over alpha blending, mixing
+	// them with the destination instead of replacing its pixels.
+	Blend bool
+	// ColorKey, if non-nil, leaves destination pixels of this color
+	// untouched, as when drawing onto a sprite whose key color marks its
+	// transparent area.
+	//
+	// Blend and ColorKey both read the destination, so they only have an
+	// effect when drawing onto an image.Image.
+	ColorKey color.Color
+
+	// Glyph, if non-nil, is called before each glyph is drawn and may move,
+	// recolor or skip it, for effects such as wavy text, without changing
+	// the layout of the string. Scale, Debug, Blend and ColorKey apply as
+	// usual, but Smooth, Scale2x, Subpixel, ColorFunc and Fill are ignored.
+	Glyph func(g *GlyphDraw)
+
+	// AutoContrast picks colors which keep the text readable on unknown
+	// backgrounds, such as video or photos. ContrastBlackWhite only has an
+	// effect when drawing onto an image.Image. With ContrastOutline, the
+	// outline is drawn in a flat color without calling the Glyph hook.
+	AutoContrast ContrastMode
+}
+
+// GlyphDraw describes one glyph of a string drawn by DrawStringOptions, as
+// passed to the Glyph hook.
+type GlyphDraw struct {
+	// Rune is the rune being drawn, and Index its index in the string,
+	// counting from 0, after normalization and shaping.
+	Rune  rune
+	Index int
+	// X and Y are the top-left corner of the glyph, which the hook may change
+	// to move it. Moving a glyph doesn't move the glyphs after it.
+	X, Y int
+	// Color is the color of the glyph, which the hook may change.
+	Color color.Color
+	// Skip, if set by the hook, leaves the glyph undrawn. Its advance is kept.
+	Skip bool
+}
+
+// colorFunc returns the per-pixel color function for text drawn at x,y, or nil
+// if the text is drawn in a flat color.
+func (o *DrawOptions) colorFunc(x, y int) func(px, py, i int) color.Color {
+	if o.ColorFunc != nil {
+		return o.ColorFunc
+	}
+	if o.Fill == nil || o.Fill.Bounds().Empty() {
+		return nil
+	}
+	b := o.Fill.Bounds()
+	fill, absolute := o.Fill, o.FillAbsolute
+	return func(px, py, i int) color.Color {
+		if absolute {
+			px, py = px+x, py+y
+		}
+		px, py = px%b.Dx(), py%b.Dy()
+		if px < 0 {
+			px += b.Dx()
+		}
+		if py < 0 {
+			py += b.Dy()
+		}
+		return fill.At(b.Min.X+px, b.Min.Y+py)
+	}
+}
+
+// DrawStringOptions draws s like DrawString, changed by opts, and returns the x
+// position following the string. If opts is nil it is the same as DrawString.
+func (p *PixFont) DrawStringOptions(dr Drawable, x, y int, s string, clr color.Color, opts *DrawOptions) int {
+	if opts == nil {
+		opts = &DrawOptions{}
+	}
+	scale := opts.Scale
+	if scale < 1 {
+		scale = 1
+	}
+	sp := p.Spacing()
+	if opts.Spacing != nil {
+		sp = *opts.Spacing
+	}
+	if opts.AutoContrast != ContrastNone {
+		return p.drawContrast(dr, x, y, s, clr, opts, scale, sp)
+	}
+	dr = composite(dr, opts)
+	if opts.Debug != nil {
+		p.drawDebug(setter(dr, opts.Debug), x, y, s, scale, sp)
+	}
+	if opts.Glyph != nil {
+		p.recordUsage(s)
+		return p.drawHooked(dr, x, y, s, clr, scale, sp, opts.Glyph)
+	}
+	if img, ok := dr.(image.Image); ok && opts.Subpixel && clr != nil {
+		return p.drawSubpixel(dr, img, x, y, s, clr, sp)
+	}
+	colorAt := opts.colorFunc(x, y)
+	if scale == 1 && colorAt == nil {
+		return p.drawString(dr, x, y, s, clr, sp)
+	}
+	p.recordUsage(s)
+	return p.drawScaled(dr, x, y, s, clr, colorAt, scale, sp, opts.Smooth, opts.Scale2x)
+}
+
+// drawHooked draws s at x,y with each pixel enlarged to a scale by scale block,
+// calling hook before each glyph is drawn.
+func (p *PixFont) drawHooked(dr Drawable, x, y int, s string, clr color.Color, scale, sp int, hook func(g *GlyphDraw)) int {
+	i := 0
+	w := p.layout(p.prepare(s), sp, func(c rune, dx, dy int) int {
+		g := GlyphDraw{Rune: c, Index: i, X: x + dx*scale, Y: y + dy*scale, Color: clr}
+		i++
+		hook(&g)
+		if g.Skip {
+			_, adv := p.drawRune(nil, 0, 0, c, sp)
+			return adv
+		}
+		set := setter(dr, g.Color)
+		_, adv := p.drawRune(func(xx, yy int) {
+			fillRect(set, image.Rect(g.X+xx*scale, g.Y+yy*scale, g.X+(xx+1)*scale, g.Y+(yy+1)*scale), false)
+		}, 0, 0, c, sp)
+		return adv
+	})
+	return x + w*scale
+}
+
+// drawScaled draws s at x,y with each pixel enlarged to a scale by scale block,
+// or first with Scale2x for each factor of 2 in scale if epx is true. If
+// colorAt is non-nil it gives the color of each pixel instead of clr.
+func (p *PixFont) drawScaled(dr Drawable, x, y int, s string, clr color.Color, colorAt func(x, y, i int) color.Color, scale, sp int, smooth, epx bool) int {
+	// runes holds the index of the rune drawn at each pixel of the string, or
+	// -1 where nothing is drawn
+	s = p.prepare(s)
+	w, h := p.measure(s, sp), int(p.charHeight)
+	runes := make([]int, w*h)
+	for i := range runes {
+		runes[i] = -1
+	}
+	at := func(xx, yy int) int {
+		if xx < 0 || yy < 0 || xx >= w || yy >= h {
+			return -1
+		}
+		return runes[yy*w+xx]
+	}
+	i := 0
+	p.layout(s, sp, func(c rune, dx, dy int) int {
+		_, adv := p.drawRune(func(xx, yy int) {
+			if xx >= 0 && yy >= 0 && xx < w && yy < h {
+				runes[yy*w+xx] = i
+			}
+		}, dx, dy, c, sp)
+		i++
+		return adv
+	})
+	for epx && scale%2 == 0 {
+		runes = scale2x(runes, w, h)
+		w, h, scale = 2*w, 2*h, scale/2
+	}
+
+	set := setter(dr, clr)
+	for yy := 0; yy < h; yy++ {
+		for xx := 0; xx < w; xx++ {
+			i := at(xx, yy)
+			if i < 0 {
+				continue
+			}
+			r := image.Rect(xx*scale, yy*scale, (xx+1)*scale, (yy+1)*scale)
+			if colorAt == nil {
+				fillRect(set, r.Add(image.Pt(x, y)), false)
+				continue
+			}
+			fillRect(func(px, py int) {
+				dr.Set(x+px, y+py, colorAt(px, py, i))
+			}, r, false)
+		}
+	}
+	if smooth && scale > 1 && (clr != nil || colorAt != nil) {
+		p.smoothCorners(dr, x, y, w, h, scale, at, clr, colorAt)
+	}
+	return x + w*scale
+}
+
+// smoothCorners draws half-transparent triangles into each empty pixel of the
+// string that has opaque pixels on two adjacent sides, filling the corner
+// between them. at returns the index of the rune at a pixel, or -1.
+func (p *PixFont) smoothCorners(dr Drawable, x, y, w, h, scale int, at func(x, y int) int, clr color.Color, colorAt func(x, y, i int) color.Color) {
+	n := scale / 2 // size of the triangle
+	for yy := -1; yy <= h; yy++ {
+		for xx := -1; xx <= w; xx++ {
+			if at(xx, yy) >= 0 {
+				continue
+			}
+			// corners are identified by the direction of their neighbors
+			for _, d := range []image.Point{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+				i := at(xx+d.X, yy)
+				if i < 0 || at(xx, yy+d.Y) < 0 {
+					continue
+				}
+				for ty := 0; ty < n; ty++ {
+					for tx := 0; tx+ty < n; tx++ {
+						px, py := xx*scale+tx, yy*scale+ty
+						if d.X > 0 {
+							px = (xx+1)*scale - 1 - tx
+						}
+						if d.Y > 0 {
+							py = (yy+1)*scale - 1 - ty
+						}
+						c := clr
+						if colorAt != nil {
+							c = colorAt(px, py, i)
+						}
+						cr, cg, cb, ca := c.RGBA()
+						blend(dr, x+px, y+py, color.RGBA64{uint16(cr / 2), uint16(cg / 2), uint16(cb / 2), uint16(ca / 2)})
+					}
+				}
+			}
+		}
+	}
+}
+
+// drawSubpixel draws s at x,y with three columns of the font in each pixel of
+// img, setting the red, green and blue channels of the pixel to those of clr
+// for the columns which are opaque.
+func (p *PixFont) drawSubpixel(dr Drawable, img image.Image, x, y int, s string, clr color.Color, sp int) int {
+	m := p.prerender(s, clr, sp).Mask()
+	b := m.Bounds()
+	cr, cg, cb, _ := clr.RGBA()
+	src := [3]uint32{cr, cg, cb}
+	for yy := 0; yy < b.Dy(); yy++ {
+		for px := 0; px*3 < b.Dx(); px++ {
+			pt := image.Pt(x+px, y+yy)
+			if !pt.In(img.Bounds()) {
+				continue
+			}
+			dstR, dstG, dstB, _ := img.At(pt.X, pt.Y).RGBA()
+			dst := [3]uint32{dstR, dstG, dstB}
+			changed := false
+			for i := range dst {
+				if m.AlphaAt(px*3+i, yy).A != 0 {
+					dst[i] = src[i]
+					changed = true
+				}
+			}
+			if changed {
+				dr.Set(pt.X, pt.Y, color.RGBA64{uint16(dst[0]), uint16(dst[1]), uint16(dst[2]), 0xffff})
+			}
+		}
+	}
+	return x + (b.Dx()+2)/3
+}
+
+// drawDebug outlines the glyph cells and the box of s drawn at x,y.
+func (p *PixFont) drawDebug(set func(x, y int), x, y int, s string, scale, sp int) {
+	h := int(p.charHeight) * scale
+	width := p.layout(p.prepare(s), sp, func(c rune, dx, dy int) int {
+		_, w := p.drawRune(nil, 0, 0, c, sp)
+		if !p.combining[c] {
+			strokeRect(set, image.Rect(x+dx*scale, y, x+(dx+w)*scale, y+h))
+		}
+		return w
+	})
+	strokeRect(set, image.Rect(x-1, y-1, x+width*scale+1, y+h+1))
+}
+
+// strokeRect calls set for every pixel on the edge of r.
+func strokeRect(set func(x, y int), r image.Rectangle) {
+	if r.Empty() {
+		return
+	}
+	for xx := r.Min.X; xx < r.Max.X; xx++ {
+		set(xx, r.Min.Y)
+		set(xx, r.Max.Y-1)
+	}
+	for yy := r.Min.Y + 1; yy < r.Max.Y-1; yy++ {
+		set(r.Min.X, yy)
+		set(r.Max.X-1, yy)
+	}
+}
+
+// Rainbow is a palette of red, orange, yellow, green, blue and violet, for use
+// with CyclePalette.
+var Rainbow = []color.Color{
+	color.RGBA{0xff, 0x00, 0x00, 0xff},
+	color.RGBA{0xff, 0x80, 0x00, 0xff},
+	color.RGBA{0xff, 0xff, 0x00, 0xff},
+	color.RGBA{0x00, 0xc0, 0x00, 0xff},
+	color.RGBA{0x00, 0x60, 0xff, 0xff},
+	color.RGBA{0x90, 0x00, 0xff, 0xff},
+}
+
+// CyclePalette returns a DrawOptions.ColorFunc which colors each rune of a
+// string with the next color of palette, starting again from the first color
+// after the last.
+func CyclePalette(palette []color.Color) func(x, y, i int) color.Color {
+	return func(x, y, i int) color.Color {
+		return palette[i%len(palette)]
+	}
+}
+
+// DrawStringCycled draws s like DrawString, coloring each rune with the next
+// color of palette in turn, such as Rainbow. If palette is empty nothing is
+// drawn, but the x position following the string is still returned.
+func (p *PixFont) DrawStringCycled(dr Drawable, x, y int, s string, palette []color.Color) int {
+	if len(palette) == 0 {
+		return x + p.MeasureString(s)
+	}
+	return p.DrawStringOptions(dr, x, y, s, palette[0], &DrawOptions{ColorFunc: CyclePalette(palette)})
+}
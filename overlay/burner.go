@@ -0,0 +1,82 @@
+// Package overlay stamps pixel font text onto existing images, such as
+// timestamps on camera frames.
+package overlay
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Frame is an image from a stream, with the time it was captured.
+type Frame struct {
+	Image draw.Image
+	Time  time.Time
+}
+
+// Burner burns text, such as a timestamp or subtitle, into each frame of an
+// image stream. The text is only rendered again when it changes, so stamping
+// a clock which changes once a second onto a 30fps stream is cheap.
+//
+// Wrap *image.YCbCr video frames in a pixfont.YCbCrDrawable to burn text into
+// them directly.
+type Burner struct {
+	// Font is used to draw the text. If nil, pixfont.DefaultFont is used.
+	Font *pixfont.PixFont
+	// Color is the text color.
+	Color color.Color
+	// Background, if not nil, fills a box behind the text so that it is
+	// readable on any frame.
+	Background color.Color
+	// Anchor is the position of the text within each frame.
+	Anchor pixfont.Anchor
+	// Margin is the distance in pixels from the edges of the frame.
+	Margin int
+	// Text returns the text to draw for a frame captured at time t, e.g.:
+	//
+	//	func(t time.Time) string { return t.Format("2006-01-02 15:04:05") }
+	Text func(t time.Time) string
+
+	text string
+	list *pixfont.DrawList
+}
+
+// Burn draws the text for time t onto img.
+func (b *Burner) Burn(img draw.Image, t time.Time) {
+	s := b.Text(t)
+	f := b.Font
+	if f == nil {
+		f = pixfont.DefaultFont
+	}
+	if b.list == nil || s != b.text || b.list.Color != b.Color {
+		b.text, b.list = s, f.Prerender(s, b.Color)
+	}
+
+	size := image.Pt(b.list.Width, b.list.Height)
+	if size.X > 0 {
+		size.X -= f.Spacing() // no spacing after the last character
+	}
+	pad := 0
+	if b.Background != nil {
+		pad = 1
+	}
+	pt := b.Anchor.Place(img.Bounds(), size.Add(image.Pt(2*pad, 2*pad)), b.Margin)
+	if b.Background != nil {
+		r := image.Rectangle{pt, pt.Add(size).Add(image.Pt(2*pad, 2*pad))}
+		draw.Draw(img, r, image.NewUniform(b.Background), image.Point{}, draw.Src)
+	}
+	b.list.Draw(img, pt.X+pad, pt.Y+pad)
+}
+
+// Run burns text into each frame received from in and sends it on to out,
+// until in is closed. It then closes out.
+func (b *Burner) Run(in <-chan Frame, out chan<- Frame) {
+	for f := range in {
+		b.Burn(f.Image, f.Time)
+		out <- f
+	}
+	close(out)
+}
@@ -0,0 +1,239 @@
+package overlay
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/fonts/pico"
+)
+
+// grayImage returns a new image of w by h mid-gray pixels.
+func grayImage(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	return img
+}
+
+// grayRows returns the rows of img, with '#' for black pixels, '.' for white
+// and ' ' for any other shade, separated by "|".
+func grayRows(img *image.Gray) string {
+	var rows []string
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		var row []byte
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			switch img.GrayAt(x, y).Y {
+			case 0:
+				row = append(row, '#')
+			case 0xff:
+				row = append(row, '.')
+			default:
+				row = append(row, ' ')
+			}
+		}
+		rows = append(rows, string(row))
+	}
+	return strings.Join(rows, "|")
+}
+
+func TestBurner(t *testing.T) {
+	calls := 0
+	b := &Burner{
+		Font:       pico.Font3x5,
+		Color:      color.Black,
+		Background: color.White,
+		Anchor:     pixfont.BottomRight,
+		Margin:     1,
+		Text: func(t time.Time) string {
+			calls++
+			if t.Second() == 0 {
+				return "I"
+			}
+			return "T"
+		},
+	}
+	img := grayImage(10, 9)
+	b.Burn(img, time.Unix(0, 0))
+	want := "          |    ..... |    .###. |    ..#.. |    ..#.. |    ..#.. |    .###. |    ..... |          "
+	if got := grayRows(img); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	list := b.list
+	b.Burn(grayImage(10, 9), time.Unix(0, 5e8))
+	if b.list != list {
+		t.Error("unchanged text was rendered again")
+	}
+
+	in, out := make(chan Frame, 2), make(chan Frame, 2)
+	in <- Frame{grayImage(10, 9), time.Unix(1, 0)}
+	in <- Frame{grayImage(10, 9), time.Unix(2, 0)}
+	close(in)
+	b.Background = nil
+	b.Run(in, out)
+	n := 0
+	for f := range out {
+		want := "          |          |          |      ### |       #  |       #  |       #  |       #  |          "
+		if got := grayRows(f.Image.(*image.Gray)); got != want {
+			t.Errorf("frame %d is %q\nwant %q", n, got, want)
+		}
+		n++
+	}
+	if n != 2 || calls != 4 {
+		t.Errorf("%d frames, %d calls to Text; want 2 and 4", n, calls)
+	}
+}
+
+func TestStamp(t *testing.T) {
+	s := &Stamp{Font: pico.Font3x5, Color: color.Black, Background: color.White, Padding: 1, Margin: 1}
+	if sz := s.Size("I\nT"); sz != image.Pt(5, 12) {
+		t.Errorf("size is %v, want 5x12", sz)
+	}
+	img := grayImage(7, 14)
+	s.Draw(img, "I\nT")
+	want := "       | ..... | .###. | ..#.. | ..#.. | ..#.. | .###. | .###. | ..#.. | ..#.. | ..#.. | ..#.. | ..... |       "
+	if got := grayRows(img); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	// the shadow and padding are scaled along with the text
+	s.Scale, s.Shadow = 2, color.Black
+	if sz := s.Size("I\nT"); sz != image.Pt(12, 26) {
+		t.Errorf("scaled size is %v, want 12x26", sz)
+	}
+
+	s = &Stamp{Font: pico.Font3x5, Scale: 2, Anchor: pixfont.BottomRight, Margin: 1}
+	img = grayImage(8, 12)
+	s.Draw(img, "T")
+	want = "        | ...... | ...... |   ..   |   ..   |   ..   |   ..   |   ..   |   ..   |   ..   |   ..   |        "
+	if got := grayRows(img); got != want {
+		t.Errorf("scaled: got  %q\nwant %q", got, want)
+	}
+}
+
+func TestWatermark(t *testing.T) {
+	s := &Stamp{Font: pico.Font3x5, Color: color.Black}
+	var in, out bytes.Buffer
+	if err := png.Encode(&in, grayImage(5, 7)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Watermark(&out, &in, "T", s); err != nil {
+		t.Fatal(err)
+	}
+	m, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := grayRows(m.(*image.Gray)), "###  | #   | #   | #   | #   |     |     "; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	// JPEG images decode as YCbCr, and are converted to be drawn on
+	in.Reset()
+	out.Reset()
+	if err := jpeg.Encode(&in, image.NewGray(image.Rect(0, 0, 16, 16)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Watermark(&out, &in, "T", &Stamp{Font: pico.Font3x5}); err != nil {
+		t.Fatal(err)
+	}
+	m, format, err := image.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := m.At(0, 0).RGBA(); format != "jpeg" || r < 0x8000 {
+		t.Errorf("got a %s image with %v at 0,0", format, m.At(0, 0))
+	}
+
+	in.Reset()
+	if err := gif.Encode(&in, grayImage(5, 5), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Watermark(&out, &in, "T", s); err == nil {
+		t.Error("no error for a GIF image")
+	}
+	if err := Watermark(&out, strings.NewReader("not an image"), "T", s); err == nil {
+		t.Error("no error for a corrupt image")
+	}
+}
+
+// busyImage returns a w by h black and white checkerboard, except for a white
+// patch covering quiet.
+func busyImage(w, h int, quiet image.Rectangle) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for yy := 0; yy < h; yy++ {
+		for xx := 0; xx < w; xx++ {
+			if (xx+yy)%2 == 0 || image.Pt(xx, yy).In(quiet) {
+				img.SetGray(xx, yy, color.Gray{0xff})
+			}
+		}
+	}
+	return img
+}
+
+func TestQuietRegion(t *testing.T) {
+	img := busyImage(12, 8, image.Rect(7, 3, 10, 6))
+	if pt := QuietRegion(img, image.Pt(3, 3), 0); pt != image.Pt(7, 3) {
+		t.Errorf("quiet region is at %v, want 7,3", pt)
+	}
+	if pt := QuietRegion(img, image.Pt(3, 3), 1); pt != image.Pt(7, 3) {
+		t.Errorf("quiet region with a margin is at %v, want 7,3", pt)
+	}
+	// on a sub-image, which the patch is outside of
+	sub := img.SubImage(image.Rect(1, 1, 7, 8))
+	if pt := QuietRegion(sub, image.Pt(3, 3), 0); !pt.In(image.Rect(1, 1, 5, 6)) {
+		t.Errorf("quiet region of a sub-image is at %v", pt)
+	}
+	for _, size := range []image.Point{{13, 3}, {3, 9}, {0, 3}} {
+		if pt := QuietRegion(img, size, 2); pt != image.Pt(2, 2) {
+			t.Errorf("region %v doesn't fit, but is placed at %v", size, pt)
+		}
+	}
+}
+
+func TestContrastColor(t *testing.T) {
+	img := grayImage(4, 4)
+	if c := ContrastColor(img, img.Rect); c != color.Black {
+		t.Errorf("contrast with mid-gray is %v, want black", c)
+	}
+	for i := range img.Pix {
+		img.Pix[i] = 0x7f
+	}
+	if c := ContrastColor(img, img.Rect); c != color.White {
+		t.Errorf("contrast with dark gray is %v, want white", c)
+	}
+	if c := ContrastColor(img, image.Rect(10, 10, 12, 12)); c != color.White {
+		t.Errorf("contrast outside the image is %v, want white", c)
+	}
+}
+
+func TestDrawQuiet(t *testing.T) {
+	quiet := image.Rect(7, 2, 10, 7) // the size of the pico font's I
+	img := busyImage(12, 8, quiet)
+	s := &Stamp{Font: pico.Font3x5}
+	if r := s.DrawQuiet(img, "I"); r != quiet {
+		t.Errorf("stamped in %v, want %v", r, quiet)
+	}
+	sub := img.SubImage(quiet).(*image.Gray)
+	if got, want := grayRows(sub), "###|.#.|.#.|.#.|###"; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	// with a background, the color contrasts with the background
+	img = busyImage(12, 8, quiet)
+	s.Background = color.Black
+	s.DrawQuiet(img, "I")
+	sub = img.SubImage(quiet).(*image.Gray)
+	if got, want := grayRows(sub), "...|#.#|#.#|#.#|..."; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// luma returns the brightness of c from 0 to 255.
+func luma(c color.Color) int64 {
+	return int64(color.GrayModel.Convert(c).(color.Gray).Y)
+}
+
+// QuietRegion returns the top-left corner of the size.X by size.Y region of img,
+// at least margin pixels from its edges, with the least variance in brightness.
+// Text placed there stays readable, as it avoids busy detail such as foliage or
+// faces in favor of sky, walls or shadow. If the region doesn't fit, the
+// top-left corner within the margin is returned.
+func QuietRegion(img image.Image, size image.Point, margin int) image.Point {
+	b := img.Bounds()
+	area := b.Inset(margin)
+	if area.Dx() < size.X || area.Dy() < size.Y || size.X <= 0 || size.Y <= 0 {
+		return area.Min
+	}
+
+	// summed area tables of brightness and its square give the variance of
+	// any region in constant time
+	w, h := area.Dx(), area.Dy()
+	sum := make([]int64, (w+1)*(h+1))
+	sq := make([]int64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		var rs, rq int64
+		for x := 0; x < w; x++ {
+			v := luma(img.At(area.Min.X+x, area.Min.Y+y))
+			rs += v
+			rq += v * v
+			i := (y+1)*(w+1) + x + 1
+			sum[i] = sum[i-w-1] + rs
+			sq[i] = sq[i-w-1] + rq
+		}
+	}
+	total := func(t []int64, x, y int) int64 {
+		x1, y1 := x+size.X, y+size.Y
+		return t[y1*(w+1)+x1] - t[y*(w+1)+x1] - t[y1*(w+1)+x] + t[y*(w+1)+x]
+	}
+
+	n := int64(size.X * size.Y)
+	best, bestVar := area.Min, int64(-1)
+	for y := 0; y+size.Y <= h; y++ {
+		for x := 0; x+size.X <= w; x++ {
+			s := total(sum, x, y)
+			// n² times the variance, which orders regions the same way
+			v := n*total(sq, x, y) - s*s
+			if bestVar < 0 || v < bestVar {
+				best, bestVar = area.Min.Add(image.Pt(x, y)), v
+			}
+		}
+	}
+	return best
+}
+
+// ContrastColor returns black or white, whichever stands out more against the
+// average brightness of r in img.
+func ContrastColor(img image.Image, r image.Rectangle) color.Color {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return color.White
+	}
+	var s int64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s += luma(img.At(x, y))
+		}
+	}
+	return contrast(s / int64(r.Dx()*r.Dy()))
+}
+
+// contrast returns black or white, whichever stands out more against the
+// brightness l.
+func contrast(l int64) color.Color {
+	if l >= 128 {
+		return color.Black
+	}
+	return color.White
+}
+
+// DrawQuiet stamps text onto img like Draw, but in the region found by
+// QuietRegion instead of at s.Anchor, for annotating arbitrary photos. If
+// s.Color is nil, the text is black or white by ContrastColor. DrawQuiet
+// returns the rectangle the text was stamped in.
+func (s *Stamp) DrawQuiet(img draw.Image, text string) image.Rectangle {
+	size := s.Size(text)
+	pt := QuietRegion(img, size, s.Margin)
+	r := image.Rectangle{pt, pt.Add(size)}
+	clr := s.Color
+	if clr == nil {
+		if s.Background != nil {
+			clr = contrast(luma(s.Background))
+		} else {
+			clr = ContrastColor(img, r)
+		}
+	}
+	s.drawAt(img, pt, text, clr)
+	return r
+}
@@ -0,0 +1,148 @@
+package overlay
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Stamp describes text stamped onto an image by Watermark, such as a caption or
+// copyright notice.
+type Stamp struct {
+	// Font is used to draw the text. If nil, pixfont.DefaultFont is used.
+	Font *pixfont.PixFont
+	// Scale draws each pixel of the font as a Scale by Scale block, so that
+	// text is readable on large photos. Values below 2 draw at the font's
+	// own size.
+	Scale int
+	// Color is the text color, white if nil.
+	Color color.Color
+	// Shadow, if not nil, draws the text again in this color one (scaled)
+	// pixel down and to the right, under the text.
+	Shadow color.Color
+	// Background, if not nil, fills a box behind the text, Padding (scaled)
+	// pixels larger than the text on each side.
+	Background color.Color
+	Padding    int
+	// Anchor is the position of the text within the image, and Margin its
+	// distance in pixels from the edges.
+	Anchor pixfont.Anchor
+	Margin int
+	// Quality is the JPEG quality used to encode JPEG images, 90 if 0.
+	Quality int
+}
+
+// Draw stamps text onto img as described by s. Lines of text are separated by
+// newlines.
+func (s *Stamp) Draw(img draw.Image, text string) {
+	pt := s.Anchor.Place(img.Bounds(), s.Size(text), s.Margin)
+	clr := s.Color
+	if clr == nil {
+		clr = color.White
+	}
+	s.drawAt(img, pt, text, clr)
+}
+
+func (s *Stamp) font() *pixfont.PixFont {
+	if s.Font == nil {
+		return pixfont.DefaultFont
+	}
+	return s.Font
+}
+
+func (s *Stamp) scale() int {
+	if s.Scale < 1 {
+		return 1
+	}
+	return s.Scale
+}
+
+// pad returns the padding around the text, which is only used with a
+// background.
+func (s *Stamp) pad() int {
+	if s.Background == nil {
+		return 0
+	}
+	return s.Padding * s.scale()
+}
+
+// Size returns the size of text stamped as described by s, including the
+// shadow and background box.
+func (s *Stamp) Size(text string) image.Point {
+	f, scale := s.font(), s.scale()
+	var size image.Point
+	lines := strings.Split(text, "\n")
+	for _, line := range lines {
+		w := f.MeasureString(line)
+		if w > 0 {
+			w -= f.Spacing() // no spacing after the last character
+		}
+		if w*scale > size.X {
+			size.X = w * scale
+		}
+	}
+	size.Y = len(lines) * f.GetHeight() * scale
+	if s.Shadow != nil {
+		size = size.Add(image.Pt(scale, scale))
+	}
+	pad := s.pad()
+	return size.Add(image.Pt(2*pad, 2*pad))
+}
+
+// drawAt stamps text in clr with the top-left corner of its box at pt.
+func (s *Stamp) drawAt(img draw.Image, pt image.Point, text string, clr color.Color) {
+	f, scale, pad := s.font(), s.scale(), s.pad()
+	if s.Background != nil {
+		r := image.Rectangle{pt, pt.Add(s.Size(text))}
+		draw.Draw(img, r, image.NewUniform(s.Background), image.Point{}, draw.Src)
+	}
+	pt = pt.Add(image.Pt(pad, pad))
+	lh := f.GetHeight() * scale
+	opts := &pixfont.DrawOptions{Scale: scale}
+	for i, line := range strings.Split(text, "\n") {
+		y := pt.Y + i*lh
+		if s.Shadow != nil {
+			f.DrawStringOptions(img, pt.X+scale, y+scale, line, s.Shadow, opts)
+		}
+		f.DrawStringOptions(img, pt.X, y, line, clr, opts)
+	}
+}
+
+// Watermark decodes a PNG or JPEG image from r, stamps text onto it as
+// described by s, and writes it to w in the same format. This is the whole
+// "caption this photo" workflow in one call:
+//
+//	s := &overlay.Stamp{Anchor: pixfont.BottomRight, Margin: 8, Scale: 2, Shadow: color.Black}
+//	err := overlay.Watermark(out, in, "(c) 2024 Example", s)
+func Watermark(w io.Writer, r io.Reader, text string, s *Stamp) error {
+	src, format, err := image.Decode(r)
+	if err != nil {
+		return err
+	}
+	img, ok := src.(draw.Image)
+	if !ok {
+		rgba := image.NewRGBA(src.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+		img = rgba
+	}
+	s.Draw(img, text)
+
+	switch format {
+	case "png":
+		return png.Encode(w, img)
+	case "jpeg":
+		q := s.Quality
+		if q == 0 {
+			q = 90
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: q})
+	}
+	return fmt.Errorf("overlay: can't encode %s images", format)
+}
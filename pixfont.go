@@ -8,8 +8,12 @@
 package pixfont
 
 import (
-	"bytes"
+	"image"
 	"image/color"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // DefaultFont is used by the convienence method DrawString, and is initialized
@@ -17,6 +21,12 @@ import (
 var DefaultFont = Font8x8
 
 // Spacing is the pixel spacing to use between letters (1 px by default)
+//
+// Deprecated: Spacing is read without synchronization, so changing it while
+// another goroutine is drawing is a data race, and can mix two spacings in one
+// string. Use SetDefaultSpacing instead, or SetSpacing for a single font. So
+// that existing code keeps working, a Spacing changed from 1 still takes
+// precedence over SetDefaultSpacing.
 var Spacing = 1
 
 // Drawable is an interface which supports setting an x,y coordinate to a color.
@@ -28,17 +38,47 @@ type Drawable interface {
 // simple opaque-pixel operations (supported by image.Image and easily included
 // in other packages).
 type PixFont struct {
+	// Name, Copyright and Comment describe the font, so that its license
+	// attribution travels with it. They are set by the loaders and generated
+	// code when the source font provides them, and are otherwise empty.
+	Name      string
+	Copyright string
+	Comment   string
+
 	charWidth    uint8
 	charHeight   uint8
 	charmap      map[rune]uint16
+	sorted       []rune // with offsets, replaces charmap if non-nil
+	offsets      []uint16
 	data         []uint32
+	rom          string    // replaces data if not empty
+	lazy         *lazyData // non-nil if data is decompressed on demand
 	varCharWidth uint8
+	normalize    bool
+	arabic       bool
+	hangul       bool
+	gridCell     int // advance of narrow runes on a CJK grid, or 0
+	combining    map[rune]bool
+	stacking     map[rune]int // stacking group of stacked marks
+	stacks       []int        // y offset between marks of each stacking group
+	replacement  rune
+	aliases      map[rune]rune
+	cache        runCache
+	usage        *runeUsage // non-nil while TrackUsage is on
+	spacing      int        // replaces the default spacing if ownSpacing
+	ownSpacing   bool
 }
 
 // NewPixFont creates a new PixFont with the provided character width/height and
 // character map of offsets into a packed uint32 array of bits.
 func NewPixFont(w, h uint8, cm map[rune]uint16, d []uint32) *PixFont {
-	return &PixFont{w, h, cm, d, w}
+	return &PixFont{
+		charWidth:    w,
+		charHeight:   h,
+		charmap:      cm,
+		data:         d,
+		varCharWidth: w,
+	}
 }
 
 // GetHeight returns the height of the font in pixels.
@@ -46,6 +86,17 @@ func (p *PixFont) GetHeight() int {
 	return int(p.charHeight)
 }
 
+// GetWidth returns the width of the font's character cell in pixels.
+func (p *PixFont) GetWidth() int {
+	return int(p.charWidth)
+}
+
+// IsVariableWidth returns true if the PixFont draws using variable width per
+// character.
+func (p *PixFont) IsVariableWidth() bool {
+	return p.varCharWidth != p.charWidth
+}
+
 // SetVariableWidth toggles the PixFont between drawing using variable width
 // per character or the default fixed-width representation.
 func (p *PixFont) SetVariableWidth(isVar bool) {
@@ -58,6 +109,96 @@ func (p *PixFont) SetVariableWidth(isVar bool) {
 			p.varCharWidth = 3
 		}
 	}
+	p.cache.reset()
+}
+
+// SetNormalization toggles Unicode NFC normalization of strings before glyph lookup.
+// When enabled, decomposed sequences such as "e\u0301" are drawn using the
+// precomposed glyph (here "\u00e9") when the PixFont has one, instead of a letter
+// followed by a missing-glyph gap.
+func (p *PixFont) SetNormalization(nfc bool) {
+	p.normalize = nfc
+	p.cache.reset()
+}
+
+// prepare applies any configured string transformations before glyph lookup.
+func (p *PixFont) prepare(s string) string {
+	if p.normalize {
+		s = norm.NFC.String(s)
+	}
+	if p.arabic {
+		s = p.shapeArabic(s)
+	}
+	return s
+}
+
+// SetCombining flags the glyphs for rs as combining marks, such as accents,
+// replacing any previously flagged runes (including stacked marks). Combining
+// marks are drawn over the preceding glyph without advancing, so their glyphs
+// should be designed in the position they take over a base character. For
+// example, to flag every nonspacing mark in a font:
+//
+//	var marks []rune
+//	for _, r := range f.Runes() {
+//		if unicode.Is(unicode.Mn, r) {
+//			marks = append(marks, r)
+//		}
+//	}
+//	f.SetCombining(marks...)
+func (p *PixFont) SetCombining(rs ...rune) {
+	p.combining, p.stacking, p.stacks = nil, nil, nil
+	if len(rs) > 0 {
+		p.combining = make(map[rune]bool, len(rs))
+		for _, r := range rs {
+			p.combining[r] = true
+		}
+	}
+	p.cache.reset()
+}
+
+// SetReplacementRune sets a rune, such as '?' or '\u25a1', whose glyph is drawn
+// in place of any rune missing from this PixFont, instead of leaving a gap. A
+// replacement of 0 (the default) restores the gap. DrawRune still returns false
+// for missing runes when a replacement is drawn.
+func (p *PixFont) SetReplacementRune(r rune) {
+	p.replacement = r
+	p.cache.reset()
+}
+
+// layout positions each rune of s, calling draw with the rune and its x offset
+// from the start of the string, and its y offset (which is only non-zero for
+// stacked marks). draw returns the rune's advance, and layout returns the total
+// advance of the string, with sp pixels of spacing after each rune. Combining
+// marks are placed at the offset of the preceding rune, and do not advance.
+func (p *PixFont) layout(s string, sp int, draw func(c rune, dx, dy int) int) int {
+	x, last := 0, 0
+	var stacked []int // marks of each stacking group on the current base
+	if len(p.stacks) > 0 {
+		stacked = make([]int, len(p.stacks))
+	}
+	for _, c := range s {
+		if p.combining[c] {
+			dy := 0
+			if g, ok := p.stacking[c]; ok {
+				dy = stacked[g] * p.stacks[g]
+				stacked[g]++
+			}
+			draw(c, last, dy)
+			continue
+		}
+		if c == softHyphen {
+			// invisible, without spacing, and not a base for marks
+			draw(c, x, 0)
+			continue
+		}
+		for i := range stacked {
+			stacked[i] = 0
+		}
+		w := draw(c, x, 0)
+		last = x
+		x += w + sp
+	}
+	return x
 }
 
 // DrawRune uses this PixFont to display a single rune in the provided color and
@@ -65,11 +206,49 @@ func (p *PixFont) SetVariableWidth(isVar bool) {
 // Drawable.Set is called for each opaque pixel in the font, leaving all other pixels
 // in the Drawable as-is. If the rune has no representation in the PixFont, then
 // DrawRune returns false and no drawing is done. DrawRune always returns the number
-// of pixels to advance before drawing another character.
+// of pixels to advance before drawing another character. A soft hyphen (U+00AD)
+// is invisible, with no advance.
 func (p *PixFont) DrawRune(dr Drawable, x, y int, c rune, clr color.Color) (bool, int) {
-	poff, haveChar := p.charmap[c]
+	if u := p.usage; u != nil {
+		u.add(c)
+	}
+	return p.drawRune(setter(dr, clr), x, y, c, p.Spacing())
+}
+
+// drawRune is the shared implementation of DrawRune and MeasureRune. If set is
+// non-nil it is called for each opaque pixel of the rune. A soft hyphen is
+// invisible, as it is only drawn as a hyphen where wrapping breaks a line, and a
+// no-break space is drawn as a space unless the font has a glyph for it. The
+// advance of variable width glyphs includes the spacing sp.
+func (p *PixFont) drawRune(set func(x, y int), x, y int, c rune, sp int) (bool, int) {
+	switch {
+	case c == softHyphen:
+		return true, 0
+	case c == noBreakSpace && !p.has(c):
+		c = ' '
+	}
+	ok, w := p.drawGlyph(set, x, y, c, sp)
+	if p.gridCell > 0 {
+		w = p.gridAdvance(c, sp)
+	}
+	return ok, w
+}
+
+// drawGlyph draws the glyph for c, returning whether c has one and its advance
+// with the spacing sp.
+func (p *PixFont) drawGlyph(set func(x, y int), x, y int, c rune, sp int) (bool, int) {
+	poff, haveChar := p.lookup(c)
+	if !haveChar && p.hangul {
+		if jamo := p.hangulJamo(c); jamo != nil {
+			return p.drawJamo(set, x, y, jamo, sp)
+		}
+	}
 	if !haveChar {
-		return false, int(p.varCharWidth)
+		// draw the replacement glyph, but still report the rune as missing
+		var haveRepl bool
+		if poff, haveRepl = p.lookup(p.replacement); !haveRepl || p.replacement == 0 {
+			return false, int(p.varCharWidth)
+		}
 	}
 	w := int(p.charWidth)
 	if p.varCharWidth != p.charWidth {
@@ -77,20 +256,25 @@ func (p *PixFont) DrawRune(dr Drawable, x, y int, c rune, clr color.Color) (bool
 	}
 	pindex := int(poff >> 2)
 	psub := (poff & 0x03) * 8
-	d := p.data[pindex : pindex+int(p.charHeight)]
+	if p.lazy != nil {
+		p.lazy.load(p.data, pindex)
+	}
 	for yy := 0; yy < int(p.charHeight); yy++ {
+		line := p.word(pindex + yy)
 		bitMask := uint32(1) << psub
 		for xx := 0; xx < int(p.charWidth); xx++ {
-			if (d[yy] & bitMask) != 0 {
-				dr.Set(x+xx, y+yy, clr)
+			if (line & bitMask) != 0 {
+				if set != nil {
+					set(x+xx, y+yy)
+				}
 				if xx >= w {
-					w = xx + Spacing
+					w = xx + sp
 				}
 			}
 			bitMask <<= 1
 		}
 	}
-	return true, w
+	return haveChar, w
 }
 
 // DrawString uses this PixFont to display text in the provided color and the specified
@@ -98,46 +282,112 @@ func (p *PixFont) DrawRune(dr Drawable, x, y int, c rune, clr color.Color) (bool
 // first letter of s. Text is drawn by repeated calls to DrawRune for each character.
 // DrawString returns the total pixel advance used by the string.
 func (p *PixFont) DrawString(dr Drawable, x, y int, s string, clr color.Color) int {
-	for _, c := range s {
-		_, w := p.DrawRune(dr, x, y, c, clr)
-		x += w + Spacing
-	}
-	return x
+	return p.drawString(dr, x, y, s, clr, p.Spacing())
 }
 
-// MeasureRune measures the advance of a rune drawn using this PixFont.
-func (p *PixFont) MeasureRune(c rune) (bool, int) {
-	poff, haveChar := p.charmap[c]
-	if !haveChar {
-		return false, int(p.varCharWidth)
+// drawString is DrawString with the spacing sp.
+func (p *PixFont) drawString(dr Drawable, x, y int, s string, clr color.Color, sp int) int {
+	p.recordUsage(s)
+	set := setter(dr, clr)
+	if run := p.shape(s, sp); run != nil {
+		for i, c := range run.runes {
+			p.drawRune(set, x+run.offsets[i], y+run.rises[i], c, sp)
+		}
+		return x + run.width
 	}
-	w := int(p.charWidth)
-	if p.varCharWidth != p.charWidth {
-		w = 0
+	return x + p.layout(p.prepare(s), sp, func(c rune, dx, dy int) int {
+		_, w := p.drawRune(set, x+dx, y+dy, c, sp)
+		return w
+	})
+}
+
+// DrawStringChecked draws a string like DrawString, and also reports which runes
+// were drawn: drawn[i] is false if the i'th rune of s has no glyph in the
+// PixFont and was skipped. Previews and editors can use this to flag unsupported
+// characters. If normalization is enabled, the runes are those of the
+// normalized string.
+func (p *PixFont) DrawStringChecked(dr Drawable, x, y int, s string, clr color.Color) (int, []bool) {
+	p.recordUsage(s)
+	set, sp := setter(dr, clr), p.Spacing()
+	s = p.prepare(s)
+	drawn := make([]bool, 0, len(s))
+	x += p.layout(s, sp, func(c rune, dx, dy int) int {
+		ok, w := p.drawRune(set, x+dx, y+dy, c, sp)
+		drawn = append(drawn, ok)
+		return w
+	})
+	return x, drawn
+}
+
+// DrawStringInverse displays text in reverse video, like the selected or highlighted
+// text of a classic terminal. Each character cell (the rune's advance including
+// spacing, by the font height) is filled with fg, and the glyph's opaque pixels are
+// set to bg. If bg is nil, glyph pixels are left untouched so that the existing
+// contents of the Drawable show through. DrawStringInverse returns the total pixel
+// advance used by the string.
+func (p *PixFont) DrawStringInverse(dr Drawable, x, y int, s string, fg, bg color.Color) int {
+	p.recordUsage(s)
+	setFg := setter(dr, fg)
+	var setBg func(x, y int)
+	if bg != nil {
+		setBg = setter(dr, bg)
 	}
-	pindex := int(poff >> 2)
-	psub := (poff & 0x03) * 8
-	d := p.data[pindex : pindex+int(p.charHeight)]
-	for yy := 0; yy < int(p.charHeight); yy++ {
-		bitMask := uint32(1) << psub
-		for xx := 0; xx < int(p.charWidth); xx++ {
-			if (d[yy]&bitMask) != 0 && xx >= w {
-				w = xx + Spacing
+
+	cw, h, sp := int(p.charWidth), int(p.charHeight), p.Spacing()
+	mask := make([]bool, cw*h)
+	return x + p.layout(p.prepare(s), sp, func(c rune, dx, dy int) int {
+		if p.combining[c] {
+			// marks are drawn over the already filled cell of their base
+			if setBg != nil {
+				p.drawRune(setBg, x+dx, y+dy, c, sp)
 			}
-			bitMask <<= 1
+			return 0
 		}
-	}
-	return true, w
+		_, w := p.drawRune(func(xx, yy int) {
+			mask[yy*cw+xx] = true
+		}, 0, 0, c, sp)
+
+		for yy := 0; yy < h; yy++ {
+			for xx := 0; xx < w+sp; xx++ {
+				if xx < cw && mask[yy*cw+xx] {
+					mask[yy*cw+xx] = false
+					if setBg != nil {
+						setBg(x+dx+xx, y+yy)
+					}
+					continue
+				}
+				setFg(x+dx+xx, y+yy)
+			}
+		}
+		return w
+	})
+}
+
+// MeasureRune measures the advance of a rune drawn using this PixFont.
+func (p *PixFont) MeasureRune(c rune) (bool, int) {
+	return p.drawRune(nil, 0, 0, c, p.Spacing())
 }
 
 // MeasureString measures the pixel advance of a string drawn using this PixFont.
 func (p *PixFont) MeasureString(s string) int {
-	x := 0
-	for _, c := range s {
-		_, w := p.MeasureRune(c)
-		x += w + Spacing
+	return p.measureString(s, p.Spacing())
+}
+
+// measureString is MeasureString with the spacing sp.
+func (p *PixFont) measureString(s string, sp int) int {
+	if run := p.shape(s, sp); run != nil {
+		return run.width
 	}
-	return x
+	return p.measure(s, sp)
+}
+
+// measure is MeasureString with the spacing sp, without the shaped string
+// cache.
+func (p *PixFont) measure(s string, sp int) int {
+	return p.layout(p.prepare(s), sp, func(c rune, dx, dy int) int {
+		_, w := p.drawRune(nil, 0, 0, c, sp)
+		return w
+	})
 }
 
 // DrawString is a convienence method that calls DrawString using the DefaultFont
@@ -155,20 +405,72 @@ func MeasureString(s string) int {
 // StringDrawable implements Drawable so you can do FIGlet-inspired pixel fonts in
 // text. Obviously it's much simpler though.
 type StringDrawable struct {
+	// On and Off are the characters written for set and unset pixels, 'X'
+	// and ' ' if zero.
+	On, Off rune
+	// DoubleWidth writes each pixel as two characters, which makes the
+	// output closer to square in terminals with tall character cells.
+	DoubleWidth bool
+
 	lines [][]byte
+	width int  // capacity of new lines
+	fixed bool // clip to width by cap(lines) and always output that size
+}
+
+// NewStringDrawable creates a StringDrawable with room for text up to w pixels
+// wide and h pixels tall, so that drawing within that size does not allocate.
+// It will still grow to fit larger text.
+func NewStringDrawable(w, h int) *StringDrawable {
+	return &StringDrawable{
+		lines: make([][]byte, 0, h),
+		width: w,
+	}
+}
+
+// NewFixedStringDrawable creates a StringDrawable with a fixed canvas of w by h
+// pixels. Pixels set outside the canvas are ignored, and the output is always w
+// characters wide (2*w with DoubleWidth) and h lines tall.
+func NewFixedStringDrawable(w, h int) *StringDrawable {
+	s := NewStringDrawable(w, h)
+	s.fixed = true
+	return s
+}
+
+// Reset clears the StringDrawable so that it can be reused, keeping its memory.
+func (s *StringDrawable) Reset() {
+	s.lines = s.lines[:0]
 }
 
 func (s *StringDrawable) Set(x, y int, c color.Color) {
+	if x < 0 || y < 0 {
+		return
+	}
+	if s.fixed && (x >= s.width || y >= cap(s.lines)) {
+		return
+	}
 	for len(s.lines) <= y {
-		s.lines = append(s.lines, make([]byte, x))
+		if len(s.lines) < cap(s.lines) {
+			// reuse the memory of a line from before Reset
+			s.lines = s.lines[:len(s.lines)+1]
+			s.lines[len(s.lines)-1] = s.lines[len(s.lines)-1][:0]
+		} else {
+			s.lines = append(s.lines, make([]byte, 0, s.width))
+		}
 	}
 
-	if len(s.lines[y]) <= x {
-		nb := make([]byte, 1+(x-len(s.lines[y])))
-		s.lines[y] = append(s.lines[y], nb...)
+	line := s.lines[y]
+	if n := len(line); n <= x {
+		if cap(line) > x {
+			line = line[:x+1]
+			for i := n; i < x; i++ {
+				line[i] = 0
+			}
+		} else {
+			line = append(line, make([]byte, x+1-n)...)
+		}
+		s.lines[y] = line
 	}
-
-	s.lines[y][x] = byte('X')
+	line[x] = byte('X')
 }
 
 // String returns the current string representation of this Drawable.
@@ -179,9 +481,89 @@ func (s *StringDrawable) String() string {
 // PrefixString returns the current string representation of this Drawable with a
 // user-provided prefix before each line. Useful for adding output in code comments.
 func (s *StringDrawable) PrefixString(p string) string {
-	r := ""
+	height, width := s.size()
+	n := 0
 	for _, line := range s.lines {
-		r += p + string(bytes.Replace(line, []byte{0}, []byte(" "), -1)) + "\n"
+		n += len(p) + len(line)*utf8.UTFMax + 1
+	}
+	buf := make([]byte, 0, n)
+	for y := 0; y < height; y++ {
+		buf = s.appendLine(buf, p, y, 0, width)
+	}
+	return string(buf)
+}
+
+// WriteTo implements io.WriterTo, writing the same text as String to w one line
+// at a time.
+func (s *StringDrawable) WriteTo(w io.Writer) (int64, error) {
+	height, width := s.size()
+	var buf []byte
+	var n int64
+	for y := 0; y < height; y++ {
+		buf = s.appendLine(buf[:0], "", y, 0, width)
+		nn, err := w.Write(buf)
+		n += int64(nn)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Region returns the text of the pixels within r, as for String, with every
+// line r.Dx() pixels wide. This is useful for composing several drawn blocks
+// into one text screen.
+func (s *StringDrawable) Region(r image.Rectangle) string {
+	var buf []byte
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		buf = s.appendLine(buf, "", y, r.Min.X, r.Max.X)
+	}
+	return string(buf)
+}
+
+// size returns the number of lines to output, and their width in pixels, or -1
+// if each line is only as long as its last set pixel.
+func (s *StringDrawable) size() (height, width int) {
+	if s.fixed {
+		return cap(s.lines), s.width
+	}
+	return len(s.lines), -1
+}
+
+// appendLine appends prefix and the text for the pixels from x0 to x1 of line y
+// to buf, followed by a newline. If x1 is negative the text ends at the line's
+// last set pixel.
+func (s *StringDrawable) appendLine(buf []byte, prefix string, y, x0, x1 int) []byte {
+	on, off := s.On, s.Off
+	if on == 0 {
+		on = 'X'
+	}
+	if off == 0 {
+		off = ' '
+	}
+	reps := 1
+	if s.DoubleWidth {
+		reps = 2
+	}
+	var line []byte
+	if y >= 0 && y < len(s.lines) {
+		line = s.lines[y]
+	}
+	if x1 < 0 {
+		x1 = len(line)
+	}
+
+	var enc [utf8.UTFMax]byte
+	buf = append(buf, prefix...)
+	for x := x0; x < x1; x++ {
+		c := off
+		if x >= 0 && x < len(line) && line[x] != 0 {
+			c = on
+		}
+		n := utf8.EncodeRune(enc[:], c)
+		for i := 0; i < reps; i++ {
+			buf = append(buf, enc[:n]...)
+		}
 	}
-	return r
+	return append(buf, '\n')
 }
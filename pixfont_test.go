@@ -0,0 +1,1978 @@
+package pixfont
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// slowDrawable hides the concrete image type so that the generic Set path is used.
+type slowDrawable struct {
+	Drawable
+}
+
+func TestPalettedFastPath(t *testing.T) {
+	fast := image.NewPaletted(image.Rect(0, 0, 120, 12), palette.Plan9)
+	slow := image.NewPaletted(fast.Rect, palette.Plan9)
+	clr := color.RGBA{200, 30, 90, 255}
+
+	// start partially offscreen to exercise clipping
+	Font8x8.DrawString(fast, -3, 2, "Hello, World!", clr)
+	Font8x8.DrawString(slowDrawable{slow}, -3, 2, "Hello, World!", clr)
+
+	for i := range fast.Pix {
+		if fast.Pix[i] != slow.Pix[i] {
+			t.Fatalf("pixel %d: fast path wrote %d, Set wrote %d", i, fast.Pix[i], slow.Pix[i])
+		}
+	}
+}
+
+func TestParseRanges(t *testing.T) {
+	rs, err := ParseRanges("U+0020-007E, u+263A, U+4??")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RuneRange{{0x20, 0x7E}, {0x263A, 0x263A}, {0x400, 0x4FF}}
+	if len(rs) != len(want) {
+		t.Fatalf("got %v, want %v", rs, want)
+	}
+	for i := range want {
+		if rs[i] != want[i] {
+			t.Errorf("range %d: got %v, want %v", i, rs[i], want[i])
+		}
+	}
+	if !InRanges(rs, 'A') || InRanges(rs, 0x7F) || !InRanges(rs, 0x4AF) {
+		t.Error("InRanges gave the wrong result")
+	}
+
+	for _, bad := range []string{"0020-007E", "U+7E-20", "U+4?1", "U+ZZ", "U+110000"} {
+		if _, err := ParseRanges(bad); err == nil {
+			t.Errorf("ParseRanges(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLoadRaw(t *testing.T) {
+	// a 10x2 'A' and 'B' with 2 byte rows, LSB first, padded to 6 bytes each
+	data := []byte{
+		0x01, 0x02, 0x00, 0x01, 0xff, 0xff,
+		0xff, 0x03, 0x00, 0x00, 0xff, 0xff,
+	}
+	f, err := LoadRaw(bytes.NewReader(data), 10, 2, 'A', &RawOptions{LSBFirst: true, GlyphStride: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := &StringDrawable{}
+	f.DrawString(sd, 0, 0, "AB", nil)
+	want := "X        X XXXXXXXXXX\n        X\n"
+	if got := sd.String(); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestPrerender(t *testing.T) {
+	want := &StringDrawable{}
+	x := DefaultFont.DrawString(want, 3, 1, "Hello, world", nil)
+
+	d := DefaultFont.Prerender("Hello, world", color.Black)
+	got := &StringDrawable{}
+	if gx := d.Draw(got, 3, 1); gx != x {
+		t.Errorf("Draw returned %d, want %d", gx, x)
+	}
+	if got.String() != want.String() {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStringDrawableReset(t *testing.T) {
+	sd := NewStringDrawable(64, 8)
+	DefaultFont.DrawString(sd, 0, 0, "ABC", nil)
+	want := sd.String()
+
+	allocs := testing.AllocsPerRun(10, func() {
+		sd.Reset()
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 64; x += 3 {
+				sd.Set(x, y, nil)
+			}
+		}
+	})
+	if allocs > 0 {
+		t.Errorf("drawing after Reset made %v allocations", allocs)
+	}
+
+	sd.Reset()
+	DefaultFont.DrawString(sd, 0, 0, "ABC", nil)
+	if got := sd.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	sd.Reset()
+	if sd.String() != "" {
+		t.Error("Reset did not clear the drawable")
+	}
+}
+
+func TestShapeCache(t *testing.T) {
+	f := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	f.SetVariableWidth(true)
+	f.SetCacheSize(2)
+
+	uncached := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	uncached.SetVariableWidth(true)
+	uncached.SetCacheSize(0)
+
+	defer func(s int) { Spacing = s }(Spacing)
+	for _, sp := range []int{1, 1, 3} {
+		Spacing = sp
+		for _, s := range []string{"Hello", "world", "Hello", "!"} {
+			if got, want := f.MeasureString(s), uncached.MeasureString(s); got != want {
+				t.Errorf("spacing %d: MeasureString(%q) = %d, want %d", sp, s, got, want)
+			}
+			a, b := &StringDrawable{}, &StringDrawable{}
+			f.DrawString(a, 0, 0, s, nil)
+			uncached.DrawString(b, 0, 0, s, nil)
+			if a.String() != b.String() {
+				t.Errorf("spacing %d: DrawString(%q) differs from uncached", sp, s)
+			}
+		}
+	}
+	if n := len(f.cache.items); n != 2 {
+		t.Errorf("cache has %d entries, want 2", n)
+	}
+}
+
+func TestDrawStringGlow(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 16))
+	x := DefaultFont.DrawStringGlow(img, 4, 4, "I", color.White, color.RGBA{0, 0, 255, 255}, 2)
+	if want := DefaultFont.DrawString(&StringDrawable{}, 4, 4, "I", nil); x != want {
+		t.Errorf("advance %d, want %d", x, want)
+	}
+
+	mask := DefaultFont.GlyphMask('I')
+	for yy := 0; yy < 8; yy++ {
+		for xx := 0; xx < 8; xx++ {
+			if mask.AlphaAt(xx, yy).A != 0 {
+				if c := img.RGBAAt(4+xx, 4+yy); c != (color.RGBA{255, 255, 255, 255}) {
+					t.Fatalf("glyph pixel %d,%d is %v", xx, yy, c)
+				}
+				// the pixel above the top of the glyph is within the halo
+				if yy == 0 || mask.AlphaAt(xx, yy-1).A == 0 {
+					if c := img.RGBAAt(4+xx, 3+yy); c.B == 0 || c.R != 0 {
+						t.Errorf("no halo above glyph pixel %d,%d: %v", xx, yy, c)
+					}
+				}
+			}
+		}
+	}
+	if c := img.RGBAAt(39, 15); c != (color.RGBA{}) {
+		t.Errorf("pixel far from the glyph is %v", c)
+	}
+}
+
+func TestDrawStringChecked(t *testing.T) {
+	sd := &StringDrawable{}
+	x, drawn := DefaultFont.DrawStringChecked(sd, 0, 0, "A世B", nil)
+	if want := DefaultFont.MeasureString("A世B"); x != want {
+		t.Errorf("advance %d, want %d", x, want)
+	}
+	if len(drawn) != 3 || !drawn[0] || drawn[1] || !drawn[2] {
+		t.Errorf("drawn = %v, want [true false true]", drawn)
+	}
+}
+
+func TestCombining(t *testing.T) {
+	data, cm, _ := Pack(3, 3, map[rune]map[int]string{
+		'a':    {1: "XXX", 2: "X X"},
+		'b':    {0: "X  ", 1: "XX ", 2: "XX "},
+		0x0301: {0: " X "},
+	})
+	f := NewPixFont(3, 3, cm, data)
+
+	// the mark advances like any other glyph until it is flagged
+	if x := f.MeasureString("a\u0301b"); x != 12 {
+		t.Errorf("before SetCombining got advance %d, want 12", x)
+	}
+
+	f.SetCombining(0x0301)
+	sd := &StringDrawable{}
+	x := f.DrawString(sd, 0, 0, "a\u0301b", nil)
+	want := " X  X\nXXX XX\nX X XX\n"
+	if sd.String() != want || x != 8 {
+		t.Errorf("got advance %d\n%q\nwant 8\n%q", x, sd.String(), want)
+	}
+	if m := f.MeasureString("a\u0301b"); m != x {
+		t.Errorf("MeasureString = %d, want %d", m, x)
+	}
+}
+
+func TestReplacementRune(t *testing.T) {
+	f := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	f.SetReplacementRune('?')
+
+	got, want := &StringDrawable{}, &StringDrawable{}
+	x, drawn := f.DrawStringChecked(got, 0, 0, "A世", nil)
+	Font8x8.DrawString(want, 0, 0, "A?", nil)
+	if got.String() != want.String() || x != Font8x8.MeasureString("A?") {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if !drawn[0] || drawn[1] {
+		t.Errorf("drawn = %v, want [true false]", drawn)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	w := Font8x8.MeasureString("hello")
+	lines := Font8x8.wrap("hello world\nsupercalifragilistic x", w, Font8x8.Spacing())
+	want := []string{"hello", "world", "super", "calif", "ragil", "istic", "x"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", lines, want)
+	}
+}
+
+func TestYCbCrFastPath(t *testing.T) {
+	r := image.Rect(0, 0, 60, 10)
+	fast := &YCbCrDrawable{image.NewYCbCr(r, image.YCbCrSubsampleRatio420)}
+	slow := &YCbCrDrawable{image.NewYCbCr(r, image.YCbCrSubsampleRatio420)}
+	clr := color.RGBA{250, 200, 20, 255}
+
+	DefaultFont.DrawString(fast, -3, 1, "Hello!", clr)
+	DefaultFont.DrawString(slowDrawable{slow}, -3, 1, "Hello!", clr)
+	if !bytes.Equal(fast.Y, slow.Y) || !bytes.Equal(fast.Cb, slow.Cb) || !bytes.Equal(fast.Cr, slow.Cr) {
+		t.Error("fast path output differs from Set")
+	}
+	if y, _, _ := color.RGBToYCbCr(250, 200, 20); bytes.IndexByte(fast.Y, y) < 0 {
+		t.Error("text was not drawn")
+	}
+}
+
+func TestYCbCrDrawable(t *testing.T) {
+	m := image.NewYCbCr(image.Rect(2, 2, 7, 5), image.YCbCrSubsampleRatio444)
+	for _, plane := range [][]byte{m.Y, m.Cb, m.Cr} {
+		for i := range plane {
+			plane[i] = 0x80
+		}
+	}
+	d := &YCbCrDrawable{m}
+	f := tinyFont()
+	clr := color.YCbCr{0, 20, 30}
+	f.DrawString(d, 3, 2, "I", clr)
+	f.DrawString(d, 6, 4, "T", clr) // mostly outside the frame
+	luma := &image.Gray{Pix: m.Y, Stride: m.YStride, Rect: m.Rect}
+	if got, want := grayRows(luma), " ### |  #  | ####"; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	if c := m.YCbCrAt(4, 3); c != clr {
+		t.Errorf("text is %v, want %v", c, clr)
+	}
+	if c := m.YCbCrAt(3, 3); c != (color.YCbCr{0x80, 0x80, 0x80}) {
+		t.Errorf("background is %v", c)
+	}
+
+	d.Set(2, 2, color.White)
+	if c := m.YCbCrAt(2, 2); c != (color.YCbCr{0xff, 0x80, 0x80}) {
+		t.Errorf("white is %v", c)
+	}
+}
+
+func TestAnchorPlace(t *testing.T) {
+	r := image.Rect(10, 10, 110, 60)
+	size := image.Pt(20, 8)
+	for a, want := range map[Anchor]image.Point{
+		TopLeft:     {12, 12},
+		Center:      {50, 31},
+		BottomRight: {88, 50},
+		TopCenter:   {50, 12},
+		CenterLeft:  {12, 31},
+	} {
+		if got := a.Place(r, size, 2); got != want {
+			t.Errorf("anchor %d: got %v, want %v", a, got, want)
+		}
+	}
+}
+
+func TestDrawStringAnchored(t *testing.T) {
+	w := Font8x8.MeasureString("Hi") - Font8x8.Spacing()
+	for a, want := range map[Anchor]image.Point{
+		TopLeft:      {20, 10},
+		Center:       {20 - w/2, 6},
+		BottomRight:  {20 - w, 2},
+		BaselineLeft: {20, 10 - Font8x8.baseline()},
+	} {
+		sd := &StringDrawable{}
+		if got := Font8x8.DrawStringAnchored(sd, 20, 10, "Hi", nil, a); got != want {
+			t.Errorf("anchor %d: got %v, want %v", a, got, want)
+		}
+	}
+	if b := Font8x8.baseline(); b != 7 {
+		t.Errorf("got baseline %d, want 7", b)
+	}
+
+	// right anchored text ends exactly at x
+	data, cm, _ := Pack(3, 3, map[rune]map[int]string{
+		'I': {0: "XXX", 1: "XXX", 2: "XXX"},
+	})
+	f := NewPixFont(3, 3, cm, data)
+	f.SetVariableWidth(true)
+	img := image.NewAlpha(image.Rect(0, 0, 20, 3))
+	f.DrawStringAnchored(img, 10, 0, "II", color.Opaque, TopRight)
+	if img.AlphaAt(9, 1).A == 0 || img.AlphaAt(10, 1).A != 0 {
+		t.Errorf("right anchored text doesn't end at x: %v", img.Pix[20:40])
+	}
+}
+
+func TestRecognize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 30))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	DefaultFont.DrawString(img, 3, 2, "Total: 42 items", color.Black)
+	DefaultFont.DrawString(img, 3, 14, "ok", color.Black)
+
+	want := "Total: 42 items\nok"
+	if got := Recognize(img, DefaultFont); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// on a sub-image, with a space and a mark which isn't a glyph
+	f := tinyFont()
+	gray := grayImage(30, 10)
+	f.DrawString(gray, 12, 4, "I TI", color.Black)
+	gray.SetGray(29, 4, color.Gray{})
+	sub := gray.SubImage(image.Rect(10, 2, 30, 10))
+	if got, want := Recognize(sub, f), "I TI\uFFFD"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := Recognize(grayImage(5, 5), f); got != "" {
+		t.Errorf("blank image is %q", got)
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	b := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	if a.Hash() != b.Hash() {
+		t.Error("identical fonts have different hashes")
+	}
+	b.SetVariableWidth(true)
+	if a.Hash() == b.Hash() {
+		t.Error("changing variable width did not change the hash")
+	}
+
+	// the hash depends on the glyphs, not how they are stored
+	f := tinyFont()
+	runes, offsets := SortedCharMap(f.charmap)
+	for name, g := range map[string]*PixFont{
+		"sorted":     NewSortedPixFont(3, 3, runes, offsets, f.data),
+		"string":     NewStringPixFont(3, 3, f.charmap, DataString(f.data)),
+		"compressed": NewCompressedPixFont(3, 3, f.charmap, CompressData(3, f.data, 3)),
+	} {
+		if g.Hash() != f.Hash() {
+			t.Errorf("%s font has a different hash", name)
+		}
+	}
+	if h := f.Hash(); h != "7273c0b530ed3da868e92320a9abd8800c6fb43c4526c2733ef23782d2c482e7" {
+		t.Errorf("hash changed to %s", h)
+	}
+
+	data, cm, _ := Pack(3, 3, map[rune]map[int]string{
+		'I': {0: "XXX", 1: " X ", 2: "XXX"},
+		'T': {0: "XXX", 1: " X ", 2: "X  "},
+	})
+	if NewPixFont(3, 3, cm, data).Hash() == f.Hash() {
+		t.Error("changing a glyph did not change the hash")
+	}
+	data, cm, _ = Pack(3, 3, map[rune]map[int]string{
+		'I': {0: "XXX", 1: " X ", 2: "XXX"},
+		'U': {0: "XXX", 1: " X ", 2: " X "},
+	})
+	if NewPixFont(3, 3, cm, data).Hash() == f.Hash() {
+		t.Error("changing a rune did not change the hash")
+	}
+}
+
+func TestCompressedPixFont(t *testing.T) {
+	cd := CompressData(8, Font8x8.data, 64)
+	if len(cd.Blocks) < 2 {
+		t.Fatalf("expected several blocks, got %d", len(cd.Blocks))
+	}
+	f := NewCompressedPixFont(8, 8, Font8x8.charmap, cd)
+
+	want, got := &StringDrawable{}, &StringDrawable{}
+	Font8x8.DrawString(want, 0, 0, "Hello", nil)
+	f.DrawString(got, 0, 0, "Hello", nil)
+	if got.String() != want.String() {
+		t.Errorf("compressed font drew\n%s\nwant\n%s", got, want)
+	}
+	if f.Hash() != NewPixFont(8, 8, Font8x8.charmap, Font8x8.data).Hash() {
+		t.Error("compressed font has a different hash")
+	}
+}
+
+func TestSortedPixFont(t *testing.T) {
+	runes, offsets := SortedCharMap(Font8x8.charmap)
+	f := NewSortedPixFont(8, 8, runes, offsets, Font8x8.data)
+
+	want, got := &StringDrawable{}, &StringDrawable{}
+	Font8x8.DrawString(want, 0, 0, "Hello \u00e9\uffff", nil)
+	f.DrawString(got, 0, 0, "Hello \u00e9\uffff", nil)
+	if got.String() != want.String() {
+		t.Errorf("sorted font drew\n%s\nwant\n%s", got, want)
+	}
+	if f.Hash() != NewPixFont(8, 8, Font8x8.charmap, Font8x8.data).Hash() {
+		t.Error("sorted font has a different hash")
+	}
+	if f.HasGlyph(0x10ffff) || !f.HasGlyph(runes[0]) || !f.HasGlyph(runes[len(runes)-1]) {
+		t.Error("HasGlyph is wrong at the ends of the sorted runes")
+	}
+}
+
+func TestStringPixFont(t *testing.T) {
+	f := NewStringPixFont(8, 8, Font8x8.charmap, DataString(Font8x8.data))
+	want, got := &StringDrawable{}, &StringDrawable{}
+	Font8x8.DrawString(want, 0, 0, "Hello", nil)
+	f.DrawString(got, 0, 0, "Hello", nil)
+	if got.String() != want.String() {
+		t.Errorf("string font drew\n%s\nwant\n%s", got, want)
+	}
+	if f.Hash() != NewPixFont(8, 8, Font8x8.charmap, Font8x8.data).Hash() {
+		t.Error("string font has a different hash")
+	}
+}
+
+func TestTextWindow(t *testing.T) {
+	r := image.Rect(2, 3, 50, 30) // 3 rows of 8x8 text, with 3 rows of pixels left over
+	fast := image.NewRGBA(image.Rect(0, 0, 60, 40))
+	slow := image.NewRGBA(fast.Rect)
+	fw := NewTextWindow(fast, r, Font8x8, color.White, color.Black)
+	sw := NewTextWindow(slowDrawable{slow}, r, Font8x8, color.White, color.Black)
+	for _, s := range []string{"one", "two", "three", "four", "five, a long line"} {
+		fw.AppendLine(s)
+		sw.AppendLine(s)
+	}
+	if got := strings.Join(fw.Lines(), ","); got != "three,four,five, a long line" {
+		t.Errorf("got lines %q", got)
+	}
+
+	want := image.NewRGBA(fast.Rect)
+	fillRect(setter(want, color.Black), r, false)
+	for i, s := range fw.Lines() {
+		Font8x8.DrawString(&clipDrawable{want, r}, r.Min.X, r.Min.Y+8*i, s, color.White)
+	}
+	if !bytes.Equal(fast.Pix, want.Pix) {
+		t.Error("shifted window differs from the expected lines")
+	}
+	if !bytes.Equal(slow.Pix, want.Pix) {
+		t.Error("redrawn window differs from the expected lines")
+	}
+}
+
+func TestIndexToX(t *testing.T) {
+	f := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	f.SetCombining('\u0301')
+	s := "a\u0301bc"
+	adv := 8 + Spacing
+	for i, want := range []int{0, 0, 0, adv, 2 * adv, 3 * adv, 3 * adv} {
+		if got := f.IndexToX(s, i); got != want {
+			t.Errorf("IndexToX(%d) = %d, want %d", i, got, want)
+		}
+	}
+	for px, want := range map[int]int{-5: 0, 3: 0, adv - 3: 3, adv + 1: 3, 2*adv + 5: 5, 100: 5} {
+		if got := f.XToIndex(s, px); got != want {
+			t.Errorf("XToIndex(%d) = %d, want %d", px, got, want)
+		}
+	}
+}
+
+func TestDrawHighlight(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 10))
+	adv := 8 + Spacing
+	r := Font8x8.DrawHighlight(img, 2, 1, "abc", 1, 2, color.White)
+	if want := image.Rect(2+adv, 1, 2+2*adv, 9); r != want {
+		t.Errorf("got highlight %v, want %v", r, want)
+	}
+	if img.RGBAAt(2+adv, 1).A == 0 || img.RGBAAt(1+adv, 1).A != 0 || img.RGBAAt(2+adv, 9).A != 0 {
+		t.Error("highlight was not drawn in its rectangle")
+	}
+}
+
+func TestTileString(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 40, 40))
+	r := image.Rect(0, 0, 30, 30)
+	Font8x8.TileString(img, r, "x", color.White, image.Pt(2, 2), 5)
+	stepX, stepY := Font8x8.MeasureString("x")+2, 8+2
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			on := img.GrayAt(x, y).Y != 0
+			if !(image.Point{x, y}.In(r)) {
+				if on {
+					t.Fatalf("pixel %d,%d drawn outside the rectangle", x, y)
+				}
+				continue
+			}
+			if x+stepX < 30 && on != (img.GrayAt(x+stepX, y).Y != 0) {
+				t.Fatalf("pixel %d,%d does not repeat across the row", x, y)
+			}
+			if x+5 < 30 && y+stepY < 30 && on != (img.GrayAt(x+5, y+stepY).Y != 0) {
+				t.Fatalf("pixel %d,%d is not staggered in the next row", x, y)
+			}
+		}
+	}
+}
+
+func TestDrawStringDebug(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 12))
+	red, white := color.RGBA{255, 0, 0, 255}, color.RGBA{255, 255, 255, 255}
+	x := Font8x8.DrawStringOptions(img, 1, 1, "ab", white, &DrawOptions{Debug: red})
+	if want := 1 + Font8x8.MeasureString("ab"); x != want {
+		t.Errorf("got x %d, want %d", x, want)
+	}
+	for _, pt := range []image.Point{{0, 0}, {x, 9}, {1, 1}, {8, 8}, {1 + 8 + Spacing + 7, 8}} {
+		if c := img.RGBAAt(pt.X, pt.Y); c != red {
+			t.Errorf("pixel %v is %v, want the debug color", pt, c)
+		}
+	}
+}
+
+func TestDrawStringScaled(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	white := color.RGBA{255, 255, 255, 255}
+	x := Font8x8.DrawStringOptions(img, 0, 0, "/", white, &DrawOptions{Scale: 2, Smooth: true})
+	if want := 2 * Font8x8.MeasureString("/"); x != want {
+		t.Errorf("got x %d, want %d", x, want)
+	}
+	m := Font8x8.GlyphMask('/')
+	full, half := 0, 0
+	for yy := 0; yy < 16; yy++ {
+		for xx := 0; xx < 16; xx++ {
+			a := img.RGBAAt(xx, yy).A
+			if on := m.AlphaAt(xx/2, yy/2).A != 0; on != (a == 0xff) {
+				t.Fatalf("pixel %d,%d has alpha %d", xx, yy, a)
+			}
+			if a == 0xff {
+				full++
+			} else if a != 0 {
+				half++
+			}
+		}
+	}
+	if full == 0 || half == 0 {
+		t.Errorf("got %d opaque and %d smoothed pixels", full, half)
+	}
+}
+
+func TestDrawStringSubpixel(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 8))
+	white := color.RGBA{255, 255, 255, 255}
+	x := Font8x8.DrawStringOptions(img, 0, 0, "|", white, &DrawOptions{Subpixel: true})
+	if want := (Font8x8.MeasureString("|") + 2) / 3; x != want {
+		t.Errorf("got x %d, want %d", x, want)
+	}
+	m := Font8x8.GlyphMask('|')
+	for yy := 0; yy < 8; yy++ {
+		for col := 0; col < 9; col++ {
+			c := img.RGBAAt(col/3, yy)
+			v := [3]uint8{c.R, c.G, c.B}[col%3]
+			if on := m.AlphaAt(col, yy).A != 0; on != (v == 0xff) {
+				t.Fatalf("column %d of row %d has value %d", col, yy, v)
+			}
+		}
+	}
+}
+
+func TestDrawStringFill(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	fill := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	fill.SetRGBA(0, 0, red)
+	fill.SetRGBA(1, 0, blue)
+
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	Font8x8.DrawStringOptions(img, 1, 1, "#", color.White, &DrawOptions{Fill: fill})
+	m := Font8x8.GlyphMask('#')
+	for yy := 0; yy < 8; yy++ {
+		for xx := 0; xx < 8; xx++ {
+			want := color.RGBA{}
+			if m.AlphaAt(xx, yy).A != 0 {
+				want = [2]color.RGBA{red, blue}[xx%2]
+			}
+			if got := img.RGBAAt(1+xx, 1+yy); got != want {
+				t.Fatalf("pixel %d,%d is %v, want %v", xx, yy, got, want)
+			}
+		}
+	}
+
+	img = image.NewRGBA(image.Rect(0, 0, 20, 10))
+	Font8x8.DrawStringOptions(img, 0, 0, "##", color.White, &DrawOptions{
+		ColorFunc: func(x, y, i int) color.Color { return [2]color.RGBA{red, blue}[i] },
+	})
+	adv := Font8x8.MeasureString("#")
+	for xx := 0; xx < 2*adv; xx++ {
+		if c := img.RGBAAt(xx, 2); c.A != 0 && c != [2]color.RGBA{red, blue}[xx/adv] {
+			t.Fatalf("pixel %d,2 of rune %d is %v", xx, xx/adv, c)
+		}
+	}
+}
+
+func TestDrawStringComposite(t *testing.T) {
+	blue := color.RGBA{0, 0, 255, 255}
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff // white
+	}
+	half := color.RGBA{128, 0, 0, 128} // premultiplied, half-transparent red
+	Font8x8.DrawStringOptions(img, 0, 0, "\u2588", half, &DrawOptions{Blend: true})
+	if got, want := img.RGBAAt(3, 3), (color.RGBA{255, 127, 127, 255}); got != want {
+		t.Errorf("blended pixel is %v, want %v", got, want)
+	}
+
+	// key out the left half, which is then left alone
+	for yy := 0; yy < 8; yy++ {
+		for xx := 0; xx < 4; xx++ {
+			img.SetRGBA(xx, yy, blue)
+		}
+	}
+	Font8x8.DrawStringOptions(img, 0, 0, "\u2588", color.Black, &DrawOptions{ColorKey: blue})
+	if got := img.RGBAAt(1, 3); got != blue {
+		t.Errorf("keyed pixel is %v, want %v", got, blue)
+	}
+	if got, want := img.RGBAAt(5, 3), (color.RGBA{0, 0, 0, 255}); got != want {
+		t.Errorf("unkeyed pixel is %v, want %v", got, want)
+	}
+}
+
+func TestDrawStringGlyphHook(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 12))
+	var runes []rune
+	x := Font8x8.DrawStringOptions(img, 0, 2, "|||", color.White, &DrawOptions{
+		Glyph: func(g *GlyphDraw) {
+			runes = append(runes, g.Rune)
+			switch g.Index {
+			case 0:
+				g.Y -= 2
+			case 1:
+				g.Skip = true
+			case 2:
+				g.Color = red
+			}
+		},
+	})
+	if x != Font8x8.MeasureString("|||") || string(runes) != "|||" {
+		t.Fatalf("got x %d and runes %q", x, string(runes))
+	}
+
+	want := image.NewRGBA(img.Rect)
+	adv := Font8x8.MeasureString("|")
+	Font8x8.DrawString(want, 0, 0, "|", color.White)
+	Font8x8.DrawString(want, 2*adv, 2, "|", red)
+	if !bytes.Equal(img.Pix, want.Pix) {
+		t.Error("hooked glyphs were not moved, skipped and recolored")
+	}
+}
+
+func TestDrawStringCycled(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 80, 8))
+	Font8x8.DrawStringCycled(img, 0, 0, "#######", Rainbow)
+	adv := Font8x8.MeasureString("#")
+	for i := 0; i < 7; i++ {
+		m := Font8x8.GlyphMask('#')
+		want := color.RGBAModel.Convert(Rainbow[i%len(Rainbow)])
+		for xx := 0; xx < 8; xx++ {
+			if m.AlphaAt(xx, 2).A != 0 && img.At(i*adv+xx, 2) != want {
+				t.Fatalf("rune %d is %v, want %v", i, img.At(i*adv+xx, 2), want)
+			}
+		}
+	}
+
+	img = image.NewRGBA(image.Rect(0, 0, 80, 8))
+	if got, want := Font8x8.DrawStringCycled(img, 3, 0, "##", nil), 3+2*adv; got != want {
+		t.Errorf("empty palette advances to %d, want %d", got, want)
+	}
+	for _, v := range img.Pix {
+		if v != 0 {
+			t.Fatal("text was drawn with an empty palette")
+		}
+	}
+}
+
+func TestUpgradeFont(t *testing.T) {
+	old := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	old.SetVariableWidth(true)
+	for _, sp := range []int{-1, 0, 3} {
+		old.SetSpacing(sp)
+		v2, err := UpgradeFont(old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v2.Spacing() != old.Spacing() {
+			t.Errorf("spacing %d: upgraded font has spacing %d", old.Spacing(), v2.Spacing())
+		}
+		for _, s := range []string{"Hello, World!", "iIl1 ☃"} {
+			want, got := &StringDrawable{}, &StringDrawable{}
+			wx := old.DrawString(want, 0, 0, s, nil)
+			gx := v2.DrawString(got, 0, 0, s, nil)
+			if wx != gx || got.String() != want.String() {
+				t.Errorf("spacing %d, %q: upgraded font drew to %d\n%s\nwant %d\n%s", sp, s, gx, got, wx, want)
+			}
+			if w := v2.MeasureString(s); w != wx {
+				t.Errorf("spacing %d, %q: upgraded font measures %d, want %d", sp, s, w, wx)
+			}
+		}
+		if len(v2.Runes()) != len(old.Runes()) {
+			t.Errorf("got %d runes, want %d", len(v2.Runes()), len(old.Runes()))
+		}
+	}
+
+	old.SetSpacing(300)
+	if _, err := UpgradeFont(old); err == nil {
+		t.Error("no error for advances too large to pack")
+	}
+}
+
+func TestPackV2(t *testing.T) {
+	d := map[rune]map[int]string{'a': {0: "X"}, 'b': {0: " X"}}
+	data, cm, err := PackV2(64, 1, d, map[rune]int{'a': 255})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewPixFontV2(64, 1, cm, data)
+	if _, w := f.MeasureRune('a'); w != 255 {
+		t.Errorf("a advances %d, want 255", w)
+	}
+	if _, w := f.MeasureRune('b'); w != 64 {
+		t.Errorf("b advances %d, want the cell width 64", w)
+	}
+	for name, tc := range map[string]struct {
+		w        int
+		advances map[rune]int
+	}{
+		"wide":             {65, nil},
+		"large advance":    {8, map[rune]int{'a': 256}},
+		"negative advance": {8, map[rune]int{'b': -1}},
+	} {
+		if _, _, err := PackV2(tc.w, 1, d, tc.advances); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestArabicShaping(t *testing.T) {
+	d := make(map[rune]map[int]string)
+	for _, r := range []rune{0x0628, 0xfe8f, 0xfe90, 0xfe91, 0xfe92, 0x0627, 0xfe8e, 0x0644, 0xfefb} {
+		d[r] = map[int]string{0: "X"}
+	}
+	data, cm, _ := Pack(1, 1, d)
+	f := NewPixFont(1, 1, cm, data)
+	f.SetArabicShaping(true)
+	for s, want := range map[string]string{
+		"\u0628\u0628\u0628":  "\ufe91\ufe92\ufe90",
+		"\u0628\u0627 \u0628": "\ufe91\ufe8e \ufe8f",
+		"\u0628\u064e\u0628":  "\ufe91\u064e\ufe90", // harakat are transparent
+		"\u0627\u0628":        "\u0627\ufe8f",       // alef doesn't join the next letter
+		"\u0644\u0627":        "\ufefb",
+		"\u062a\u0628":        "\u062a\ufe90", // no glyphs for teh
+	} {
+		if got := f.prepare(s); got != want {
+			t.Errorf("%+q: got %+q, want %+q", s, got, want)
+		}
+	}
+}
+
+func TestThaiMarks(t *testing.T) {
+	data, cm, _ := Pack(1, 6, map[rune]map[int]string{
+		0x0e01: {5: "X"}, // ko kai
+		0x0e34: {2: "X"}, // sara i
+		0x0e48: {2: "X"}, // mai ek
+		0x0e38: {4: "X"}, // sara u
+	})
+	f := NewPixFont(1, 6, cm, data)
+	f.SetThaiMarks(2)
+	for _, size := range []int{DefaultCacheSize, 0} {
+		f.SetCacheSize(size)
+		sd := &StringDrawable{}
+		f.DrawString(sd, 0, 0, "กิุ่ก่", nil)
+		// the tone mark stacks above the vowel in the first cluster only
+		if want := "X\n\nX X\n\nX\nX X\n"; sd.String() != want {
+			t.Errorf("cache size %d: got %q, want %q", size, sd.String(), want)
+		}
+	}
+}
+
+func TestHangulComposition(t *testing.T) {
+	data, cm, _ := Pack(3, 3, map[rune]map[int]string{
+		0x1100: {0: "X"},   // kiyeok
+		0x1161: {1: "  X"}, // a
+		0x11a8: {2: "XXX"}, // final kiyeok
+	})
+	f := NewPixFont(3, 3, cm, data)
+	if ok, _ := f.MeasureRune('각'); ok {
+		t.Error("syllable was composed without enabling composition")
+	}
+	f.SetHangulComposition(true)
+	for s, want := range map[string]string{
+		"가": "X\n  X\n",
+		"각": "X\n  X\nXXX\n",
+	} {
+		sd := &StringDrawable{}
+		f.DrawString(sd, 0, 0, s, nil)
+		if sd.String() != want {
+			t.Errorf("%s: got %q, want %q", s, sd.String(), want)
+		}
+	}
+	if ok, _ := f.MeasureRune('난'); ok {
+		t.Error("syllable was composed from missing jamo")
+	}
+}
+
+func TestCJKGrid(t *testing.T) {
+	f := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	f.SetVariableWidth(true)
+	f.SetCJKGrid(6)
+	for s, want := range map[string]int{
+		"ab":    12,
+		"日本":    24,
+		"a日b本c": 42,
+		"ｗ":     12, // fullwidth
+	} {
+		if got := f.MeasureString(s); got != want {
+			t.Errorf("%q: got width %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestScale2x(t *testing.T) {
+	big, err := Font8x8.Scale2x()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if big.GetWidth() != 16 || big.GetHeight() != 16 {
+		t.Fatalf("got %dx%d font, want 16x16", big.GetWidth(), big.GetHeight())
+	}
+
+	white := color.RGBA{255, 255, 255, 255}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	x := Font8x8.DrawStringOptions(img, 0, 0, "/", white, &DrawOptions{Scale: 2, Scale2x: true})
+	if want := 2 * Font8x8.MeasureString("/"); x != want {
+		t.Errorf("got x %d, want %d", x, want)
+	}
+	m, orig := big.GlyphMask('/'), Font8x8.GlyphMask('/')
+	changed := false
+	for yy := 0; yy < 16; yy++ {
+		for xx := 0; xx < 16; xx++ {
+			on := m.AlphaAt(xx, yy).A != 0
+			if drawn := img.RGBAAt(xx, yy).A != 0; drawn != on {
+				t.Fatalf("pixel %d,%d drawn %v, but %v in the scaled font", xx, yy, drawn, on)
+			}
+			if on != (orig.AlphaAt(xx/2, yy/2).A != 0) {
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		t.Error("Scale2x drew the same as plain scaling")
+	}
+}
+
+func TestStringDrawableOptions(t *testing.T) {
+	sd := &StringDrawable{On: '█', Off: '.', DoubleWidth: true}
+	sd.Set(1, 0, nil)
+	sd.Set(0, 1, nil)
+	if got, want := sd.String(), "..██\n██\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	sd = NewFixedStringDrawable(3, 2)
+	DefaultFont.DrawString(sd, 0, 0, "A", nil)
+	sd.Reset()
+	sd.Set(1, 0, nil)
+	sd.Set(5, 0, nil)
+	sd.Set(0, 3, nil)
+	if got, want := sd.String(), " X \n   \n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStringDrawableRegion(t *testing.T) {
+	sd := &StringDrawable{}
+	DefaultFont.DrawString(sd, 0, 0, "AB", nil)
+	var buf bytes.Buffer
+	n, err := sd.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) || buf.String() != sd.String() {
+		t.Errorf("WriteTo wrote %d bytes, %v:\n%s\nwant\n%s", n, err, buf.String(), sd.String())
+	}
+
+	want := &StringDrawable{}
+	DefaultFont.DrawString(want, 0, 0, "B", nil)
+	x := DefaultFont.MeasureString("A")
+	got := sd.Region(image.Rect(x, 0, x+8, 8))
+	if w := want.Region(image.Rect(0, 0, 8, 8)); got != w || len(got) != 8*9 {
+		t.Errorf("got\n%s\nwant\n%s", got, w)
+	}
+}
+
+func TestMeasureStringWrapped(t *testing.T) {
+	s := "The quick brown fox\njumps"
+	lines, w, h := DefaultFont.MeasureStringWrapped(s, 60)
+	if want := []string{"The", "quick", "brown", "fox", "jumps"}; strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+
+	sd := &StringDrawable{}
+	if dh := DefaultFont.DrawStringWrapped(sd, 0, 0, s, 60, nil); h != dh {
+		t.Errorf("got height %d, DrawStringWrapped drew %d", h, dh)
+	}
+	if want := 5*8 + 4*DefaultFont.Spacing(); w != want {
+		t.Errorf("got width %d, want five cells and the spacing between them, %d", w, want)
+	}
+
+	// with variable widths, the width is as wide as the pixels drawn
+	f := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	f.SetVariableWidth(true)
+	_, w, _ = f.MeasureStringWrapped(s, 60)
+	a := image.NewAlpha(image.Rect(0, 0, 100, 100))
+	f.DrawStringWrapped(a, 0, 0, s, 60, color.Opaque)
+	drawnW := 0
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if a.AlphaAt(x, y).A != 0 && x >= drawnW {
+				drawnW = x + 1
+			}
+		}
+	}
+	if w > 60 || w != drawnW {
+		t.Errorf("got width %d, want the drawn width %d, at most 60", w, drawnW)
+	}
+
+	// the spacing after the last character of a line doesn't need to fit
+	fit := DefaultFont.MeasureString("ab cd") - DefaultFont.Spacing()
+	if lines, _, _ := DefaultFont.MeasureStringWrapped("ab cd", fit); len(lines) != 1 {
+		t.Errorf("text as wide as the limit is wrapped to %q", lines)
+	}
+	if lines, _, _ := DefaultFont.MeasureStringWrapped("ab cd", fit-1); len(lines) != 2 {
+		t.Errorf("text wider than the limit is wrapped to %q", lines)
+	}
+}
+
+func TestWrapSoftHyphenNoBreakSpace(t *testing.T) {
+	f := Font8x8
+	lines, _, _ := f.MeasureStringWrapped("un\u00adbreak\u00adable word", f.MeasureString("unbreak-"))
+	if want := []string{"unbreak-", "able", "word"}; strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+	lines, _, _ = f.MeasureStringWrapped("a b\u00a0c", f.MeasureString("a b"))
+	if want := []string{"a", "b\u00a0c"}; strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("got lines %q, want %q", lines, want)
+	}
+
+	// unbroken, a soft hyphen is invisible and a no-break space is a space
+	for s, want := range map[string]string{"a\u00adb": "ab", "a\u00a0b": "a b"} {
+		a := image.NewAlpha(image.Rect(0, 0, 32, 8))
+		b := image.NewAlpha(image.Rect(0, 0, 32, 8))
+		if got, w := f.DrawString(a, 0, 0, s, color.Opaque), f.DrawString(b, 0, 0, want, color.Opaque); got != w {
+			t.Errorf("%q advances %d, want %d", s, got, w)
+		}
+		if !bytes.Equal(a.Pix, b.Pix) {
+			t.Errorf("%q is drawn differently from %q", s, want)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := Font8x8.Metrics()
+	want := FontMetrics{Ascent: 7, Descent: 1, CapHeight: 7, XHeight: 5}
+	if m != want {
+		t.Errorf("got %+v, want %+v", m, want)
+	}
+}
+
+func TestCoverageRanges(t *testing.T) {
+	cm := map[rune]uint16{'a': 0, 'b': 0, 'c': 0, 'x': 0, 'z': 0}
+	f := NewPixFont(8, 1, cm, []uint32{0})
+	want := []RuneRange{{'a', 'c'}, {'x', 'x'}, {'z', 'z'}}
+	got := f.CoverageRanges()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+	if !f.HasGlyph('b') || f.HasGlyph('y') {
+		t.Error("HasGlyph is wrong")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	cm := map[rune]uint16{'a': 0, 'e': 0, '?': 0, 0x0301: 0}
+	f := NewPixFont(8, 1, cm, []uint32{0})
+	if got, want := f.Sanitize("a\u00e9 z\n"), "ae \n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	f.SetCombining(0x0301)
+	f.SetReplacementRune('?')
+	if got, want := f.Sanitize("a\u00e9 z"), "ae\u0301 ?"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestShortcodes(t *testing.T) {
+	sc := NewShortcodes()
+	sc.Add("block", '\u2588')
+	ok := sc.AddGlyph("ok", "      X", "     X", "X   X", " X X", "  X")
+	if got, want := sc.Expand("a:b:block: :nope: :ok::"), "a:b\u2588 :nope: "+string(ok)+":"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	f, err := sc.WithGlyphs(Font8x8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Hash() == Font8x8.Hash() || !f.HasGlyph(ok) || !f.HasGlyph('\u2588') {
+		t.Error("custom glyph was not added")
+	}
+	want, got := &StringDrawable{}, &StringDrawable{}
+	Font8x8.DrawString(want, 0, 0, "Hello", nil)
+	f.DrawString(got, 0, 0, "Hello", nil)
+	if got.String() != want.String() {
+		t.Errorf("font with glyphs drew\n%s\nwant\n%s", got, want)
+	}
+
+	sc.AddGlyph("wide", "XXXXXXXXX")
+	if _, err := sc.WithGlyphs(Font8x8); err == nil {
+		t.Error("glyph wider than the font was accepted")
+	}
+}
+
+func TestDrawStringAutoContrast(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 24, 8))
+	for i := 0; i < 8*12; i++ {
+		img.Pix[i%12+i/12*24] = 0xff // light left half
+	}
+	bw := &DrawOptions{AutoContrast: ContrastBlackWhite}
+	Font8x8.DrawStringOptions(img, 0, 0, "\u2588", color.White, bw)
+	if got := img.GrayAt(3, 3).Y; got != 0 {
+		t.Errorf("text on light background is %d, want black", got)
+	}
+	Font8x8.DrawStringOptions(img, 16, 0, "\u2588", color.Black, bw)
+	if got := img.GrayAt(19, 3).Y; got != 0xff {
+		t.Errorf("text on dark background is %d, want white", got)
+	}
+
+	img = image.NewGray(image.Rect(0, 0, 12, 12))
+	Font8x8.DrawStringOptions(img, 2, 2, "\u2588", color.Black, &DrawOptions{AutoContrast: ContrastOutline})
+	if got := img.GrayAt(1, 5).Y; got != 0xff {
+		t.Errorf("outline is %d, want white", got)
+	}
+	if got := img.GrayAt(5, 5).Y; got != 0 {
+		t.Errorf("text is %d, want black", got)
+	}
+
+	// the outline stays white when the text is colored by ColorFunc
+	img = image.NewGray(image.Rect(0, 0, 12, 12))
+	Font8x8.DrawStringOptions(img, 2, 2, "\u2588", color.Black, &DrawOptions{
+		AutoContrast: ContrastOutline,
+		ColorFunc:    func(x, y, i int) color.Color { return color.Gray{0x40} },
+	})
+	if got := img.GrayAt(1, 5).Y; got != 0xff {
+		t.Errorf("outline with ColorFunc is %d, want white", got)
+	}
+	if got := img.GrayAt(5, 5).Y; got != 0x40 {
+		t.Errorf("text with ColorFunc is %d, want 0x40", got)
+	}
+}
+
+func TestTrackUsage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 80, 8))
+	Font8x8.TrackUsage(true)
+	defer Font8x8.TrackUsage(false)
+	Font8x8.MeasureString("measured only")
+	Font8x8.DrawString(img, 0, 0, "HELLO", color.Black)
+	Font8x8.DrawStringOptions(img, 0, 0, "A", color.Black, &DrawOptions{Scale: 2})
+
+	if got, want := string(Font8x8.UsedRunes()), "AEHLO"; got != want {
+		t.Errorf("used runes are %q, want %q", got, want)
+	}
+	if got, want := Font8x8.UsedRanges(), "U+0041,U+0045,U+0048,U+004C,U+004F"; got != want {
+		t.Errorf("used ranges are %q, want %q", got, want)
+	}
+
+	sub, err := Font8x8.Subset(Font8x8.UsedRunes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(sub.Runes()), "AEHLO"; got != want {
+		t.Errorf("subset runes are %q, want %q", got, want)
+	}
+	if got, want := sub.MeasureString("HELLO"), Font8x8.MeasureString("HELLO"); got != want {
+		t.Errorf("subset measures %d, want %d", got, want)
+	}
+	for i, v := range sub.GlyphMask('H').Pix {
+		if v != Font8x8.GlyphMask('H').Pix[i] {
+			t.Fatalf("subset glyph for H differs at %d", i)
+		}
+	}
+}
+
+func TestTrackUsageProbes(t *testing.T) {
+	f := NewPixFont(8, 8, Font8x8.charmap, Font8x8.data)
+	f.SetReplacementRune('?')
+	f.TrackUsage(true)
+	img := image.NewRGBA(image.Rect(0, 0, 80, 8))
+	f.GlyphMask('M')
+	f.DrawStringAnchored(img, 40, 7, "ab", color.Black, BaselineRight)
+	f.DrawString(img, 0, 0, "c\u0e01", color.Black)
+	if got, want := string(f.UsedRunes()), "abc\u0e01"; got != want {
+		t.Errorf("used runes are %q, want %q", got, want)
+	}
+
+	data, cm, _ := Pack(3, 3, map[rune]map[int]string{
+		0x1100: {0: "X"},   // kiyeok
+		0x1161: {1: "  X"}, // a
+		0x11a8: {2: "XXX"}, // final kiyeok
+	})
+	h := NewPixFont(3, 3, cm, data)
+	h.SetHangulComposition(true)
+	h.TrackUsage(true)
+	h.DrawString(img, 0, 0, "각", color.Black)
+	if got, want := string(h.UsedRunes()), "각"; got != want {
+		t.Errorf("used runes are %q, want %q", got, want)
+	}
+	sub, err := h.Subset(h.UsedRunes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := &StringDrawable{}
+	sub.DrawString(sd, 0, 0, "각", nil)
+	if want := "X\n  X\nXXX\n"; sd.String() != want {
+		t.Errorf("subset syllable is %q, want %q", sd.String(), want)
+	}
+}
+
+func TestSetAliases(t *testing.T) {
+	f := NewPixFont(8, 8, Font8x8.charmap, Font8x8.data)
+	if f.HasGlyph('’') {
+		t.Fatal("Font8x8 has a glyph for U+2019, choose another rune")
+	}
+	f.SetAliases(TypographicAliases())
+	if !f.HasGlyph('’') {
+		t.Error("aliased rune has no glyph")
+	}
+	if got, want := f.MeasureString("it’s"), f.MeasureString("it's"); got != want {
+		t.Errorf("aliased string measures %d, want %d", got, want)
+	}
+	a := image.NewAlpha(image.Rect(0, 0, 40, 8))
+	b := image.NewAlpha(image.Rect(0, 0, 40, 8))
+	f.DrawString(a, 0, 0, "“A—B”", color.Opaque)
+	f.DrawString(b, 0, 0, "\"A-B\"", color.Opaque)
+	if !bytes.Equal(a.Pix, b.Pix) {
+		t.Error("aliased string is drawn differently from its ASCII equivalent")
+	}
+
+	f.SetAliases(nil)
+	if f.HasGlyph('’') {
+		t.Error("alias is kept after SetAliases(nil)")
+	}
+}
+
+func TestScanlines(t *testing.T) {
+	s := "Hi!"
+	img := image.NewAlpha(image.Rect(0, 0, Font8x8.MeasureString(s), 8))
+	Font8x8.DrawString(img, 0, 0, s, color.Opaque)
+
+	got := image.NewAlpha(img.Rect)
+	rows := 0
+	Font8x8.Scanlines(s, func(y int, spans []Span) {
+		if y != rows {
+			t.Errorf("got row %d, want %d", y, rows)
+		}
+		rows++
+		for i, sp := range spans {
+			if sp.X0 >= sp.X1 || (i > 0 && spans[i-1].X1 >= sp.X0) {
+				t.Errorf("row %d has bad spans %v", y, spans)
+			}
+			for x := sp.X0; x < sp.X1; x++ {
+				got.SetAlpha(x, y, color.Alpha{0xff})
+			}
+		}
+	})
+	if rows != 8 {
+		t.Errorf("got %d rows, want 8", rows)
+	}
+	if !bytes.Equal(got.Pix, img.Pix) {
+		t.Error("scanlines differ from DrawString")
+	}
+}
+
+func TestDrawStringBox(t *testing.T) {
+	f := Font8x8
+	box := image.Rect(10, 10, 50, 26)
+	bounds := func(s string, overflow Overflow) (image.Rectangle, image.Rectangle) {
+		img := image.NewAlpha(image.Rect(0, 0, 80, 40))
+		r := f.DrawStringBox(img, box, s, color.Opaque, TopLeft, overflow)
+		ink := image.Rectangle{}
+		for y := 0; y < 40; y++ {
+			for x := 0; x < 80; x++ {
+				if img.AlphaAt(x, y).A != 0 {
+					ink = ink.Union(image.Rect(x, y, x+1, y+1))
+				}
+			}
+		}
+		return r, ink
+	}
+
+	for _, overflow := range []Overflow{OverflowClip, OverflowWrap, OverflowShrink, OverflowEllipsis} {
+		if r, ink := bounds("too long for the box", overflow); !ink.In(box) || !r.In(box) {
+			t.Errorf("overflow %d draws %v and returns %v outside of %v", overflow, ink, r, box)
+		}
+	}
+	if r, _ := bounds("ab cd", OverflowWrap); r.Dy() != 16 {
+		t.Errorf("wrapped text is %d pixels high, want 16", r.Dy())
+	}
+	if r, _ := bounds("ab", OverflowShrink); r.Dy() != 16 {
+		t.Errorf("shrunk text is %d pixels high, want 16", r.Dy())
+	}
+	if got, want := f.ellipsize("too long for the box", 40, 1), "t..."; got != want {
+		t.Errorf("ellipsized text is %q, want %q", got, want)
+	}
+	if got := f.ellipsize("fits", 40, 1); got != "fits" {
+		t.Errorf("ellipsized text that fits is %q", got)
+	}
+}
+
+func TestStringRuns(t *testing.T) {
+	s := "Go!"
+	img := image.NewAlpha(image.Rect(0, 0, Font8x8.MeasureString(s), 8))
+	Font8x8.DrawString(img, 0, 0, s, color.Opaque)
+
+	got := image.NewAlpha(img.Rect)
+	runs := Font8x8.StringRuns(s)
+	for i, r := range runs {
+		if i > 0 && (runs[i-1].Y > r.Y || runs[i-1].Y == r.Y && runs[i-1].X1 >= r.X0) {
+			t.Errorf("runs %v and %v are out of order", runs[i-1], r)
+		}
+		for x := r.X0; x < r.X1; x++ {
+			got.SetAlpha(x, r.Y, color.Alpha{0xff})
+		}
+	}
+	if !bytes.Equal(got.Pix, img.Pix) {
+		t.Error("runs differ from DrawString")
+	}
+}
+
+func TestScreen(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	s := NewScreen(Font8x8, 4, 2, color.White, color.Black)
+	s.WriteString("ab\tc\n")
+	if got := s.Cell(3, 0).Rune; got != 'c' {
+		t.Errorf("tab led to %q at column 3, want 'c'", got)
+	}
+	s.SetColors(red, nil)
+	s.Write([]byte("wxyz\xe2\x96")) // the start of U+2588
+	if x, y := s.Cursor(); x != 4 || y != 1 {
+		t.Errorf("cursor is at %d,%d, want 4,1 before wrapping", x, y)
+	}
+	s.Write([]byte("\x88"))
+	if got := s.Cell(0, 0); got.Rune != 'w' || got.FG != red {
+		t.Errorf("scrolled cell is %v, want red 'w'", got)
+	}
+	if got := s.Cell(0, 1).Rune; got != '█' {
+		t.Errorf("split rune is %q, want U+2588", got)
+	}
+
+	s.MoveCursor(0, 1)
+	img := s.Image()
+	if got, want := img.Bounds(), image.Rect(0, 0, 32, 16); got != want {
+		t.Fatalf("image is %v, want %v", got, want)
+	}
+	// the full block glyph under the cursor is drawn in the background color
+	if got := img.RGBAAt(4, 12); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("cursor cell is %v, want black", got)
+	}
+	if got := img.RGBAAt(4, 4); got != red {
+		t.Errorf("text is %v, want red", got)
+	}
+	s.ShowCursor(false)
+	if got := s.Image().RGBAAt(4, 12); got != red {
+		t.Errorf("cell without cursor is %v, want red", got)
+	}
+}
+
+func TestScreenNilColors(t *testing.T) {
+	s := NewScreen(Font8x8, 2, 1, nil, nil)
+	s.WriteString("a")
+	img := image.NewRGBA(s.Bounds())
+	s.Draw(img, 0, 0) // no colors to draw with, so nothing changes
+	for _, v := range img.Pix {
+		if v != 0 {
+			t.Fatal("screen without colors drew pixels")
+		}
+	}
+
+	red := color.RGBA{255, 0, 0, 255}
+	s.SetColors(red, nil)
+	s.WriteString("a")
+	s.ShowCursor(false)
+	img = s.Image()
+	if got := img.RGBAAt(12, 4); got != red {
+		t.Errorf("text is %v, want red", got)
+	}
+	if got := img.RGBAAt(8, 0); got != (color.RGBA{}) {
+		t.Errorf("background is %v, want the image showing through", got)
+	}
+}
+
+func TestScreenANSI(t *testing.T) {
+	s := NewScreen(Font8x8, 10, 1, color.White, color.Black)
+	s.SetANSI(true)
+	s.WriteString("\x1b[31ma\x1b[1mb\x1b[0;7mc\x1b[27;38;5;196;48;2;1;2;3md\x1b[")
+	s.WriteString("2Ke\x1b[m")
+	s.WriteString("f")
+
+	red, brightRed := color.RGBA{205, 0, 0, 255}, color.RGBA{255, 0, 0, 255}
+	want := []Cell{
+		{'a', red, nil},
+		{'b', brightRed, nil},
+		{'c', color.Black, color.White},
+		{'d', color.RGBA{255, 0, 0, 255}, color.RGBA{1, 2, 3, 255}},
+		{'e', color.RGBA{255, 0, 0, 255}, color.RGBA{1, 2, 3, 255}},
+		{'f', nil, nil},
+	}
+	for i, w := range want {
+		if got := s.Cell(i, 0); got != w {
+			t.Errorf("cell %d is %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestScreenANSIStrings(t *testing.T) {
+	s := NewScreen(Font8x8, 10, 1, color.White, color.Black)
+	s.SetANSI(true)
+	s.WriteString("\x1b]0;title\x07a")
+	s.WriteString("\x1b]2;tïtle\x1b")
+	s.WriteString("\\b\x1bPq#0\x1b\\c\x1b_x\x1b\\\x1b[31md")
+	want := "abcd"
+	for i, r := range want {
+		if got := s.Cell(i, 0).Rune; got != r {
+			t.Errorf("cell %d is %q, want %q", i, got, r)
+		}
+	}
+	if got := s.Cell(3, 0).FG; got != (color.RGBA{205, 0, 0, 255}) {
+		t.Errorf("color after control strings is %v, want red", got)
+	}
+	if got := s.Cell(4, 0).Rune; got != 0 {
+		t.Errorf("cell 4 is %q, want it empty", got)
+	}
+}
+
+func TestSpacing(t *testing.T) {
+	f := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	if got := f.MeasureString("ab"); got != 18 {
+		t.Errorf("default spacing: width is %d, want 18", got)
+	}
+	f.SetSpacing(0)
+	if got := f.MeasureString("ab"); got != 16 {
+		t.Errorf("SetSpacing(0): width is %d, want 16", got)
+	}
+	if got := Font8x8.MeasureString("ab"); got != 18 {
+		t.Errorf("SetSpacing changed another font: width is %d, want 18", got)
+	}
+
+	sp := 3
+	a, b := &StringDrawable{}, &StringDrawable{}
+	if x := f.DrawStringOptions(a, 0, 0, "ab", nil, &DrawOptions{Spacing: &sp}); x != 22 {
+		t.Errorf("DrawOptions.Spacing: x is %d, want 22", x)
+	}
+	f.SetSpacing(3)
+	f.DrawString(b, 0, 0, "ab", nil)
+	if a.String() != b.String() {
+		t.Errorf("DrawOptions.Spacing draws differently from SetSpacing")
+	}
+
+	f.SetSpacing(-1)
+	SetDefaultSpacing(2)
+	defer SetDefaultSpacing(1)
+	if got := f.MeasureString("ab"); got != 20 {
+		t.Errorf("SetDefaultSpacing(2): width is %d, want 20", got)
+	}
+	defer func(s int) { Spacing = s }(Spacing)
+	Spacing = 4
+	if got := f.MeasureString("ab"); got != 24 {
+		t.Errorf("deprecated Spacing = 4: width is %d, want 24", got)
+	}
+}
+
+func TestDefaultSpacingConcurrent(t *testing.T) {
+	f := NewPixFont(Font8x8.charWidth, Font8x8.charHeight, Font8x8.charmap, Font8x8.data)
+	const s = "Hello, world"
+	// each string must be laid out with one spacing or the other throughout
+	widths := map[int]bool{}
+	for _, sp := range []int{1, 2} {
+		SetDefaultSpacing(sp)
+		widths[f.MeasureString(s)] = true
+	}
+	defer SetDefaultSpacing(1)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				SetDefaultSpacing(1 + i%2)
+			}
+		}
+	}()
+	errs := make(chan string, 4)
+	var readers sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for i := 0; i < 500; i++ {
+				if w := f.MeasureString(s); !widths[w] {
+					errs <- "MeasureString mixed spacings"
+					return
+				}
+				if x := f.DrawString(&StringDrawable{}, 0, 0, s, nil); !widths[x] {
+					errs <- "DrawString mixed spacings"
+					return
+				}
+			}
+		}()
+	}
+	readers.Wait()
+	close(done)
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Error(e)
+	}
+}
+
+func TestPackTooManyGlyphs(t *testing.T) {
+	glyphs := func(n int) map[rune]map[int]string {
+		d := make(map[rune]map[int]string, n)
+		for i := 0; i < n; i++ {
+			// each glyph is a single pixel at a position unique to the glyph
+			d[rune(i)] = map[int]string{i % 16: strings.Repeat(" ", i/16%16) + "X"}
+		}
+		return d
+	}
+	if _, _, err := Pack(16, 16, glyphs(3000)); err == nil {
+		t.Error("no error packing 3000 16x16 glyphs")
+	}
+
+	data, cm, err := Pack(16, 16, glyphs(2048))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewPixFont(16, 16, cm, data)
+	for _, r := range []rune{0, 1000, 2047} {
+		m := f.GlyphMask(r)
+		if m.AlphaAt(int(r)/16%16, int(r)%16).A == 0 {
+			t.Errorf("glyph %d is missing its pixel", r)
+		}
+	}
+}
+
+func TestPackBadSize(t *testing.T) {
+	d := map[rune]map[int]string{'A': {0: "X"}}
+	for _, sz := range [][2]int{{0, 8}, {33, 8}, {8, 256}, {8, -1}} {
+		if _, _, err := Pack(sz[0], sz[1], d); err == nil {
+			t.Errorf("no error packing %dx%d glyphs", sz[0], sz[1])
+		}
+	}
+}
+
+func TestDitherDrawable(t *testing.T) {
+	on := func(img *image.Gray) int {
+		n := 0
+		for _, v := range img.Pix {
+			if v == 0xff {
+				n++
+			}
+		}
+		return n
+	}
+	for c, want := range map[color.Color]int{
+		color.White:                         16,
+		color.Gray{0x80}:                    8,
+		color.Gray{0x20}:                    2,
+		color.Black:                         0,
+		color.Transparent:                   0,
+		color.NRGBA{0xff, 0xff, 0xff, 0x80}: 8,
+	} {
+		img := image.NewGray(image.Rect(0, 0, 4, 4))
+		d := &DitherDrawable{Dst: img, On: color.White}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				d.Set(x, y, c)
+			}
+		}
+		if got := on(img); got != want {
+			t.Errorf("%v lights %d pixels, want %d", c, got, want)
+		}
+	}
+
+	// Off sets unlit pixels, which are otherwise left as they are
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 0x55
+	}
+	d := &DitherDrawable{Dst: img, On: color.White}
+	d.Set(0, 0, color.Black)
+	if got := img.GrayAt(0, 0).Y; got != 0x55 {
+		t.Errorf("unlit pixel without Off is %d, want it untouched", got)
+	}
+	d.Off = color.Black
+	d.Set(0, 0, color.Black)
+	if got := img.GrayAt(0, 0).Y; got != 0 {
+		t.Errorf("unlit pixel is %d, want Off", got)
+	}
+
+	// text drawn in white is drawn solid
+	want := image.NewAlpha(image.Rect(0, 0, 16, 8))
+	Font8x8.DrawString(want, 0, 0, "Hi", color.Opaque)
+	got := image.NewGray(want.Rect)
+	Font8x8.DrawString(&DitherDrawable{Dst: got, On: color.White}, 0, 0, "Hi", color.White)
+	for i := range want.Pix {
+		if want.Pix[i] != got.Pix[i] {
+			t.Fatalf("pixel %d is %d, want %d", i, got.Pix[i], want.Pix[i])
+		}
+	}
+}
+
+// tinyFont returns a fixed width 3x3 font with glyphs for 'I' and 'T'.
+func tinyFont() *PixFont {
+	data, cm, _ := Pack(3, 3, map[rune]map[int]string{
+		'I': {0: "XXX", 1: " X ", 2: "XXX"},
+		'T': {0: "XXX", 1: " X ", 2: " X "},
+	})
+	return NewPixFont(3, 3, cm, data)
+}
+
+// grayRows returns the rows of img, with '#' for black pixels, '.' for white
+// and ' ' for any other shade.
+func grayRows(img *image.Gray) string {
+	var sb strings.Builder
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		if y > img.Rect.Min.Y {
+			sb.WriteByte('|')
+		}
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			switch img.GrayAt(x, y).Y {
+			case 0:
+				sb.WriteByte('#')
+			case 0xff:
+				sb.WriteByte('.')
+			default:
+				sb.WriteByte(' ')
+			}
+		}
+	}
+	return sb.String()
+}
+
+// grayImage returns a new image of w by h mid-gray pixels.
+func grayImage(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	return img
+}
+
+func TestDrawStringInverse(t *testing.T) {
+	f := tinyFont()
+	img := grayImage(10, 4)
+	if x := f.DrawStringInverse(img, 1, 0, "IT", color.Black, color.White); x != 9 {
+		t.Errorf("advanced to %d, want 9", x)
+	}
+	if got, want := grayRows(img), " ...#...# | #.###.## | ...##.## |          "; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	// without bg, the glyphs show what is under them
+	img = grayImage(10, 4)
+	f.DrawStringInverse(img, 1, 0, "I", color.Black, nil)
+	if got, want := grayRows(img), "    #     | # ##     |    #     |          "; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestDrawLabel(t *testing.T) {
+	f := tinyFont()
+	img := grayImage(8, 8)
+	l := Label{Color: color.Black, Background: color.White, Border: color.Black, Padding: 1, Rounded: true}
+	if r := f.DrawLabel(img, 0, 0, "I", l); r != image.Rect(0, 0, 7, 7) {
+		t.Errorf("label bounds are %v, want 7x7", r)
+	}
+	want := " #####  |#.....# |#.###.# |#..#..# |#.###.# |#.....# | #####  |        "
+	if got := grayRows(img); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	img = grayImage(8, 3)
+	if r := f.DrawLabel(img, 1, 0, "IT", Label{Color: color.Black}); r != image.Rect(1, 0, 8, 3) {
+		t.Errorf("unpadded label bounds are %v, want 7x3 at 1,0", r)
+	}
+	if got, want := grayRows(img), " ### ###|  #   # | ###  # "; got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	f := NewPixFont(8, 8, Font8x8.charmap, Font8x8.data)
+	if !f.HasGlyph('é') || f.HasGlyph('́') {
+		t.Fatal("Font8x8 should have é but not a combining acute accent")
+	}
+	decomposed := "été"
+	if _, drawn := f.DrawStringChecked(&StringDrawable{}, 0, 0, decomposed, nil); len(drawn) != 4 || drawn[1] {
+		t.Errorf("without normalization, drawn runes are %v", drawn)
+	}
+
+	f.SetNormalization(true)
+	got, want := &StringDrawable{}, &StringDrawable{}
+	f.DrawString(got, 0, 0, decomposed, nil)
+	f.DrawString(want, 0, 0, "été", nil)
+	if got.String() != want.String() {
+		t.Errorf("normalized string is drawn as\n%s\nwant\n%s", got, want)
+	}
+	if _, drawn := f.DrawStringChecked(&StringDrawable{}, 0, 0, decomposed, nil); len(drawn) != 3 {
+		t.Errorf("with normalization, drawn runes are %v", drawn)
+	}
+	if a, b := f.MeasureString(decomposed), f.MeasureString("été"); a != b {
+		t.Errorf("normalized string measures %d, want %d", a, b)
+	}
+}
+
+func TestTicker(t *testing.T) {
+	tk := NewTicker(tinyFont(), 5, "I")
+	if n := tk.Frames(); n != 9 {
+		t.Errorf("ticker has %d frames, want 9", n)
+	}
+	for n, want := range map[int]string{
+		0:  " ###    ",
+		2:  " #      ",
+		7:  "   ###  ",
+		-2: "   ###  ",
+		16: "   ###  ",
+	} {
+		img := grayImage(8, 3)
+		tk.DrawFrame(img, 1, 0, n, color.Black)
+		if got := strings.Split(grayRows(img), "|")[0]; got != want {
+			t.Errorf("frame %d is %q, want %q", n, got, want)
+		}
+	}
+
+	// with a gap narrower than the viewport, repetitions are visible together
+	tk.Width, tk.Gap = 8, 1
+	img := grayImage(8, 3)
+	tk.DrawFrame(img, 0, 0, 1, color.Black)
+	if got, want := strings.Split(grayRows(img), "|")[0], "##  ### "; got != want {
+		t.Errorf("frame with small gap is %q, want %q", got, want)
+	}
+}
+
+func TestFramebuffer(t *testing.T) {
+	page := NewFramebuffer(3, 10, PageLayout)
+	horiz := NewFramebuffer(10, 2, HorizontalLayout)
+	if len(page.Pix) != 6 || len(horiz.Pix) != 4 {
+		t.Fatalf("buffers are %d and %d bytes, want 6 and 4", len(page.Pix), len(horiz.Pix))
+	}
+	page.Set(1, 0, color.White)
+	page.Set(1, 9, color.White)
+	page.Set(2, 7, color.Gray{0x80})
+	page.Set(0, 0, color.Gray{0x7f}) // too dark to light
+	page.Set(3, 0, color.White)      // outside
+	if got, want := page.Pix, []byte{0, 1, 0x80, 0, 2, 0}; !bytes.Equal(got, want) {
+		t.Errorf("page layout is % x, want % x", got, want)
+	}
+	horiz.Set(0, 0, color.White)
+	horiz.Set(9, 1, color.White)
+	horiz.Set(8, 0, color.White)
+	horiz.Set(8, 0, color.Black)
+	if got, want := horiz.Pix, []byte{0x80, 0, 0, 0x40}; !bytes.Equal(got, want) {
+		t.Errorf("horizontal layout is % x, want % x", got, want)
+	}
+
+	if !page.Bit(1, 9) || page.Bit(0, 9) || page.Bit(-1, 0) {
+		t.Error("Bit doesn't match the pixels set")
+	}
+	if page.At(1, 0) != color.White || page.At(0, 0) != color.Black || page.Bounds() != image.Rect(0, 0, 3, 10) {
+		t.Error("Framebuffer image doesn't match the pixels set")
+	}
+	page.Clear()
+	if !bytes.Equal(page.Pix, make([]byte, 6)) {
+		t.Error("Clear left pixels lit")
+	}
+
+	// text drawn in white is the same as on an image
+	want := image.NewAlpha(image.Rect(0, 0, 16, 8))
+	Font8x8.DrawString(want, 0, 0, "Hi", color.Opaque)
+	for _, layout := range []FramebufferLayout{PageLayout, HorizontalLayout} {
+		fb := NewFramebuffer(16, 8, layout)
+		Font8x8.DrawString(fb, 0, 0, "Hi", color.White)
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 16; x++ {
+				if fb.Bit(x, y) != (want.AlphaAt(x, y).A != 0) {
+					t.Fatalf("layout %d: pixel %d,%d differs", layout, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestEPaper(t *testing.T) {
+	e := NewEPaper(20, 4, EPaperBW)
+	if e.Planes[1] != nil || !e.Dirty().Empty() {
+		t.Fatal("new black and white panel has a second plane or a dirty region")
+	}
+	e.Set(9, 1, color.Black)
+	e.Set(11, 2, color.White)
+	e.Set(30, 2, color.Black) // outside
+	if !e.Planes[0].Bit(9, 1) || e.Planes[0].Bit(11, 2) {
+		t.Error("black pixel isn't inked, or white pixel is")
+	}
+	if got, want := e.Dirty(), image.Rect(9, 1, 12, 3); got != want {
+		t.Errorf("dirty region is %v, want %v", got, want)
+	}
+	if got, want := e.DirtyAligned(), image.Rect(8, 1, 16, 3); got != want {
+		t.Errorf("aligned dirty region is %v, want %v", got, want)
+	}
+	e.ResetDirty()
+	if !e.Dirty().Empty() || !e.DirtyAligned().Empty() {
+		t.Error("dirty region isn't reset")
+	}
+	e.Set(19, 0, color.Black)
+	if got, want := e.DirtyAligned(), image.Rect(16, 0, 24, 1); got != want {
+		t.Errorf("aligned dirty region at the edge is %v, want %v", got, want)
+	}
+	e.Clear()
+	if e.Planes[0].Bit(9, 1) || e.Dirty() != image.Rect(0, 0, 20, 4) {
+		t.Error("Clear didn't blank and dirty the whole panel")
+	}
+
+	gray := NewEPaper(4, 1, EPaperGray4)
+	for x, c := range []color.Gray{{0xff}, {0xaa}, {0x55}, {0}} {
+		gray.Set(x, 0, c)
+	}
+	if got, want := []byte{gray.Planes[0].Pix[0], gray.Planes[1].Pix[0]}, []byte{0x30, 0x50}; !bytes.Equal(got, want) {
+		t.Errorf("gray planes are % x, want % x", got, want)
+	}
+
+	bwr := NewEPaper(3, 1, EPaperBWR)
+	bwr.Set(0, 0, color.Black)
+	bwr.Set(1, 0, color.RGBA{0xff, 0, 0, 0xff})
+	bwr.Set(2, 0, color.White)
+	if got, want := []byte{bwr.Planes[0].Pix[0], bwr.Planes[1].Pix[0]}, []byte{0x80, 0x40}; !bytes.Equal(got, want) {
+		t.Errorf("black and red planes are % x, want % x", got, want)
+	}
+}
+
+func TestLEDMatrix(t *testing.T) {
+	m := &LEDMatrix{PanelWidth: 4, PanelHeight: 2, Panels: 4, Rows: 2, Serpentine: true, ZigZag: true}
+	if w, h := m.Size(); w != 8 || h != 4 {
+		t.Fatalf("display is %dx%d, want 8x4", w, h)
+	}
+	for _, tt := range []struct{ x, y, px, py, index int }{
+		{0, 0, 0, 0, 0},
+		{5, 1, 5, 1, 14},  // second panel, second (reversed) row
+		{0, 2, 15, 1, 28}, // third row of panels runs backwards and upside down
+		{7, 3, 8, 0, 16},
+	} {
+		px, py, ok := m.Map(tt.x, tt.y)
+		if !ok || px != tt.px || py != tt.py {
+			t.Errorf("%d,%d maps to %d,%d, want %d,%d", tt.x, tt.y, px, py, tt.px, tt.py)
+		}
+		if i, ok := m.Index(tt.x, tt.y); !ok || i != tt.index {
+			t.Errorf("%d,%d has index %d, want %d", tt.x, tt.y, i, tt.index)
+		}
+	}
+	if _, _, ok := m.Map(8, 0); ok {
+		t.Error("pixel outside the display is mapped")
+	}
+
+	// every pixel of each arrangement lands on its own physical pixel
+	for _, rot := range []int{0, 90, 180, 270} {
+		img := image.NewAlpha(image.Rect(0, 0, 16, 2))
+		m := &LEDMatrix{Dst: img, PanelWidth: 4, PanelHeight: 2, Panels: 4, Rows: 2, Rotation: rot}
+		w, h := m.Size()
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				px, py, _ := m.Map(x, y)
+				if img.AlphaAt(px, py).A != 0 {
+					t.Fatalf("rotation %d: %d,%d maps to the used pixel %d,%d", rot, x, y, px, py)
+				}
+				m.Set(x, y, color.Opaque)
+			}
+		}
+		for i, v := range img.Pix {
+			if v == 0 {
+				t.Fatalf("rotation %d: physical pixel %d is unused", rot, i)
+			}
+		}
+	}
+
+	r := &LEDMatrix{PanelWidth: 4, PanelHeight: 2, Panels: 1, Rotation: 90}
+	if w, h := r.Size(); w != 2 || h != 4 {
+		t.Errorf("rotated display is %dx%d, want 2x4", w, h)
+	}
+	if px, py, _ := r.Map(0, 0); px != 0 || py != 1 {
+		t.Errorf("rotated 0,0 maps to %d,%d, want 0,1", px, py)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	f := tinyFont()
+	if got, want := f.Columns("IT", false), []uint64{5, 7, 5, 0, 1, 7, 1, 0}; !equalUint64s(got, want) {
+		t.Errorf("columns are %v, want %v", got, want)
+	}
+	if got, want := f.Columns("T", true), []uint64{4, 7, 4, 0}; !equalUint64s(got, want) {
+		t.Errorf("msb top columns are %v, want %v", got, want)
+	}
+	if got := f.Columns("", false); len(got) != 0 {
+		t.Errorf("empty string has columns %v", got)
+	}
+}
+
+func equalUint64s(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGlyphMask(t *testing.T) {
+	f := tinyFont()
+	if got := string(f.Runes()); got != "IT" {
+		t.Errorf("runes are %q, want \"IT\"", got)
+	}
+	m := f.GlyphMask('T')
+	if m.Bounds() != image.Rect(0, 0, 3, 3) {
+		t.Fatalf("mask bounds are %v, want the 3x3 cell", m.Bounds())
+	}
+	want := []uint8{0xff, 0xff, 0xff, 0, 0xff, 0, 0, 0xff, 0}
+	if !bytes.Equal(m.Pix, want) {
+		t.Errorf("mask is % x, want % x", m.Pix, want)
+	}
+	if f.GlyphMask('X') != nil {
+		t.Error("missing glyph has a mask")
+	}
+}
+
+// charsetDump returns n 8x8 glyphs, each with a first row of its index and
+// the other rows blank.
+func charsetDump(n int) []byte {
+	data := make([]byte, 8*n)
+	for i := 0; i < n; i++ {
+		data[i*8] = byte(i)
+	}
+	return data
+}
+
+// firstRow returns the first row of the glyph for r in f.
+func firstRow(f *PixFont, r rune) string {
+	sd := &StringDrawable{}
+	f.DrawRune(sd, 0, 0, r, nil)
+	return strings.SplitN(sd.String(), "\n", 2)[0]
+}
+
+func TestLoadC64(t *testing.T) {
+	// with and without a load address
+	for _, data := range [][]byte{charsetDump(128), append([]byte{0, 0x38}, charsetDump(128)...)} {
+		f, err := LoadC64(bytes.NewReader(data), C64Upper)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r, want := range map[rune]string{'@': "", 'A': "       X", 'Z': "   XX X", '£': "   XXX", ' ': "  X", '♠': " X     X"} {
+			if got := firstRow(f, r); got != want {
+				t.Errorf("glyph %q starts %q, want %q", r, got, want)
+			}
+		}
+		if f.HasGlyph('a') {
+			t.Error("uppercase charset has a glyph for 'a'")
+		}
+	}
+
+	f, err := LoadC64(bytes.NewReader(charsetDump(128)), C64Lower)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := firstRow(f, 'a'), "       X"; got != want {
+		t.Errorf("glyph 'a' starts %q, want %q", got, want)
+	}
+	if got, want := firstRow(f, 'A'), " X     X"; got != want {
+		t.Errorf("glyph 'A' starts %q, want %q", got, want)
+	}
+
+	for _, n := range []int{0, 7, 9} {
+		if _, err := LoadC64(bytes.NewReader(make([]byte, n)), C64Upper); err == nil {
+			t.Errorf("no error for %d bytes", n)
+		}
+	}
+}
+
+func TestLoadZX(t *testing.T) {
+	f, err := LoadZX(bytes.NewReader(charsetDump(96)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r, want := range map[rune]string{'!': "       X", '£': " X", '©': " X XXXXX"} {
+		if got := firstRow(f, r); got != want {
+			t.Errorf("glyph %q starts %q, want %q", r, got, want)
+		}
+	}
+	if f.HasGlyph('`') {
+		t.Error("font has a glyph for '`'")
+	}
+	if _, err := LoadZX(bytes.NewReader(charsetDump(95)), nil); err == nil {
+		t.Error("no error for a short font")
+	}
+}
+
+func TestMetadataKept(t *testing.T) {
+	f := tinyFont()
+	f.Name, f.Copyright, f.Comment = "Tiny", "Public domain", "two\nlines"
+	check := func(what string, name, copyright, comment string) {
+		t.Helper()
+		if name != f.Name || copyright != f.Copyright || comment != f.Comment {
+			t.Errorf("%s has metadata %q, %q, %q", what, name, copyright, comment)
+		}
+	}
+
+	scaled, err := f.Scale2x()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Scale2x", scaled.Name, scaled.Copyright, scaled.Comment)
+	sub, err := f.Subset([]rune("I"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("Subset", sub.Name, sub.Copyright, sub.Comment)
+	withGlyphs, err := NewShortcodes().WithGlyphs(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("WithGlyphs", withGlyphs.Name, withGlyphs.Copyright, withGlyphs.Comment)
+	v2, err := UpgradeFont(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("UpgradeFont", v2.Name, v2.Copyright, v2.Comment)
+}
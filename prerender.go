@@ -0,0 +1,62 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// DrawList is a string pre-rendered by Prerender, which can be drawn repeatedly
+// at different positions without decoding and measuring the string each time.
+// It is useful for UI labels and game HUD elements which rarely change but are
+// drawn every frame.
+type DrawList struct {
+	// Color is used for every pixel of the string.
+	Color color.Color
+	// Width is the advance of the string, as returned by MeasureString.
+	Width int
+	// Height is the height of the font used.
+	Height int
+
+	points []image.Point
+}
+
+// Prerender draws s into a new DrawList in the given color.
+func (p *PixFont) Prerender(s string, clr color.Color) *DrawList {
+	return p.prerender(s, clr, p.Spacing())
+}
+
+// prerender is Prerender with the spacing sp.
+func (p *PixFont) prerender(s string, clr color.Color, sp int) *DrawList {
+	p.recordUsage(s)
+	d := &DrawList{Color: clr, Height: int(p.charHeight)}
+	d.Width = p.layout(p.prepare(s), sp, func(c rune, dx, dy int) int {
+		_, w := p.drawRune(func(xx, yy int) {
+			d.points = append(d.points, image.Point{xx, yy})
+		}, dx, dy, c, sp)
+		return w
+	})
+	return d
+}
+
+// Draw draws the pre-rendered string at x,y and returns the x position following
+// it, just as DrawString does.
+func (d *DrawList) Draw(dr Drawable, x, y int) int {
+	set := setter(dr, d.Color)
+	for _, pt := range d.points {
+		set(x+pt.X, y+pt.Y)
+	}
+	return x + d.Width
+}
+
+// Mask returns the pre-rendered string as an alpha mask, with opaque pixels for
+// each pixel of the string, for use with draw.DrawMask. The mask bounds are
+// (0,0) to (Width,Height).
+func (d *DrawList) Mask() *image.Alpha {
+	m := image.NewAlpha(image.Rect(0, 0, d.Width, d.Height))
+	for _, pt := range d.points {
+		if pt.In(m.Rect) {
+			m.Pix[m.PixOffset(pt.X, pt.Y)] = 0xff
+		}
+	}
+	return m
+}
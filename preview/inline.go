@@ -0,0 +1,67 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+)
+
+// kittyChunkSize is the maximum payload size of a single kitty graphics escape.
+const kittyChunkSize = 4096
+
+// Kitty writes img to w using the kitty terminal graphics protocol, which is also
+// supported by wezterm and Konsole.
+func Kitty(w io.Writer, img image.Image) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for first := true; first || len(data) > 0; first = false {
+		n := len(data)
+		if n > kittyChunkSize {
+			n = kittyChunkSize
+		}
+		more := 0
+		if n < len(data) {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(bw, "\x1b_Ga=T,f=100,m=%d;%s\x1b\\", more, data[:n])
+		} else {
+			fmt.Fprintf(bw, "\x1b_Gm=%d;%s\x1b\\", more, data[:n])
+		}
+		data = data[n:]
+	}
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// ITerm2 writes img to w using the iTerm2 inline images protocol, which is also
+// supported by wezterm and mintty.
+func ITerm2(w io.Writer, img image.Image) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	// the size is that of the PNG, before it is base64-encoded
+	size := base64.StdEncoding.DecodedLen(len(data)) - bytes.Count(data[len(data)-2:], []byte("="))
+	_, err = fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a\n", size, data)
+	return err
+}
+
+// encodePNG returns the base64-encoded PNG representation of img.
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	data := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+	base64.StdEncoding.Encode(data, buf.Bytes())
+	return data, nil
+}
@@ -0,0 +1,130 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestSixel(t *testing.T) {
+	pm := image.NewPaletted(image.Rect(0, 0, 3, 1), color.Palette{color.Transparent, color.Black, color.White})
+	pm.Pix = []uint8{0, 1, 2}
+	var buf bytes.Buffer
+	if err := Sixel(&buf, pm); err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1bP0;1;0q\"1;1;3;1#1;2;0;0;0#2;2;100;100;100#1?@?$#2??@-\x1b\\"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// transparent pixels of other images are left as the background too
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.Black)
+	buf.Reset()
+	if err := Sixel(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.HasSuffix(got, "@?-\x1b\\") {
+		t.Errorf("transparent pixel is drawn: %q", got)
+	}
+
+	big := image.NewPaletted(image.Rect(0, 0, 1, 1), make(color.Palette, 257))
+	if err := Sixel(&buf, big); err == nil {
+		t.Error("no error for a palette of 257 colors")
+	}
+}
+
+// testImage returns a small image with a few colors.
+func testImage() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 7, 5))
+	img.Set(1, 1, color.Black)
+	img.Set(2, 3, color.NRGBA{0xff, 0, 0, 0x80})
+	return img
+}
+
+// decodePNG decodes base64-encoded PNG data, and checks that it is testImage.
+func decodePNG(t *testing.T, data string) []byte {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != testImage().Bounds() || img.At(1, 1) != testImage().At(1, 1) {
+		t.Errorf("decoded image differs")
+	}
+	return raw
+}
+
+func TestITerm2(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ITerm2(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	var size int
+	var data string
+	out := strings.TrimSuffix(buf.String(), "\a\n")
+	if _, err := fmt.Sscanf(out, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s", &size, &data); err != nil {
+		t.Fatalf("bad escape %q: %v", out, err)
+	}
+	if raw := decodePNG(t, data); len(raw) != size {
+		t.Errorf("size is %d, want %d", size, len(raw))
+	}
+}
+
+func TestKitty(t *testing.T) {
+	// a noisy image, so that the PNG needs more than one chunk
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	seed := uint32(1)
+	for i := range img.Pix {
+		seed = seed*1103515245 + 12345
+		img.Pix[i] = uint8(seed >> 16)
+	}
+	var buf bytes.Buffer
+	if err := Kitty(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	out := strings.TrimSuffix(buf.String(), "\x1b\\\n")
+	chunks := strings.Split(out, "\x1b\\")
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	var data string
+	for i, c := range chunks {
+		more := 1
+		if i == len(chunks)-1 {
+			more = 0
+		}
+		prefix := fmt.Sprintf("\x1b_Gm=%d;", more)
+		if i == 0 {
+			prefix = fmt.Sprintf("\x1b_Ga=T,f=100,m=%d;", more)
+		}
+		if !strings.HasPrefix(c, prefix) {
+			t.Fatalf("chunk %d doesn't start with %q", i, prefix)
+		}
+		if n := len(c) - len(prefix); n > kittyChunkSize {
+			t.Errorf("chunk %d has %d bytes", i, n)
+		}
+		data += c[len(prefix):]
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, ok := got.(*image.Gray); !ok || !bytes.Equal(g.Pix, img.Pix) {
+		t.Error("decoded image differs")
+	}
+}
@@ -0,0 +1,112 @@
+// Package preview writes images to terminals that support inline graphics, so
+// that rendered text and font specimens can be checked without opening an image
+// viewer.
+package preview
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"io"
+)
+
+// Sixel writes img to w as DEC sixel graphics, which are supported by terminals
+// such as xterm (with -ti vt340), mlterm and wezterm. Images that are not already
+// paletted are mapped onto the Plan 9 palette. Fully transparent pixels are left
+// as the terminal background.
+func Sixel(w io.Writer, img image.Image) error {
+	pm, ok := img.(*image.Paletted)
+	if !ok {
+		b := img.Bounds()
+		pm = image.NewPaletted(b, palette.Plan9)
+		draw.Draw(pm, b, img, b.Min, draw.Src)
+	}
+	if len(pm.Palette) > 256 {
+		return fmt.Errorf("preview: sixel supports at most 256 colors, image has %d", len(pm.Palette))
+	}
+
+	bw := bufio.NewWriter(w)
+	b := pm.Bounds()
+
+	// DCS with P2=1 so that unset pixels remain transparent, then raster attributes
+	fmt.Fprintf(bw, "\x1bP0;1;0q\"1;1;%d;%d", b.Dx(), b.Dy())
+	// clear reports whether the pixel at x,y is left as the background
+	transparent := make([]bool, len(pm.Palette))
+	clear := func(x, y int) bool {
+		return transparent[pm.ColorIndexAt(x, y)]
+	}
+	if !ok {
+		clear = func(x, y int) bool {
+			_, _, _, a := img.At(x, y).RGBA()
+			return a == 0
+		}
+	}
+	for i, c := range pm.Palette {
+		if _, _, _, a := c.RGBA(); a == 0 {
+			transparent[i] = true
+			continue
+		}
+		// unpremultiply and convert to percentages
+		nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, int(nc.R)*100/255, int(nc.G)*100/255, int(nc.B)*100/255)
+	}
+
+	row := make([]byte, b.Dx())
+	for y0 := b.Min.Y; y0 < b.Max.Y; y0 += 6 {
+		// find the colors used in this band of six rows
+		used := make(map[uint8]bool)
+		for y := y0; y < y0+6 && y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if !clear(x, y) {
+					used[pm.ColorIndexAt(x, y)] = true
+				}
+			}
+		}
+
+		first := true
+		for idx := 0; idx < len(pm.Palette); idx++ {
+			if !used[uint8(idx)] {
+				continue
+			}
+			for x := b.Min.X; x < b.Max.X; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && y0+dy < b.Max.Y; dy++ {
+					if pm.ColorIndexAt(x, y0+dy) == uint8(idx) && !clear(x, y0+dy) {
+						bits |= 1 << uint(dy)
+					}
+				}
+				row[x-b.Min.X] = '?' + bits
+			}
+			if !first {
+				bw.WriteByte('$') // return to the start of the band
+			}
+			first = false
+			fmt.Fprintf(bw, "#%d", idx)
+			writeSixelRLE(bw, row)
+		}
+		bw.WriteByte('-') // next band
+	}
+	bw.WriteString("\x1b\\")
+	return bw.Flush()
+}
+
+// writeSixelRLE writes a row of sixel characters using run-length encoding.
+func writeSixelRLE(w *bufio.Writer, row []byte) {
+	for i := 0; i < len(row); {
+		j := i + 1
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, row[i])
+		} else {
+			for k := 0; k < n; k++ {
+				w.WriteByte(row[i])
+			}
+		}
+		i = j
+	}
+}
@@ -0,0 +1,106 @@
+package pixfont
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RuneRange is an inclusive range of runes, Lo through Hi.
+type RuneRange struct {
+	Lo, Hi rune
+}
+
+// Contains returns true if c is within the range.
+func (r RuneRange) Contains(c rune) bool {
+	return c >= r.Lo && c <= r.Hi
+}
+
+// String formats the range in the CSS unicode-range syntax, e.g. "U+20-7E".
+func (r RuneRange) String() string {
+	if r.Lo == r.Hi {
+		return fmt.Sprintf("U+%04X", r.Lo)
+	}
+	return fmt.Sprintf("U+%04X-%04X", r.Lo, r.Hi)
+}
+
+// InRanges returns true if c is contained in any of the ranges.
+func InRanges(rs []RuneRange, c rune) bool {
+	for _, r := range rs {
+		if r.Contains(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRanges parses a comma separated list of ranges in the syntax of the CSS
+// unicode-range descriptor, so that every tool can select a subset of a font the
+// same way. Each entry is a single code point ("U+263A"), a range
+// ("U+0020-007E") or a wildcard range ("U+4??", meaning U+400-4FF).
+func ParseRanges(s string) ([]RuneRange, error) {
+	var rs []RuneRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if len(part) < 3 || (part[:2] != "U+" && part[:2] != "u+") {
+			return nil, fmt.Errorf("pixfont: bad unicode range %q", part)
+		}
+		spec := part[2:]
+
+		var r RuneRange
+		if i := strings.IndexByte(spec, '-'); i >= 0 {
+			lo, err1 := strconv.ParseUint(spec[:i], 16, 32)
+			hi, err2 := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(spec[i+1:], "U+"), "u+"), 16, 32)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("pixfont: bad unicode range %q", part)
+			}
+			r = RuneRange{rune(lo), rune(hi)}
+		} else {
+			lo, err1 := strconv.ParseUint(strings.Replace(spec, "?", "0", -1), 16, 32)
+			hi, err2 := strconv.ParseUint(strings.Replace(spec, "?", "F", -1), 16, 32)
+			if err1 != nil || err2 != nil || strings.Contains(strings.TrimRight(spec, "?"), "?") {
+				return nil, fmt.Errorf("pixfont: bad unicode range %q", part)
+			}
+			r = RuneRange{rune(lo), rune(hi)}
+		}
+		if r.Lo > r.Hi || r.Hi > 0x10FFFF {
+			return nil, fmt.Errorf("pixfont: bad unicode range %q", part)
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// HasGlyph returns true if the font can draw r, either from its own glyph, the
+// glyph of its alias set with SetAliases or, with SetHangulComposition, by
+// composing it from jamo. It does not count the replacement rune, so it can be
+// used to validate input before drawing.
+func (p *PixFont) HasGlyph(r rune) bool {
+	if p.has(r) {
+		return true
+	}
+	return p.hangul && p.hangulJamo(r) != nil
+}
+
+// CoverageRanges returns the runes which have a glyph in the font as a sorted
+// list of ranges, merging consecutive runes. Composed Hangul syllables are not
+// included.
+func (p *PixFont) CoverageRanges() []RuneRange {
+	return mergeRanges(p.Runes())
+}
+
+// mergeRanges returns the sorted runes as ranges, merging consecutive runes.
+func mergeRanges(runes []rune) []RuneRange {
+	var rs []RuneRange
+	for _, r := range runes {
+		if n := len(rs); n > 0 && rs[n-1].Hi+1 == r {
+			rs[n-1].Hi = r
+			continue
+		}
+		rs = append(rs, RuneRange{r, r})
+	}
+	return rs
+}
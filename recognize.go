@@ -0,0 +1,195 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"unicode/utf8"
+)
+
+// recogGlyph is a glyph trimmed to its opaque columns, for matching.
+type recogGlyph struct {
+	r       rune
+	left    int // blank columns before the glyph pixels
+	w       int
+	pix     []bool // w by charHeight
+	count   int    // number of opaque pixels
+	advance int
+}
+
+// Recognize decodes text that was drawn onto img using f, by exact matching
+// against the glyph bitmaps. It is meant for reading back values from images
+// generated by your own services, not general OCR: the text must be drawn at
+// 1:1 scale in a single color, and any pixel which isn't the image's background
+// color (its most common color) is treated as part of the text.
+//
+// Lines of text are returned separated by newlines. Gaps between glyphs of at
+// least the width of a space become spaces, and anything that matches no glyph
+// becomes utf8.RuneError.
+func Recognize(img image.Image, f *PixFont) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// find the background color, and mark everything else as ink
+	counts := make(map[color.RGBA64]int)
+	var bg color.RGBA64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBA64Model.Convert(img.At(x, y)).(color.RGBA64)
+			counts[c]++
+			if counts[c] > counts[bg] {
+				bg = c
+			}
+		}
+	}
+	ink := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			ink[y*w+x] = color.RGBA64Model.Convert(img.At(b.Min.X+x, b.Min.Y+y)) != bg
+		}
+	}
+
+	glyphs := f.recogGlyphs()
+	sp := f.Spacing()
+	_, space := f.MeasureRune(' ')
+	space += sp
+
+	var lines []string
+	ch := int(f.charHeight)
+	for y := 0; y < h; y++ {
+		rowInk := false
+		for x := 0; x < w && !rowInk; x++ {
+			rowInk = ink[y*w+x]
+		}
+		if !rowInk {
+			continue
+		}
+
+		// the first inked row could be any row of the glyphs, so try each
+		// possible line top and keep the one which matches best
+		var best string
+		bestScore := -1
+		for top := y - ch + 1; top <= y; top++ {
+			s, score := recognizeLine(ink, w, h, top, ch, glyphs, space, sp)
+			if score > bestScore {
+				best, bestScore = s, score
+			}
+		}
+		lines = append(lines, best)
+		y += ch - 1
+	}
+	return strings.Join(lines, "\n")
+}
+
+// recogGlyphs returns the glyphs of p for matching, preferring those with more
+// pixels, then lower runes, when several match at the same position.
+func (p *PixFont) recogGlyphs() []*recogGlyph {
+	cw, ch := int(p.charWidth), int(p.charHeight)
+	var glyphs []*recogGlyph
+	for _, r := range p.Runes() {
+		full := make([]bool, cw*ch)
+		_, adv := p.drawRune(func(x, y int) {
+			full[y*cw+x] = true
+		}, 0, 0, r, p.Spacing())
+
+		left, right := cw, -1
+		for i, on := range full {
+			if on {
+				if i%cw < left {
+					left = i % cw
+				}
+				if i%cw > right {
+					right = i % cw
+				}
+			}
+		}
+		if right < 0 {
+			continue // blank glyphs can't be seen
+		}
+		g := &recogGlyph{r: r, left: left, w: right - left + 1, advance: adv}
+		g.pix = make([]bool, g.w*ch)
+		for y := 0; y < ch; y++ {
+			for x := 0; x < g.w; x++ {
+				if full[y*cw+left+x] {
+					g.pix[y*g.w+x] = true
+					g.count++
+				}
+			}
+		}
+		glyphs = append(glyphs, g)
+	}
+	// stable insertion sort by descending pixel count; Runes is already in
+	// ascending order
+	for i := 1; i < len(glyphs); i++ {
+		for j := i; j > 0 && glyphs[j].count > glyphs[j-1].count; j-- {
+			glyphs[j], glyphs[j-1] = glyphs[j-1], glyphs[j]
+		}
+	}
+	return glyphs
+}
+
+// recognizeLine decodes a line of text whose glyph cells start at row top. It
+// returns the text and the number of ink pixels it explains. space is the
+// advance of a space and sp the spacing between glyphs.
+func recognizeLine(ink []bool, w, h, top, ch int, glyphs []*recogGlyph, space, sp int) (string, int) {
+	at := func(x, y int) bool {
+		if y < 0 || y >= h || x < 0 || x >= w {
+			return false
+		}
+		return ink[y*w+x]
+	}
+	colInk := func(x int) bool {
+		for y := top; y < top+ch; y++ {
+			if at(x, y) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var sb strings.Builder
+	score := 0
+	nextOrigin := -1 // where the next glyph would start, after the last match
+	unknown := false
+	for x := 0; x < w; x++ {
+		if !colInk(x) {
+			continue
+		}
+		var match *recogGlyph
+		for _, g := range glyphs {
+			ok := true
+			for yy := 0; yy < ch && ok; yy++ {
+				for xx := 0; xx < g.w; xx++ {
+					if g.pix[yy*g.w+xx] != at(x+xx, top+yy) {
+						ok = false
+						break
+					}
+				}
+			}
+			if ok {
+				match = g
+				break
+			}
+		}
+		if match == nil {
+			if !unknown {
+				sb.WriteRune(utf8.RuneError)
+				unknown = true
+			}
+			continue
+		}
+		unknown = false
+
+		origin := x - match.left
+		if nextOrigin >= 0 && space > 0 {
+			for n := (origin - nextOrigin + space/2) / space; n > 0; n-- {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteRune(match.r)
+		score += match.count
+		nextOrigin = origin + match.advance + sp
+		x += match.w - 1
+	}
+	return sb.String(), score
+}
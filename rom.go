@@ -0,0 +1,59 @@
+package pixfont
+
+import "fmt"
+
+// NewStringPixFont creates a new PixFont like NewPixFont, with the packed data
+// given as a string made by DataString instead of a []uint32. Go keeps string
+// constants in read-only memory, so generated fonts which use it need no heap
+// allocation for their data at init time, and on microcontrollers the data can
+// stay in flash. Words are decoded as glyphs are drawn.
+func NewStringPixFont(w, h uint8, cm map[rune]uint16, d string) *PixFont {
+	p := NewPixFont(w, h, cm, nil)
+	p.setROM(d)
+	return p
+}
+
+// NewSortedStringPixFont combines NewSortedPixFont and NewStringPixFont, for
+// fonts which keep both their character map and data out of RAM.
+func NewSortedStringPixFont(w, h uint8, runes []rune, offsets []uint16, d string) *PixFont {
+	p := NewSortedPixFont(w, h, runes, offsets, nil)
+	p.setROM(d)
+	return p
+}
+
+func (p *PixFont) setROM(d string) {
+	if len(d)%4 != 0 {
+		panic(fmt.Sprintf("pixfont: data string length %d is not a multiple of 4", len(d)))
+	}
+	p.rom = d
+}
+
+// DataString encodes packed font data as a string for NewStringPixFont, with
+// each word in little-endian byte order.
+func DataString(d []uint32) string {
+	b := make([]byte, 4*len(d))
+	for i, v := range d {
+		b[4*i] = byte(v)
+		b[4*i+1] = byte(v >> 8)
+		b[4*i+2] = byte(v >> 16)
+		b[4*i+3] = byte(v >> 24)
+	}
+	return string(b)
+}
+
+// word returns word i of the packed font data.
+func (p *PixFont) word(i int) uint32 {
+	if p.rom == "" {
+		return p.data[i]
+	}
+	s := p.rom[4*i : 4*i+4]
+	return uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24
+}
+
+// numWords returns the number of words of packed font data.
+func (p *PixFont) numWords() int {
+	if p.rom == "" {
+		return len(p.data)
+	}
+	return len(p.rom) / 4
+}
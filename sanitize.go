@@ -0,0 +1,44 @@
+package pixfont
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Sanitize returns s with every rune the font can't draw replaced, so that user
+// input can be normalized before it is drawn or used as a cache key. The same
+// transformations as drawing are applied first, such as normalization and
+// Arabic shaping. Then each rune without a glyph is, in order:
+//
+//   - kept if it is white space, a soft hyphen, or a rune that
+//     SetHangulComposition can compose;
+//   - folded to its base letter if the font has one, such as é to e, keeping
+//     any of its accents which are combining marks with glyphs;
+//   - replaced by the replacement rune, if one is set with SetReplacementRune
+//     and has a glyph;
+//   - otherwise removed.
+func (p *PixFont) Sanitize(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, c := range p.prepare(s) {
+		if p.HasGlyph(c) || unicode.IsSpace(c) || c == softHyphen {
+			b.WriteRune(c)
+			continue
+		}
+		if d := []rune(norm.NFD.String(string(c))); len(d) > 1 && p.HasGlyph(d[0]) {
+			b.WriteRune(d[0])
+			for _, m := range d[1:] {
+				if p.combining[m] && p.HasGlyph(m) {
+					b.WriteRune(m)
+				}
+			}
+			continue
+		}
+		if p.has(p.replacement) && p.replacement != 0 {
+			b.WriteRune(p.replacement)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package pixfont
+
+import "fmt"
+
+// scale2x doubles the w by h grid of values using the Scale2x (EPX) algorithm,
+// which rounds off diagonal edges instead of enlarging them into staircases.
+// Pixels outside the grid are treated as empty (-1).
+func scale2x(grid []int, w, h int) []int {
+	at := func(x, y int) int {
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return -1
+		}
+		return grid[y*w+x]
+	}
+	out := make([]int, 4*w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := grid[y*w+x]
+			a, b, c, d := at(x, y-1), at(x+1, y), at(x-1, y), at(x, y+1)
+			e := [4]int{p, p, p, p} // top-left, top-right, bottom-left, bottom-right
+			if c == a && c != d && a != b {
+				e[0] = a
+			}
+			if a == b && a != c && b != d {
+				e[1] = b
+			}
+			if d == c && d != b && c != a {
+				e[2] = c
+			}
+			if b == d && b != a && d != c {
+				e[3] = d
+			}
+			i := 2*y*2*w + 2*x
+			out[i], out[i+1] = e[0], e[1]
+			out[i+2*w], out[i+2*w+1] = e[2], e[3]
+		}
+	}
+	return out
+}
+
+// Scale2x returns a copy of the font at twice the size, with each glyph
+// enlarged using the Scale2x (EPX) algorithm so that diagonals stay smooth
+// instead of becoming staircases. The copy keeps the variable width setting
+// and metadata of p, but not drawing settings such as combining marks. Glyphs
+// are limited to 32 pixels wide, so fonts wider than 16 pixels can't be scaled.
+func (p *PixFont) Scale2x() (*PixFont, error) {
+	w, h := int(p.charWidth), int(p.charHeight)
+	if 2*w > 32 || 2*h > 255 {
+		return nil, fmt.Errorf("pixfont: %dx%d font is too large to scale", w, h)
+	}
+	d := make(map[rune]map[int]string, p.numRunes())
+	grid := make([]int, w*h)
+	for _, c := range p.Runes() {
+		for i := range grid {
+			grid[i] = -1
+		}
+		p.drawGlyph(func(x, y int) {
+			if x >= 0 && y >= 0 && x < w && y < h {
+				grid[y*w+x] = 0
+			}
+		}, 0, 0, c, p.Spacing())
+		big := scale2x(grid, w, h)
+		rows := make(map[int]string, 2*h)
+		for y := 0; y < 2*h; y++ {
+			row := make([]byte, 2*w)
+			for x := range row {
+				row[x] = ' '
+				if big[y*2*w+x] >= 0 {
+					row[x] = 'X'
+				}
+			}
+			rows[y] = string(row)
+		}
+		d[c] = rows
+	}
+
+	data, cm, err := Pack(2*w, 2*h, d)
+	if err != nil {
+		return nil, err
+	}
+	scaled := NewPixFont(uint8(2*w), uint8(2*h), cm, data)
+	scaled.SetVariableWidth(p.IsVariableWidth())
+	scaled.Name, scaled.Copyright, scaled.Comment = p.Name, p.Copyright, p.Comment
+	return scaled, nil
+}
@@ -0,0 +1,73 @@
+package pixfont
+
+import "image/color"
+
+// Span is a run of opaque pixels in a scanline, from X0 up to but not
+// including X1.
+type Span struct {
+	X0, X1 int
+}
+
+// Scanlines renders s one scanline at a time, from the top row of the font to
+// the bottom, calling fn with each row's y offset and its runs of opaque pixels
+// from left to right, for band-based encoders and displays with line buffers.
+// fn is called for every row, with no spans if the row is empty. Only one row
+// of the string is held in memory, and the spans passed to fn are reused for
+// the next row, so fn must copy any it keeps. The x offsets are from the start
+// of the string, within the width returned by MeasureString.
+func (p *PixFont) Scanlines(s string, fn func(y int, spans []Span)) {
+	rd := &rowDrawable{row: make([]bool, p.MeasureString(s))}
+	var spans []Span
+	for y := 0; y < int(p.charHeight); y++ {
+		for x := range rd.row {
+			rd.row[x] = false
+		}
+		rd.y = y
+		p.DrawString(rd, 0, 0, s, color.Black)
+
+		spans = spans[:0]
+		for x := 0; x < len(rd.row); x++ {
+			if !rd.row[x] {
+				continue
+			}
+			start := x
+			for x < len(rd.row) && rd.row[x] {
+				x++
+			}
+			spans = append(spans, Span{start, x})
+		}
+		fn(y, spans)
+	}
+}
+
+// rowDrawable keeps the opaque pixels of a single row.
+type rowDrawable struct {
+	row []bool
+	y   int
+}
+
+func (r *rowDrawable) Set(x, y int, _ color.Color) {
+	if y == r.y && x >= 0 && x < len(r.row) {
+		r.row[x] = true
+	}
+}
+
+// Run is a horizontal run of opaque pixels in row Y, from X0 up to but not
+// including X1.
+type Run struct {
+	Y, X0, X1 int
+}
+
+// StringRuns returns the pixels of s as horizontal runs, ordered from top to
+// bottom and left to right, with coordinates relative to the top-left corner of
+// the string. Plotters, laser engravers and vector exporters can trace the runs
+// without rasterizing the text to an image first.
+func (p *PixFont) StringRuns(s string) []Run {
+	var runs []Run
+	p.Scanlines(s, func(y int, spans []Span) {
+		for _, sp := range spans {
+			runs = append(runs, Run{y, sp.X0, sp.X1})
+		}
+	})
+	return runs
+}
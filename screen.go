@@ -0,0 +1,254 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+	"unicode/utf8"
+)
+
+// Cell is a character cell of a Screen. A nil FG or BG is drawn in the
+// screen's default foreground or background color.
+type Cell struct {
+	Rune   rune
+	FG, BG color.Color
+}
+
+// Screen is a terminal screen of cols by rows character cells, each with its
+// own colors, and a cursor, which is rendered with a fixed width font. Text
+// written to a Screen is placed at the cursor as a terminal would, so that the
+// output of a program can be replayed onto it and each state rendered as a
+// frame, such as for recording a terminal session to a GIF.
+//
+// Each cell is GetWidth by GetHeight pixels of the font, with no spacing
+// added, so that box drawing and block characters join up.
+type Screen struct {
+	font       *PixFont
+	cols, rows int
+	cells      []Cell
+	fg, bg     color.Color // the default colors
+	pen        Cell        // the colors of written text
+	cx, cy     int
+	cursor     bool
+	partial    []byte // an incomplete UTF-8 sequence from the last Write
+
+	// ANSI escape sequences, see SetANSI
+	ansi          bool
+	esc           []byte // the escape sequence being read
+	basicFG       int    // the SGR code of a basic foreground color, or 0
+	bold, inverse bool
+}
+
+// NewScreen creates a blank Screen of cols by rows cells rendered with f, with
+// text drawn in fg over bg by default, and the cursor visible at the top left.
+// A nil bg leaves the Drawable showing through behind the text, and a nil fg
+// draws no text.
+func NewScreen(f *PixFont, cols, rows int, fg, bg color.Color) *Screen {
+	return &Screen{
+		font:   f,
+		cols:   cols,
+		rows:   rows,
+		cells:  make([]Cell, cols*rows),
+		fg:     fg,
+		bg:     bg,
+		cursor: true,
+	}
+}
+
+// Size returns the number of columns and rows of the screen.
+func (s *Screen) Size() (cols, rows int) {
+	return s.cols, s.rows
+}
+
+// Bounds returns the rectangle covered by the screen when drawn at 0,0.
+func (s *Screen) Bounds() image.Rectangle {
+	return image.Rect(0, 0, s.cols*s.font.GetWidth(), s.rows*s.font.GetHeight())
+}
+
+// Cell returns the cell at column x and row y. Cells outside of the screen are
+// blank.
+func (s *Screen) Cell(x, y int) Cell {
+	if x < 0 || y < 0 || x >= s.cols || y >= s.rows {
+		return Cell{}
+	}
+	return s.cells[y*s.cols+x]
+}
+
+// SetCell sets the cell at column x and row y. Cells outside of the screen are
+// ignored.
+func (s *Screen) SetCell(x, y int, c Cell) {
+	if x < 0 || y < 0 || x >= s.cols || y >= s.rows {
+		return
+	}
+	s.cells[y*s.cols+x] = c
+}
+
+// Cursor returns the column and row of the cursor.
+func (s *Screen) Cursor() (x, y int) {
+	return s.cx, s.cy
+}
+
+// MoveCursor moves the cursor to column x and row y, limited to the screen.
+func (s *Screen) MoveCursor(x, y int) {
+	s.cx, s.cy = clampInt(x, 0, s.cols-1), clampInt(y, 0, s.rows-1)
+}
+
+// ShowCursor sets whether Draw draws the cursor, as a block with the colors of
+// its cell swapped. Toggling it between frames makes the cursor blink.
+func (s *Screen) ShowCursor(on bool) {
+	s.cursor = on
+}
+
+// SetColors sets the colors of text written from now on. Nil colors are the
+// screen's defaults.
+func (s *Screen) SetColors(fg, bg color.Color) {
+	s.pen.FG, s.pen.BG = fg, bg
+	s.basicFG = 0
+}
+
+// Clear blanks every cell and moves the cursor to the top left.
+func (s *Screen) Clear() {
+	for i := range s.cells {
+		s.cells[i] = Cell{}
+	}
+	s.cx, s.cy = 0, 0
+}
+
+// ScrollUp moves every row up by n rows, discarding the top n rows and leaving
+// n blank rows at the bottom. The cursor doesn't move.
+func (s *Screen) ScrollUp(n int) {
+	if n <= 0 {
+		return
+	}
+	if n > s.rows {
+		n = s.rows
+	}
+	copy(s.cells, s.cells[n*s.cols:])
+	for i := (s.rows - n) * s.cols; i < len(s.cells); i++ {
+		s.cells[i] = Cell{}
+	}
+}
+
+// Write implements io.Writer, placing the UTF-8 text of b at the cursor in the
+// current colors and moving the cursor along. Text wraps onto the next row at
+// the right edge, and the screen scrolls up when the cursor moves past the
+// bottom. Newlines move to the start of the next row, carriage returns to the
+// start of the row, backspaces one column left and tabs to the next multiple of
+// 8 columns; other control characters are ignored. A UTF-8 sequence split
+// between writes is kept until the rest arrives.
+func (s *Screen) Write(b []byte) (int, error) {
+	n := len(b)
+	if len(s.partial) > 0 {
+		b = append(s.partial, b...)
+		s.partial = nil
+	}
+	for len(b) > 0 {
+		if !utf8.FullRune(b) {
+			s.partial = append([]byte(nil), b...)
+			break
+		}
+		c, size := utf8.DecodeRune(b)
+		b = b[size:]
+		s.put(c)
+	}
+	return n, nil
+}
+
+// WriteString is like Write, but writes the contents of the string str.
+func (s *Screen) WriteString(str string) (int, error) {
+	return s.Write([]byte(str))
+}
+
+// put places c at the cursor, or carries out the control character c.
+func (s *Screen) put(c rune) {
+	if s.cols == 0 || s.rows == 0 {
+		return
+	}
+	if s.ansi && (len(s.esc) > 0 || c == 0x1b) {
+		s.escape(c)
+		return
+	}
+	switch {
+	case c == '\n':
+		s.cx = 0
+		s.lineFeed()
+	case c == '\r':
+		s.cx = 0
+	case c == '\b':
+		if s.cx > 0 {
+			s.cx--
+		}
+	case c == '\t':
+		s.cx = clampInt((s.cx/8+1)*8, 0, s.cols-1)
+	case c < ' ' || c == 0x7f:
+	default:
+		if s.cx >= s.cols {
+			// wrap only when the next rune arrives, so that the last
+			// column can be written without scrolling
+			s.cx = 0
+			s.lineFeed()
+		}
+		s.cells[s.cy*s.cols+s.cx] = s.penCell(c)
+		s.cx++
+	}
+}
+
+// lineFeed moves the cursor down a row, scrolling up at the bottom.
+func (s *Screen) lineFeed() {
+	if s.cy == s.rows-1 {
+		s.ScrollUp(1)
+		return
+	}
+	s.cy++
+}
+
+// Draw draws every cell of the screen, and the cursor if it is shown, with the
+// top-left corner of the screen at x,y.
+func (s *Screen) Draw(dr Drawable, x, y int) {
+	w, h := s.font.GetWidth(), s.font.GetHeight()
+	cx := s.cx
+	if cx >= s.cols {
+		cx = s.cols - 1 // past the last column, waiting to wrap
+	}
+	for row := 0; row < s.rows; row++ {
+		for col := 0; col < s.cols; col++ {
+			cell := s.cells[row*s.cols+col]
+			fg, bg := cell.FG, cell.BG
+			if fg == nil {
+				fg = s.fg
+			}
+			if bg == nil {
+				bg = s.bg
+			}
+			if s.cursor && col == cx && row == s.cy {
+				fg, bg = bg, fg
+			}
+			px, py := x+col*w, y+row*h
+			if bg != nil {
+				fillRect(setter(dr, bg), image.Rect(px, py, px+w, py+h), false)
+			}
+			if fg != nil && cell.Rune != 0 && cell.Rune != ' ' {
+				if u := s.font.usage; u != nil {
+					u.add(cell.Rune)
+				}
+				s.font.drawRune(setter(dr, fg), px, py, cell.Rune, 0)
+			}
+		}
+	}
+}
+
+// Image returns a new image of the screen, as drawn by Draw.
+func (s *Screen) Image() *image.RGBA {
+	img := image.NewRGBA(s.Bounds())
+	s.Draw(img, 0, 0)
+	return img
+}
+
+func clampInt(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// setter returns a function that sets the pixel at x,y in dr to clr. Destination
+// types with a known pixel layout get a fast path that converts clr once rather
+// than invoking the color model for every pixel.
+func setter(dr Drawable, clr color.Color) func(x, y int) {
+	switch img := dr.(type) {
+	case *image.Paletted:
+		// GIF frames: match the palette index once and write indices directly.
+		if len(img.Palette) == 0 {
+			break
+		}
+		idx := uint8(img.Palette.Index(clr))
+		return func(x, y int) {
+			if !(image.Point{x, y}.In(img.Rect)) {
+				return
+			}
+			img.Pix[img.PixOffset(x, y)] = idx
+		}
+	case *YCbCrDrawable:
+		// video frames: convert once and write the planes directly.
+		yy, cb, cr := ycbcr(clr)
+		return func(x, y int) {
+			img.set(x, y, yy, cb, cr)
+		}
+	}
+	return func(x, y int) {
+		dr.Set(x, y, clr)
+	}
+}
+
+// YCbCrDrawable adapts a *image.YCbCr, such as a decoded video frame, into a
+// Drawable so that timestamps and subtitles can be drawn without converting the
+// frame to RGBA. Drawing text with it converts the color once per string.
+//
+// With subsampled chroma (any ratio but 4:4:4), each chroma sample is shared
+// by several pixels, so pixels next to the text take on its chroma. This is not
+// noticeable for white, grey or black text.
+type YCbCrDrawable struct {
+	*image.YCbCr
+}
+
+// Set implements Drawable.
+func (d *YCbCrDrawable) Set(x, y int, c color.Color) {
+	yy, cb, cr := ycbcr(c)
+	d.set(x, y, yy, cb, cr)
+}
+
+func (d *YCbCrDrawable) set(x, y int, yy, cb, cr uint8) {
+	if !(image.Point{x, y}.In(d.Rect)) {
+		return
+	}
+	d.Y[d.YOffset(x, y)] = yy
+	ci := d.COffset(x, y)
+	d.Cb[ci] = cb
+	d.Cr[ci] = cr
+}
+
+// ycbcr converts c to Y'CbCr, ignoring alpha.
+func ycbcr(c color.Color) (uint8, uint8, uint8) {
+	if yc, ok := c.(color.YCbCr); ok {
+		return yc.Y, yc.Cb, yc.Cr
+	}
+	r, g, b, _ := c.RGBA()
+	return color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(b>>8))
+}
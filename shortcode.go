@@ -0,0 +1,118 @@
+package pixfont
+
+import (
+	"fmt"
+	"strings"
+)
+
+// shortcodeBase is the first rune given to custom shortcode glyphs, at the start
+// of Supplementary Private Use Area-A, where fonts are unlikely to have glyphs.
+const shortcodeBase = 0xF0000
+
+// Shortcodes expands chat-style shortcodes such as ":smile:" into the runes of
+// icons stored in a font. Each shortcode maps either to a rune which the font
+// already has a glyph for, or to a custom glyph which WithGlyphs adds to a copy
+// of the font:
+//
+//	sc := pixfont.NewShortcodes()
+//	sc.Add("block", '█')
+//	sc.AddGlyph("ok", " X", "X ")
+//	f, err := sc.WithGlyphs(pixfont.DefaultFont)
+//	f.DrawString(img, 0, 0, sc.Expand("Build :block: :ok:"), color.Black)
+type Shortcodes struct {
+	codes  map[string]rune
+	glyphs map[rune]map[int]string
+}
+
+// NewShortcodes creates an empty shortcode table.
+func NewShortcodes() *Shortcodes {
+	return &Shortcodes{
+		codes:  make(map[string]rune),
+		glyphs: make(map[rune]map[int]string),
+	}
+}
+
+// Add maps the shortcode name, without its colons, to r.
+func (sc *Shortcodes) Add(name string, r rune) {
+	sc.codes[name] = r
+}
+
+// AddGlyph maps the shortcode name, without its colons, to a custom glyph and
+// returns the rune it is given, from the Private Use Area. The glyph is given
+// as rows from the top, with an 'X' for each opaque pixel, as for Pack.
+func (sc *Shortcodes) AddGlyph(name string, rows ...string) rune {
+	r := rune(shortcodeBase + len(sc.glyphs))
+	glyph := make(map[int]string, len(rows))
+	for y, row := range rows {
+		glyph[y] = row
+	}
+	sc.glyphs[r] = glyph
+	sc.codes[name] = r
+	return r
+}
+
+// Expand returns s with each known shortcode replaced by its rune. Unknown
+// shortcodes, and colons which don't start one, are left as they are.
+func (sc *Shortcodes) Expand(s string) string {
+	if !strings.Contains(s, ":") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, ':')
+		if i < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+		j := strings.IndexByte(s[1:], ':') + 1
+		if j > 1 {
+			if r, ok := sc.codes[s[1:j]]; ok {
+				b.WriteRune(r)
+				s = s[j+1:]
+				continue
+			}
+		}
+		// keep the colon and look for a shortcode starting at the next one
+		b.WriteByte(':')
+		s = s[1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// WithGlyphs returns a copy of p which also has the custom glyphs added with
+// AddGlyph. Like Scale2x, the copy keeps the variable width setting and
+// metadata of p, but not drawing settings such as combining marks. It fails if
+// a custom glyph is larger than the font's character cell.
+func (sc *Shortcodes) WithGlyphs(p *PixFont) (*PixFont, error) {
+	w, h := int(p.charWidth), int(p.charHeight)
+	d := make(map[rune]map[int]string, p.numRunes()+len(sc.glyphs))
+	for _, c := range p.Runes() {
+		d[c] = p.glyphRows(c)
+	}
+	for name, r := range sc.codes {
+		glyph, ok := sc.glyphs[r]
+		if !ok {
+			continue
+		}
+		if len(glyph) > h {
+			return nil, fmt.Errorf("pixfont: glyph for :%s: is %d rows, taller than the font", name, len(glyph))
+		}
+		for _, row := range glyph {
+			if len(row) > w {
+				return nil, fmt.Errorf("pixfont: glyph for :%s: is %d pixels, wider than the font", name, len(row))
+			}
+		}
+		d[r] = glyph
+	}
+
+	data, cm, err := Pack(w, h, d)
+	if err != nil {
+		return nil, err
+	}
+	f := NewPixFont(uint8(w), uint8(h), cm, data)
+	f.SetVariableWidth(p.IsVariableWidth())
+	f.Name, f.Copyright, f.Comment = p.Name, p.Copyright, p.Comment
+	return f, nil
+}
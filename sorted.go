@@ -0,0 +1,85 @@
+package pixfont
+
+import "fmt"
+
+// NewSortedPixFont creates a new PixFont like NewPixFont, but with its character
+// map given as two parallel slices instead of a map: runes in ascending order,
+// and the offset of each rune's glyph in d. Glyphs are found by binary search.
+//
+// This avoids the memory and start up cost of building a map, which matters on
+// microcontrollers: with TinyGo, package level slices which are never modified
+// stay in flash, so the whole font can be used without copying it to RAM.
+// NewSortedPixFont panics if the slices differ in length or runes is not sorted.
+func NewSortedPixFont(w, h uint8, runes []rune, offsets []uint16, d []uint32) *PixFont {
+	if len(runes) != len(offsets) {
+		panic(fmt.Sprintf("pixfont: %d runes but %d offsets", len(runes), len(offsets)))
+	}
+	for i := 1; i < len(runes); i++ {
+		if runes[i] <= runes[i-1] {
+			panic(fmt.Sprintf("pixfont: runes are not sorted at %q", runes[i]))
+		}
+	}
+	p := NewPixFont(w, h, nil, d)
+	p.sorted, p.offsets = runes, offsets
+	return p
+}
+
+// SortedCharMap returns the character map of cm as runes in ascending order and
+// the matching offsets, as taken by NewSortedPixFont.
+func SortedCharMap(cm map[rune]uint16) ([]rune, []uint16) {
+	p := &PixFont{charmap: cm}
+	runes := p.Runes()
+	offsets := make([]uint16, len(runes))
+	for i, r := range runes {
+		offsets[i] = cm[r]
+	}
+	return runes, offsets
+}
+
+// lookup returns the offset of the glyph for c in the font data, and whether c
+// has a glyph, either of its own or that of its alias.
+func (p *PixFont) lookup(c rune) (uint16, bool) {
+	poff, ok := p.find(c)
+	if !ok && p.aliases != nil {
+		if a, isAlias := p.aliases[c]; isAlias {
+			return p.find(a)
+		}
+	}
+	return poff, ok
+}
+
+// find returns the offset of the glyph of c itself, and whether it has one.
+func (p *PixFont) find(c rune) (uint16, bool) {
+	if p.sorted == nil {
+		poff, ok := p.charmap[c]
+		return poff, ok
+	}
+	// a plain binary search, which unlike sort.Search needs no closure
+	lo, hi := 0, len(p.sorted)
+	for lo < hi {
+		m := int(uint(lo+hi) >> 1)
+		if p.sorted[m] < c {
+			lo = m + 1
+		} else {
+			hi = m
+		}
+	}
+	if lo < len(p.sorted) && p.sorted[lo] == c {
+		return p.offsets[lo], true
+	}
+	return 0, false
+}
+
+// has reports whether c has a glyph in the font.
+func (p *PixFont) has(c rune) bool {
+	_, ok := p.lookup(c)
+	return ok
+}
+
+// numRunes returns the number of runes with a glyph in the font.
+func (p *PixFont) numRunes() int {
+	if p.sorted != nil {
+		return len(p.sorted)
+	}
+	return len(p.charmap)
+}
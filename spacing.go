@@ -0,0 +1,41 @@
+package pixfont
+
+import "sync/atomic"
+
+// defaultSpacing is the spacing of fonts without their own, set atomically so
+// that it can be changed while other goroutines draw.
+var defaultSpacing int32 = 1
+
+// SetDefaultSpacing sets the number of pixels between runes for fonts which
+// don't have their own spacing set with SetSpacing, 1 to start with. It is safe
+// to call while other goroutines draw: each string is drawn or measured with
+// the spacing in effect when the call started, never a mix of two.
+func SetDefaultSpacing(n int) {
+	atomic.StoreInt32(&defaultSpacing, int32(n))
+}
+
+// DefaultSpacing returns the spacing set with SetDefaultSpacing, or the
+// deprecated Spacing variable if it has been changed from 1.
+func DefaultSpacing() int {
+	if Spacing != 1 {
+		return Spacing
+	}
+	return int(atomic.LoadInt32(&defaultSpacing))
+}
+
+// SetSpacing sets the number of pixels between the runes of this font, in
+// place of the default spacing. A negative n restores the default. Like the
+// other settings, it should not be called while the font is drawing.
+func (p *PixFont) SetSpacing(n int) {
+	p.spacing, p.ownSpacing = n, n >= 0
+	p.cache.reset()
+}
+
+// Spacing returns the number of pixels between the runes of this font, either
+// its own spacing or the default.
+func (p *PixFont) Spacing() int {
+	if p.ownSpacing {
+		return p.spacing
+	}
+	return DefaultSpacing()
+}
@@ -0,0 +1,259 @@
+// Package textfmt reads and writes the text representation of pixel fonts, as
+// written by bdf2pixfont and ebdt2pixfont and edited by hand before fontgen
+// turns it into code. Each line of the text is one of:
+//
+//	line     = glyphrow | metadata | comment | blank
+//	glyphrow = rune "  [" { pixel } "]"
+//	pixel    = "X" | any other character
+//	metadata = "# " ( "Name" | "Copyright" | "Comment" ) ":" value
+//	comment  = "#" text, except a glyphrow for the rune '#'
+//	blank    = { " " | "\t" }
+//
+// An "X" is an opaque pixel, and any other character is blank. Consecutive
+// glyph rows for the same rune form its glyph from top to bottom, and a rune's
+// rows may not be split up by rows for another rune. Lines may end with "\n" or
+// "\r\n". Comment metadata may be repeated, and the values are joined with
+// newlines. For example, a 3x3 font with metadata:
+//
+//	# Name: Tiny
+//	# Copyright: Public domain
+//	+  [ X ]
+//	+  [XXX]
+//	+  [ X ]
+//	-  [   ]
+//	-  [XXX]
+//	-  [   ]
+package textfmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Font is a pixel font in the text representation.
+type Font struct {
+	// Name, Copyright and Comment are the metadata of the font.
+	Name      string
+	Copyright string
+	Comment   string
+
+	// Width and Height are the size of the character cell, which is large
+	// enough to hold every glyph.
+	Width, Height int
+	// Order lists the runes of Glyphs in the order they are written. Runes
+	// missing from Order are written after it in ascending order.
+	Order []rune
+	// Glyphs holds each glyph as a map from row number to a string with an
+	// 'X' for every opaque pixel, as for pixfont.Pack.
+	Glyphs map[rune]map[int]string
+}
+
+// Parse reads a font in the text representation from r. Errors give the line
+// number of the problem.
+func Parse(r io.Reader) (*Font, error) {
+	f := &Font{Glyphs: make(map[rune]map[int]string)}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	var comments []string
+	var last rune
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if strings.TrimLeft(line, " \t") == "" {
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(line)
+		if c == '#' && !strings.HasPrefix(line[size:], "  [") {
+			switch key, value := metadata(line); key {
+			case "Name":
+				f.Name = value
+			case "Copyright":
+				f.Copyright = value
+			case "Comment":
+				comments = append(comments, value)
+			}
+			continue
+		}
+		if c == utf8.RuneError && size <= 1 {
+			return nil, fmt.Errorf("textfmt: line %d: invalid UTF-8", n)
+		}
+		if !strings.HasPrefix(line[size:], "  [") || !strings.HasSuffix(line, "]") || len(line) < size+4 {
+			return nil, fmt.Errorf("textfmt: line %d: expected a glyph row like \"A  [ XX ]\"", n)
+		}
+
+		glyph, seen := f.Glyphs[c]
+		if !seen || c != last {
+			if seen {
+				return nil, fmt.Errorf("textfmt: line %d: rows for %q are split up by other glyphs", n, c)
+			}
+			glyph = make(map[int]string)
+			f.Glyphs[c] = glyph
+			f.Order = append(f.Order, c)
+		}
+		last = c
+
+		var row strings.Builder
+		for _, px := range line[size+3 : len(line)-1] {
+			if px == 'X' {
+				row.WriteByte('X')
+			} else {
+				row.WriteByte(' ')
+			}
+		}
+		y := len(glyph)
+		glyph[y] = row.String()
+		if row.Len() > f.Width {
+			f.Width = row.Len()
+		}
+		if y+1 > f.Height {
+			f.Height = y + 1
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("textfmt: line %d: %v", n+1, err)
+	}
+	f.Comment = strings.Join(comments, "\n")
+	return f, nil
+}
+
+// metadata returns the key and value of a metadata line, or an empty key if
+// line is a plain comment.
+func metadata(line string) (key, value string) {
+	if !strings.HasPrefix(line, "# ") {
+		return "", ""
+	}
+	parts := strings.SplitN(line[2:], ":", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// Format writes f to w in the text representation. Every glyph is written
+// f.Height rows tall, with each row padded on the right to f.Width pixels.
+// Newlines in the Name and Copyright are replaced with spaces, and each line of
+// the Comment is written as its own metadata line. Glyphs for newlines and
+// invalid runes can't be written.
+func Format(w io.Writer, f *Font) error {
+	rs := f.runes()
+	for _, c := range rs {
+		if c == '\n' || !utf8.ValidRune(c) {
+			return fmt.Errorf("textfmt: can't write a glyph for %q", c)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	oneLine := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+	if name := strings.TrimSpace(oneLine.Replace(f.Name)); name != "" {
+		fmt.Fprintf(bw, "# Name: %s\n", name)
+	}
+	if c := strings.TrimSpace(oneLine.Replace(f.Copyright)); c != "" {
+		fmt.Fprintf(bw, "# Copyright: %s\n", c)
+	}
+	if strings.TrimSpace(f.Comment) != "" {
+		for _, c := range strings.Split(f.Comment, "\n") {
+			fmt.Fprintf(bw, "# Comment: %s\n", strings.TrimSpace(c))
+		}
+	}
+
+	row := make([]byte, 0, f.Width)
+	for _, c := range rs {
+		glyph := f.Glyphs[c]
+		for y := 0; y < f.Height; y++ {
+			row = row[:0]
+			ld := glyph[y]
+			for x := 0; x < f.Width; x++ {
+				if x < len(ld) && ld[x] == 'X' {
+					row = append(row, 'X')
+				} else {
+					row = append(row, ' ')
+				}
+			}
+			fmt.Fprintf(bw, "%c  [%s]\n", c, row)
+		}
+	}
+	return bw.Flush()
+}
+
+// runes returns the runes of f.Glyphs in the order they are written.
+func (f *Font) runes() []rune {
+	rs := make([]rune, 0, len(f.Glyphs))
+	done := make(map[rune]bool, len(f.Glyphs))
+	for _, c := range f.Order {
+		if _, ok := f.Glyphs[c]; ok && !done[c] {
+			rs = append(rs, c)
+			done[c] = true
+		}
+	}
+	rest := len(rs)
+	for c := range f.Glyphs {
+		if !done[c] {
+			rs = append(rs, c)
+		}
+	}
+	sort.Slice(rs[rest:], func(i, j int) bool { return rs[rest+i] < rs[rest+j] })
+	return rs
+}
+
+// PixFont packs f into a fixed width PixFont, carrying over its metadata. It
+// fails if the glyphs are too large for a PixFont, which allows at most 32 by
+// 255 pixels.
+func (f *Font) PixFont() (*pixfont.PixFont, error) {
+	if f.Width > 32 || f.Height > 255 {
+		return nil, fmt.Errorf("textfmt: %dx%d glyphs are too large for a PixFont", f.Width, f.Height)
+	}
+	data, cm, err := pixfont.Pack(f.Width, f.Height, f.Glyphs)
+	if err != nil {
+		return nil, err
+	}
+	p := pixfont.NewPixFont(uint8(f.Width), uint8(f.Height), cm, data)
+	p.Name, p.Copyright, p.Comment = f.Name, f.Copyright, f.Comment
+	return p, nil
+}
+
+// FromPixFont returns the glyphs and metadata of p in the text representation,
+// with its runes in ascending order.
+func FromPixFont(p *pixfont.PixFont) *Font {
+	f := &Font{
+		Name:      p.Name,
+		Copyright: p.Copyright,
+		Comment:   p.Comment,
+		Width:     p.GetWidth(),
+		Height:    p.GetHeight(),
+		Order:     p.Runes(),
+		Glyphs:    make(map[rune]map[int]string),
+	}
+	for _, c := range f.Order {
+		m := p.GlyphMask(c)
+		glyph := make(map[int]string, f.Height)
+		row := make([]byte, f.Width)
+		for y := 0; y < f.Height; y++ {
+			for x := range row {
+				row[x] = ' '
+				if m.AlphaAt(x, y).A != 0 {
+					row[x] = 'X'
+				}
+			}
+			glyph[y] = string(row)
+		}
+		f.Glyphs[c] = glyph
+	}
+	return f
+}
+
+// Load reads a font in the text representation from r and packs it into a
+// PixFont, so that text fonts can be loaded at run time.
+func Load(r io.Reader) (*pixfont.PixFont, error) {
+	f, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return f.PixFont()
+}
@@ -0,0 +1,113 @@
+package textfmt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+)
+
+const tiny = `# Name: Tiny
+# Copyright: Public domain
+# Comment: line one
+# just a comment
+# Comment: line two
++  [ X ]
++  [XXX]
++  [ X ]
+
+#  [X X]
+#  [.X.]
+-  []
+-  [XXX]` + "\r\n"
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(tiny))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "Tiny" || f.Copyright != "Public domain" || f.Comment != "line one\nline two" {
+		t.Errorf("got metadata %q, %q, %q", f.Name, f.Copyright, f.Comment)
+	}
+	if f.Width != 3 || f.Height != 3 {
+		t.Errorf("got %dx%d, want 3x3", f.Width, f.Height)
+	}
+	if string(f.Order) != "+#-" {
+		t.Errorf("got order %q, want %q", string(f.Order), "+#-")
+	}
+	if got := f.Glyphs['#'][1]; got != " X " {
+		t.Errorf("got row %q, want %q", got, " X ")
+	}
+	if got := f.Glyphs['-'][1]; got != "XXX" {
+		t.Errorf("got row %q, want %q", got, "XXX")
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Name: Tiny\n# Copyright: Public domain\n# Comment: line one\n# Comment: line two\n" +
+		"+  [ X ]\n+  [XXX]\n+  [ X ]\n#  [X X]\n#  [ X ]\n#  [   ]\n-  [   ]\n-  [XXX]\n-  [   ]\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{
+		"A [X]\n",
+		"A  [X\n",
+		"A  [X]\nB  [X]\nA  [X]\n",
+		"\xff  [X]\n",
+	} {
+		if _, err := Parse(strings.NewReader(s)); err == nil {
+			t.Errorf("Parse(%q) succeeded", s)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Format(&buf, FromPixFont(pixfont.Font8x8)); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != pixfont.Font8x8.Name || len(f.Runes()) != len(pixfont.Font8x8.Runes()) {
+		t.Fatal("loaded font differs from the original")
+	}
+	for _, r := range pixfont.Font8x8.Runes() {
+		if !bytes.Equal(f.GlyphMask(r).Pix, pixfont.Font8x8.GlyphMask(r).Pix) {
+			t.Errorf("glyph %q differs from the original", r)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(tiny)
+	f.Add("A  [X]\n\nA  [ X]\n")
+	f.Add("# Comment: \n# Comment: x\n")
+	f.Fuzz(func(t *testing.T, s string) {
+		font, err := Parse(strings.NewReader(s))
+		if err != nil {
+			return
+		}
+		var first, second bytes.Buffer
+		if err := Format(&first, font); err != nil {
+			return
+		}
+		font2, err := Parse(bytes.NewReader(first.Bytes()))
+		if err != nil {
+			t.Fatalf("can't parse formatted font: %v\n%s", err, first.String())
+		}
+		if err := Format(&second, font2); err != nil {
+			t.Fatal(err)
+		}
+		if first.String() != second.String() {
+			t.Fatalf("formatting is not stable:\n%q\n%q", first.String(), second.String())
+		}
+	})
+}
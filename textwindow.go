@@ -0,0 +1,128 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Shifter is implemented by Drawables which can move pixels more cheaply than
+// they can be redrawn, such as display drivers with a hardware copy or scroll
+// command. TextWindow uses it to scroll.
+type Shifter interface {
+	// ShiftUp moves the pixels of r up by n rows. The bottom n rows of r may
+	// be left with any content.
+	ShiftUp(r image.Rectangle, n int)
+}
+
+// TextWindow is a scrolling text console bound to a fixed rectangle of a
+// Drawable, for logs rendered onto images or small displays. New lines are
+// appended at the bottom, and once the window is full the older lines scroll
+// up out of view.
+//
+// TextWindow draws as little as it can: appending a line draws only that line,
+// and scrolling moves the existing pixels up when the Drawable is a Shifter or
+// a draw.Image, clearing only the rows uncovered at the bottom. Other Drawables
+// are cleared and redrawn in full.
+type TextWindow struct {
+	font   *PixFont
+	dr     Drawable
+	rect   image.Rectangle
+	fg, bg color.Color
+	lines  []string // the visible lines, from the top
+}
+
+// NewTextWindow creates an empty TextWindow drawing f text in fg onto the
+// rectangle r of dr, over the background color bg, or transparent if bg is nil.
+// The window is cleared to bg. Lines are one font height apart, and are clipped
+// to r.
+func NewTextWindow(dr Drawable, r image.Rectangle, f *PixFont, fg, bg color.Color) *TextWindow {
+	if bg == nil {
+		bg = color.Transparent
+	}
+	w := &TextWindow{font: f, dr: dr, rect: r, fg: fg, bg: bg}
+	w.Clear()
+	return w
+}
+
+// Rows returns the number of lines that fit in the window.
+func (w *TextWindow) Rows() int {
+	return w.rect.Dy() / w.font.GetHeight()
+}
+
+// Lines returns the lines visible in the window, from the top.
+func (w *TextWindow) Lines() []string {
+	return append([]string(nil), w.lines...)
+}
+
+// AppendLine adds s at the bottom of the window, first scrolling up by one line
+// if the window is full.
+func (w *TextWindow) AppendLine(s string) {
+	rows := w.Rows()
+	if rows == 0 {
+		return
+	}
+	if len(w.lines) == rows {
+		w.ScrollUp(1)
+	}
+	w.lines = append(w.lines, s)
+	w.drawLine(len(w.lines) - 1)
+}
+
+// ScrollUp scrolls the window up by n lines, discarding the top n lines and
+// leaving n empty lines at the bottom.
+func (w *TextWindow) ScrollUp(n int) {
+	if n <= 0 {
+		return
+	}
+	if n >= len(w.lines) {
+		w.Clear()
+		return
+	}
+	w.lines = append(w.lines[:0], w.lines[n:]...)
+
+	h := w.font.GetHeight()
+	area := image.Rect(w.rect.Min.X, w.rect.Min.Y, w.rect.Max.X, w.rect.Min.Y+w.Rows()*h)
+	if !w.shift(area, n*h) {
+		w.Redraw()
+		return
+	}
+	// clear the rows below the remaining lines
+	area.Min.Y += len(w.lines) * h
+	fillRect(setter(w.dr, w.bg), area, false)
+}
+
+// shift moves the pixels of r up by dy rows, if dr supports it.
+func (w *TextWindow) shift(r image.Rectangle, dy int) bool {
+	switch dst := w.dr.(type) {
+	case Shifter:
+		dst.ShiftUp(r, dy)
+	case draw.Image:
+		draw.Draw(dst, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Max.Y-dy), dst, image.Pt(r.Min.X, r.Min.Y+dy), draw.Src)
+	default:
+		return false
+	}
+	return true
+}
+
+// Clear removes every line and clears the window to the background color.
+func (w *TextWindow) Clear() {
+	w.lines = w.lines[:0]
+	fillRect(setter(w.dr, w.bg), w.rect, false)
+}
+
+// Redraw clears the window and draws every line again, such as after something
+// else has drawn over it.
+func (w *TextWindow) Redraw() {
+	fillRect(setter(w.dr, w.bg), w.rect, false)
+	for i := range w.lines {
+		w.drawLine(i)
+	}
+}
+
+// drawLine draws line i onto the window, which must already be clear.
+func (w *TextWindow) drawLine(i int) {
+	y := w.rect.Min.Y + i*w.font.GetHeight()
+	cd := &clipDrawable{w.dr, w.rect}
+	w.font.DrawString(cd, w.rect.Min.X, y, w.lines[i], w.fg)
+}
@@ -0,0 +1,41 @@
+package pixfont
+
+// Thai and Lao vowels and tone marks drawn above or below their consonant.
+var (
+	thaiAbove = []rune{0x0e31, 0x0e34, 0x0e35, 0x0e36, 0x0e37, 0x0e47, 0x0e48, 0x0e49, 0x0e4a, 0x0e4b, 0x0e4c, 0x0e4d, 0x0e4e}
+	thaiBelow = []rune{0x0e38, 0x0e39, 0x0e3a}
+	laoAbove  = []rune{0x0eb1, 0x0eb4, 0x0eb5, 0x0eb6, 0x0eb7, 0x0ebb, 0x0ec8, 0x0ec9, 0x0eca, 0x0ecb, 0x0ecc, 0x0ecd}
+	laoBelow  = []rune{0x0eb8, 0x0eb9, 0x0ebc}
+)
+
+// SetStackedMarks flags the glyphs for rs as combining marks which stack. When
+// several marks from rs follow the same base rune, each is drawn dy pixels
+// below the one before it, so a negative dy stacks marks upwards. The first
+// mark is drawn where its glyph is designed. Marks from separate calls to
+// SetStackedMarks stack independently, so that marks above and below a base
+// don't move each other. Calling SetCombining removes all stacked marks.
+func (p *PixFont) SetStackedMarks(dy int, rs ...rune) {
+	if p.combining == nil {
+		p.combining = make(map[rune]bool, len(rs))
+	}
+	if p.stacking == nil {
+		p.stacking = make(map[rune]int, len(rs))
+	}
+	for _, r := range rs {
+		p.combining[r] = true
+		p.stacking[r] = len(p.stacks)
+	}
+	p.stacks = append(p.stacks, dy)
+	p.cache.reset()
+}
+
+// SetThaiMarks flags the Thai and Lao vowels and tone marks which are written
+// above or below a consonant as stacked marks, so that text such as that of a
+// converted BDF font is drawn correctly. Marks above a consonant stack rise
+// pixels upwards, such as a tone mark over an above vowel, and marks below stack
+// rise pixels downwards. The glyphs of the marks should be designed in their
+// position closest to the consonant.
+func (p *PixFont) SetThaiMarks(rise int) {
+	p.SetStackedMarks(-rise, append(append([]rune{}, thaiAbove...), laoAbove...)...)
+	p.SetStackedMarks(rise, append(append([]rune{}, thaiBelow...), laoBelow...)...)
+}
@@ -0,0 +1,65 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// Ticker scrolls a string horizontally through a fixed-width viewport, wrapping
+// around when the end of the text is reached, like an LED sign or status bar.
+// Each frame advances the text by one pixel.
+type Ticker struct {
+	// Font is the PixFont used to draw Text.
+	Font *PixFont
+	// Text is the string to scroll.
+	Text string
+	// Width is the width of the viewport in pixels.
+	Width int
+	// Gap is the number of blank pixels between the end of Text and the start of
+	// its next repetition.
+	Gap int
+}
+
+// NewTicker creates a new Ticker for s with the given viewport width. The gap
+// between repetitions defaults to the viewport width, so that the text scrolls
+// completely out of view before it reappears.
+func NewTicker(f *PixFont, width int, s string) *Ticker {
+	return &Ticker{Font: f, Text: s, Width: width, Gap: width}
+}
+
+// Frames returns the number of frames in one complete scrolling cycle. Frame
+// numbers passed to DrawFrame wrap around at this value.
+func (t *Ticker) Frames() int {
+	n := t.Font.MeasureString(t.Text) + t.Gap
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// DrawFrame draws frame n of the scrolling text into dr, with the top-left corner
+// of the viewport at x,y. Pixels outside of the viewport are not touched.
+func (t *Ticker) DrawFrame(dr Drawable, x, y, n int, clr color.Color) {
+	period := t.Frames()
+	offset := n % period
+	if offset < 0 {
+		offset += period
+	}
+
+	cd := &clipDrawable{dr, image.Rect(x, y, x+t.Width, y+t.Font.GetHeight())}
+	for xx := x - offset; xx < x+t.Width; xx += period {
+		t.Font.DrawString(cd, xx, y, t.Text, clr)
+	}
+}
+
+// clipDrawable is a Drawable that discards pixels outside of a clipping rectangle.
+type clipDrawable struct {
+	dr   Drawable
+	clip image.Rectangle
+}
+
+func (c *clipDrawable) Set(x, y int, clr color.Color) {
+	if (image.Point{x, y}).In(c.clip) {
+		c.dr.Set(x, y, clr)
+	}
+}
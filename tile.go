@@ -0,0 +1,33 @@
+package pixfont
+
+import (
+	"image"
+	"image/color"
+)
+
+// TileString fills r with repeated copies of s in the given color, such as for
+// watermarking documents and preview images. Copies are gap.X pixels apart
+// within a row, rows are gap.Y pixels apart, and each row starts stagger pixels
+// further right than the one above it, so that the copies don't line up in
+// columns. Copies are clipped to r.
+func (p *PixFont) TileString(dr Drawable, r image.Rectangle, s string, clr color.Color, gap image.Point, stagger int) {
+	d := p.Prerender(s, clr)
+	stepX, stepY := d.Width+gap.X, d.Height+gap.Y
+	if stepX <= 0 || stepY <= 0 {
+		return
+	}
+	set := setter(dr, clr)
+	for row, y := 0, r.Min.Y; y < r.Max.Y; row, y = row+1, y+stepY {
+		offset := (row * stagger) % stepX
+		if offset > 0 {
+			offset -= stepX
+		}
+		for x := r.Min.X + offset; x < r.Max.X; x += stepX {
+			for _, pt := range d.points {
+				if pt = pt.Add(image.Pt(x, y)); pt.In(r) {
+					set(pt.X, pt.Y)
+				}
+			}
+		}
+	}
+}
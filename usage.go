@@ -0,0 +1,107 @@
+package pixfont
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// runeUsage is the set of runes drawn while usage tracking is on, safe for
+// concurrent use.
+type runeUsage struct {
+	mu    sync.Mutex
+	runes map[rune]bool
+}
+
+// add adds rs to the set. Soft hyphens are skipped, as they are invisible.
+func (u *runeUsage) add(rs ...rune) {
+	u.mu.Lock()
+	for _, c := range rs {
+		if c != softHyphen {
+			u.runes[c] = true
+		}
+	}
+	u.mu.Unlock()
+}
+
+// recordUsage records the runes of s, as prepared for drawing, if usage
+// tracking is on. It is called by the exported drawing methods, so that
+// measuring the font and probing its glyphs are not recorded.
+func (p *PixFont) recordUsage(s string) {
+	if u := p.usage; u != nil {
+		u.add([]rune(p.prepare(s))...)
+	}
+}
+
+// TrackUsage turns recording of the runes drawn with this font on or off.
+// Turning it on starts with an empty record. Run an application with tracking
+// on, then use UsedRunes or UsedRanges to see which glyphs it really needs and
+// Subset to trim the font down to them, which saves space on embedded targets.
+// Only runes which are drawn are recorded, not those which are only measured.
+// Runes are recorded as they appear in the text, so a composed Hangul syllable
+// is recorded rather than its jamo, and a rune missing from the font is
+// recorded rather than the replacement drawn in its place. Like the other
+// settings, TrackUsage should not be called while the font is drawing.
+func (p *PixFont) TrackUsage(on bool) {
+	if !on {
+		p.usage = nil
+		return
+	}
+	p.usage = &runeUsage{runes: make(map[rune]bool)}
+}
+
+// UsedRunes returns the runes drawn since TrackUsage was turned on, in
+// ascending order, or nil if it is off.
+func (p *PixFont) UsedRunes() []rune {
+	u := p.usage
+	if u == nil {
+		return nil
+	}
+	u.mu.Lock()
+	rs := make([]rune, 0, len(u.runes))
+	for r := range u.runes {
+		rs = append(rs, r)
+	}
+	u.mu.Unlock()
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i] < rs[j]
+	})
+	return rs
+}
+
+// UsedRanges returns the runes drawn since TrackUsage was turned on as a
+// comma separated list of ranges, such as "U+0020,U+0041-005A", which can be passed
+// to the -range flag of fontgen to generate a subset of the font's source.
+func (p *PixFont) UsedRanges() string {
+	var parts []string
+	for _, r := range mergeRanges(p.UsedRunes()) {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// Subset returns a copy of the font with only the glyphs for runes, such as
+// those returned by UsedRunes. Hangul syllables which p composes from jamo are
+// given glyphs of their own, and runes without a glyph are ignored. Like
+// Scale2x, the copy keeps the variable width setting and metadata of p, but
+// not drawing settings such as combining marks. It fails if Pack can't pack
+// the glyphs, which is only possible when p shares glyph data between runes.
+func (p *PixFont) Subset(runes []rune) (*PixFont, error) {
+	w, h := int(p.charWidth), int(p.charHeight)
+	d := make(map[rune]map[int]string, len(runes))
+	for _, c := range runes {
+		if !p.has(c) && !(p.hangul && p.hangulJamo(c) != nil) {
+			continue
+		}
+		d[c] = p.glyphRows(c)
+	}
+
+	data, cm, err := Pack(w, h, d)
+	if err != nil {
+		return nil, err
+	}
+	f := NewPixFont(uint8(w), uint8(h), cm, data)
+	f.SetVariableWidth(p.IsVariableWidth())
+	f.Name, f.Copyright, f.Comment = p.Name, p.Copyright, p.Comment
+	return f, nil
+}
@@ -0,0 +1,221 @@
+package pixfont
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+)
+
+// PixFontV2 is a pixel font stored in the second version of the packed data
+// layout, which lifts the limits of PixFont: glyphs may be up to 64 pixels wide,
+// the data may be larger than the 16-bit charmap offsets of PixFont allow, and
+// each glyph has an explicit advance instead of one derived from its pixels.
+//
+// In the v2 layout each glyph is stored as charHeight consecutive uint64 rows,
+// with the leftmost pixel in the least significant bit. Each charmap entry
+// holds the index of the glyph's first row shifted left by 8 bits, with the
+// glyph's advance in the low 8 bits. Existing generated fonts keep using
+// NewPixFont, and can be converted with UpgradeFont.
+type PixFontV2 struct {
+	// Name, Copyright and Comment describe the font, as for PixFont.
+	Name      string
+	Copyright string
+	Comment   string
+
+	charWidth  int
+	charHeight int
+	missing    int // advance of runes without a glyph
+	spacing    int
+	ownSpacing bool // spacing was set with SetSpacing
+	charmap    map[rune]uint32
+	data       []uint64
+}
+
+// NewPixFontV2 creates a new PixFontV2 with the provided character cell size,
+// and charmap and data in the v2 layout, as created by PackV2. Runes without a
+// glyph advance by the cell width, unless changed with SetMissingAdvance.
+func NewPixFontV2(w, h int, cm map[rune]uint32, d []uint64) *PixFontV2 {
+	return &PixFontV2{
+		charWidth:  w,
+		charHeight: h,
+		missing:    w,
+		charmap:    cm,
+		data:       d,
+	}
+}
+
+// PackV2 packs a textual representation of a pixel font, in the same form as
+// for Pack, into the v2 layout for NewPixFontV2. advances gives the advance of
+// each glyph, and glyphs missing from it (or all glyphs, if it is nil) advance
+// by the cell width w. PackV2 returns an error if the glyphs are wider than 64
+// pixels, if an advance is negative or more than 255, or if there is more data
+// than the charmap can address.
+func PackV2(w, h int, d map[rune]map[int]string, advances map[rune]int) ([]uint64, map[rune]uint32, error) {
+	if w < 1 || w > 64 || h < 0 {
+		return nil, nil, fmt.Errorf("pixfont: can't pack %dx%d glyphs, at most 64 pixels wide are supported", w, h)
+	}
+	chs := make([]rune, 0, len(d))
+	for ch := range d {
+		chs = append(chs, ch)
+	}
+	sort.Slice(chs, func(i, j int) bool { return chs[i] < chs[j] })
+
+	cm := make(map[rune]uint32, len(d))
+	encoded := make([]uint64, 0, len(d)*h)
+	for _, c := range chs {
+		adv, ok := advances[c]
+		if !ok {
+			adv = w
+		}
+		if adv < 0 || adv > 255 {
+			return nil, nil, fmt.Errorf("pixfont: advance %d of %q is outside 0-255", adv, c)
+		}
+		if len(encoded) >= 1<<24 {
+			return nil, nil, fmt.Errorf("pixfont: too much glyph data to pack at %q", c)
+		}
+		cm[c] = uint32(len(encoded))<<8 | uint32(adv)
+		for y := 0; y < h; y++ {
+			var line uint64
+			ld := d[c][y]
+			for x := 0; x < w && x < 64; x++ {
+				if len(ld) > x && ld[x] == 'X' {
+					line |= 1 << uint(x)
+				}
+			}
+			encoded = append(encoded, line)
+		}
+	}
+	return encoded, cm, nil
+}
+
+// UpgradeFont converts old into the v2 layout. The upgraded font draws the same
+// glyphs with the same advances as old, using the spacing of old to convert
+// variable width advances, and keeps the spacing if old has its own. Drawing
+// settings such as normalization, combining marks and the replacement rune are
+// not carried over. It fails if the spacing makes an advance too large for
+// PackV2.
+func UpgradeFont(old *PixFont) (*PixFontV2, error) {
+	w, h := int(old.charWidth), int(old.charHeight)
+	d := make(map[rune]map[int]string, old.numRunes())
+	advances := make(map[rune]int, old.numRunes())
+	sp := old.Spacing()
+	for _, c := range old.Runes() {
+		rows := make([][]byte, h)
+		for y := range rows {
+			rows[y] = make([]byte, w)
+			for x := range rows[y] {
+				rows[y][x] = ' '
+			}
+		}
+		_, advances[c] = old.drawRune(func(x, y int) {
+			rows[y][x] = 'X'
+		}, 0, 0, c, sp)
+		d[c] = make(map[int]string, h)
+		for y, row := range rows {
+			d[c][y] = string(row)
+		}
+	}
+
+	data, cm, err := PackV2(w, h, d, advances)
+	if err != nil {
+		return nil, err
+	}
+	p := NewPixFontV2(w, h, cm, data)
+	p.Name, p.Copyright, p.Comment = old.Name, old.Copyright, old.Comment
+	p.missing = int(old.varCharWidth)
+	p.spacing, p.ownSpacing = old.spacing, old.ownSpacing
+	return p, nil
+}
+
+// SetMissingAdvance sets the advance of runes which have no glyph in the font.
+func (p *PixFontV2) SetMissingAdvance(n int) {
+	p.missing = n
+}
+
+// SetSpacing sets the number of pixels between the runes of this font, in
+// place of the default spacing. A negative n restores the default.
+func (p *PixFontV2) SetSpacing(n int) {
+	p.spacing, p.ownSpacing = n, n >= 0
+}
+
+// Spacing returns the number of pixels between the runes of this font, either
+// its own spacing or the default.
+func (p *PixFontV2) Spacing() int {
+	if p.ownSpacing {
+		return p.spacing
+	}
+	return DefaultSpacing()
+}
+
+// GetHeight returns the height of the font in pixels.
+func (p *PixFontV2) GetHeight() int {
+	return p.charHeight
+}
+
+// GetWidth returns the width of the font's character cell in pixels.
+func (p *PixFontV2) GetWidth() int {
+	return p.charWidth
+}
+
+// Runes returns every rune that has a glyph in the font, in ascending order.
+func (p *PixFontV2) Runes() []rune {
+	rs := make([]rune, 0, len(p.charmap))
+	for r := range p.charmap {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i] < rs[j] })
+	return rs
+}
+
+// DrawRune draws a single rune like PixFont.DrawRune, returning false if the
+// rune has no glyph, and the rune's advance.
+func (p *PixFontV2) DrawRune(dr Drawable, x, y int, c rune, clr color.Color) (bool, int) {
+	return p.drawRune(setter(dr, clr), x, y, c)
+}
+
+// drawRune calls set, if non-nil, for each opaque pixel of the rune.
+func (p *PixFontV2) drawRune(set func(x, y int), x, y int, c rune) (bool, int) {
+	e, ok := p.charmap[c]
+	if !ok {
+		return false, p.missing
+	}
+	if set != nil {
+		rows := p.data[e>>8 : int(e>>8)+p.charHeight]
+		for yy, row := range rows {
+			for xx := 0; row != 0; xx, row = xx+1, row>>1 {
+				if row&1 != 0 {
+					set(x+xx, y+yy)
+				}
+			}
+		}
+	}
+	return true, int(e & 0xff)
+}
+
+// DrawString draws s like PixFont.DrawString, adding the font's spacing between
+// runes, and returns the x position following the string.
+func (p *PixFontV2) DrawString(dr Drawable, x, y int, s string, clr color.Color) int {
+	set := setter(dr, clr)
+	sp := p.Spacing()
+	for _, c := range s {
+		_, w := p.drawRune(set, x, y, c)
+		x += w + sp
+	}
+	return x
+}
+
+// MeasureRune returns whether the rune has a glyph, and its advance.
+func (p *PixFontV2) MeasureRune(c rune) (bool, int) {
+	return p.drawRune(nil, 0, 0, c)
+}
+
+// MeasureString returns the advance of s in pixels, as for DrawString.
+func (p *PixFontV2) MeasureString(s string) int {
+	x := 0
+	sp := p.Spacing()
+	for _, c := range s {
+		_, w := p.drawRune(nil, 0, 0, c)
+		x += w + sp
+	}
+	return x
+}
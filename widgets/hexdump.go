@@ -0,0 +1,112 @@
+package widgets
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// HexDump is a classic hex dump of Data, laid out like "hexdump -C": the offset
+// of each row, its bytes in hex, and the bytes as ASCII text in a gutter, with
+// a '.' for each unprintable byte. Every character is drawn in a cell as wide as
+// the widest printable ASCII character, so that columns line up even with
+// variable width fonts. Style.Align is ignored.
+type HexDump struct {
+	Style
+	Data []byte
+	// Offset is added to the offsets shown, as when dumping part of a file.
+	Offset int
+	// Width is the number of bytes in each row, 16 if 0.
+	Width int
+	// LineSpacing is the number of pixels between rows.
+	LineSpacing int
+}
+
+func (d *HexDump) width() int {
+	if d.Width <= 0 {
+		return 16
+	}
+	return d.Width
+}
+
+// cell returns the advance of each character, including the spacing.
+func (d *HexDump) cell() int {
+	w := 0
+	for c := rune(' '); c <= '~'; c++ {
+		if _, adv := d.font().MeasureRune(c); adv > w {
+			w = adv
+		}
+	}
+	return w + d.font().Spacing()
+}
+
+// lines returns the text of each row of the dump.
+func (d *HexDump) lines() []string {
+	n := d.width()
+	var lines []string
+	for start := 0; start < len(d.Data); start += n {
+		row := d.Data[start:]
+		if len(row) > n {
+			row = row[:n]
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "%08x  ", d.Offset+start)
+		for i := 0; i < n; i++ {
+			if i < len(row) {
+				fmt.Fprintf(&b, "%02x ", row[i])
+			} else {
+				b.WriteString("   ")
+			}
+			if i%8 == 7 && i != n-1 {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(" |")
+		for _, c := range row {
+			if c < ' ' || c > '~' {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteByte('|')
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// Size implements Widget.
+func (d *HexDump) Size() image.Point {
+	lines := d.lines()
+	if len(lines) == 0 {
+		return d.boxSize(image.Point{})
+	}
+	w := 0
+	for _, line := range lines {
+		if len(line) > w {
+			w = len(line)
+		}
+	}
+	h := len(lines)*(d.font().GetHeight()+d.LineSpacing) - d.LineSpacing
+	return d.boxSize(image.Pt(w*d.cell()-d.font().Spacing(), h))
+}
+
+// Draw implements Widget.
+func (d *HexDump) Draw(dr pixfont.Drawable, x, y int) {
+	sz := d.Size()
+	d.drawBox(dr, image.Rect(x, y, x+sz.X, y+sz.Y))
+	in := d.inset()
+	cell := d.cell()
+	for i, line := range d.lines() {
+		ry := y + in + i*(d.font().GetHeight()+d.LineSpacing)
+		for j, c := range line {
+			if c == ' ' {
+				continue
+			}
+			// center narrow characters in their cells
+			_, adv := d.font().MeasureRune(c)
+			d.font().DrawRune(dr, x+in+j*cell+(cell-d.font().Spacing()-adv)/2, ry, c, d.Color)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package widgets
+
+import (
+	"image"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Table is a box of key/value rows, such as sensor readings. Keys are left
+// aligned in the first column, and values are placed in the second column
+// according to the style's Align.
+type Table struct {
+	Style
+	Rows [][2]string
+	// Gap is the number of pixels between the key and value columns.
+	Gap int
+	// LineSpacing is the number of pixels between rows.
+	LineSpacing int
+}
+
+// columns returns the width of the key and value columns.
+func (t *Table) columns() (int, int) {
+	var kw, vw int
+	for _, row := range t.Rows {
+		if w := t.textWidth(row[0]); w > kw {
+			kw = w
+		}
+		if w := t.textWidth(row[1]); w > vw {
+			vw = w
+		}
+	}
+	return kw, vw
+}
+
+// Size implements Widget.
+func (t *Table) Size() image.Point {
+	kw, vw := t.columns()
+	h := len(t.Rows)*(t.font().GetHeight()+t.LineSpacing) - t.LineSpacing
+	if len(t.Rows) == 0 {
+		h = 0
+	}
+	return t.boxSize(image.Pt(kw+t.Gap+vw, h))
+}
+
+// Draw implements Widget.
+func (t *Table) Draw(dr pixfont.Drawable, x, y int) {
+	sz := t.Size()
+	t.drawBox(dr, image.Rect(x, y, x+sz.X, y+sz.Y))
+	kw, vw := t.columns()
+	in := t.inset()
+	for i, row := range t.Rows {
+		ry := y + in + i*(t.font().GetHeight()+t.LineSpacing)
+		t.font().DrawString(dr, x+in, ry, row[0], t.Color)
+		t.drawLine(dr, x+in+kw+t.Gap, ry, vw, row[1])
+	}
+}
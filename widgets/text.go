@@ -0,0 +1,75 @@
+package widgets
+
+import (
+	"image"
+	"strings"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Label is a single line of text in a box.
+type Label struct {
+	Style
+	Text string
+	// Width is the width of the box content in pixels. If 0, the box fits
+	// the text.
+	Width int
+}
+
+// Size implements Widget.
+func (l *Label) Size() image.Point {
+	w := l.Width
+	if w == 0 {
+		w = l.textWidth(l.Text)
+	}
+	return l.boxSize(image.Pt(w, l.font().GetHeight()))
+}
+
+// Draw implements Widget.
+func (l *Label) Draw(dr pixfont.Drawable, x, y int) {
+	sz := l.Size()
+	l.drawBox(dr, image.Rect(x, y, x+sz.X, y+sz.Y))
+	in := l.inset()
+	l.drawLine(dr, x+in, y+in, sz.X-2*in, l.Text)
+}
+
+// TextBox is multiple lines of text in a box. Lines are separated by newlines
+// in Text.
+type TextBox struct {
+	Style
+	Text string
+	// Width is the width of the box content in pixels. If 0, the box fits
+	// the longest line.
+	Width int
+	// LineSpacing is the number of pixels between lines.
+	LineSpacing int
+}
+
+func (t *TextBox) lines() []string {
+	return strings.Split(t.Text, "\n")
+}
+
+// Size implements Widget.
+func (t *TextBox) Size() image.Point {
+	lines := t.lines()
+	w := t.Width
+	if w == 0 {
+		for _, line := range lines {
+			if lw := t.textWidth(line); lw > w {
+				w = lw
+			}
+		}
+	}
+	h := len(lines)*(t.font().GetHeight()+t.LineSpacing) - t.LineSpacing
+	return t.boxSize(image.Pt(w, h))
+}
+
+// Draw implements Widget.
+func (t *TextBox) Draw(dr pixfont.Drawable, x, y int) {
+	sz := t.Size()
+	t.drawBox(dr, image.Rect(x, y, x+sz.X, y+sz.Y))
+	in := t.inset()
+	for i, line := range t.lines() {
+		t.drawLine(dr, x+in, y+in+i*(t.font().GetHeight()+t.LineSpacing), sz.X-2*in, line)
+	}
+}
@@ -0,0 +1,148 @@
+// Package widgets provides simple composable text widgets, such as labels, text
+// boxes and key/value tables, which draw onto any pixfont.Drawable. They take
+// care of the padding, borders and alignment that dashboards and camera
+// overlays would otherwise lay out by hand:
+//
+//	w := widgets.Column{Widgets: []widgets.Widget{
+//		&widgets.Label{Style: style, Text: "Camera 3"},
+//		&widgets.Table{Style: style, Rows: [][2]string{{"Temp", "21C"}, {"Fan", "on"}}},
+//	}}
+//	w.Draw(img, 4, 4)
+package widgets
+
+import (
+	"image"
+
+	"github.com/pbnjay/pixfont"
+)
+
+// Widget is an element which can be measured and drawn.
+type Widget interface {
+	// Size returns the width and height of the widget in pixels.
+	Size() image.Point
+	// Draw draws the widget with its top-left corner at x,y.
+	Draw(dr pixfont.Drawable, x, y int)
+}
+
+// Align is the horizontal alignment of text within a widget.
+type Align int
+
+const (
+	// Left aligns text to the left edge.
+	Left Align = iota
+	// Center centers text horizontally.
+	Center
+	// Right aligns text to the right edge.
+	Right
+)
+
+// Style describes how a widget draws its text and box. The embedded Label gives
+// the text color and the box background, border, padding and corners, as used
+// by PixFont.DrawLabel.
+type Style struct {
+	// Font is used to draw text. If nil, pixfont.DefaultFont is used.
+	Font *pixfont.PixFont
+	pixfont.Label
+	// Align is the alignment of each line of text within the widget.
+	Align Align
+}
+
+func (s *Style) font() *pixfont.PixFont {
+	if s.Font == nil {
+		return pixfont.DefaultFont
+	}
+	return s.Font
+}
+
+// textWidth returns the width of str drawn in the style's font, without the
+// spacing after the last character.
+func (s *Style) textWidth(str string) int {
+	f := s.font()
+	w := f.MeasureString(str)
+	if w > 0 {
+		w -= f.Spacing()
+	}
+	return w
+}
+
+// inset returns the distance from the edge of the box to its content.
+func (s *Style) inset() int {
+	if s.Border != nil {
+		return s.Padding + 1
+	}
+	return s.Padding
+}
+
+// boxSize returns the size of a box around content of the given size.
+func (s *Style) boxSize(content image.Point) image.Point {
+	in := s.inset()
+	return content.Add(image.Pt(2*in, 2*in))
+}
+
+// drawBox draws the background and border of the box r.
+func (s *Style) drawBox(dr pixfont.Drawable, r image.Rectangle) {
+	corner := func(x, y int) bool {
+		return s.Rounded && (x == r.Min.X || x == r.Max.X-1) && (y == r.Min.Y || y == r.Max.Y-1)
+	}
+	if s.Background != nil {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if !corner(x, y) {
+					dr.Set(x, y, s.Background)
+				}
+			}
+		}
+	}
+	if s.Border != nil {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				edge := x == r.Min.X || x == r.Max.X-1 || y == r.Min.Y || y == r.Max.Y-1
+				if edge && !corner(x, y) {
+					dr.Set(x, y, s.Border)
+				}
+			}
+		}
+	}
+}
+
+// drawLine draws a line of text aligned within width pixels starting at x.
+func (s *Style) drawLine(dr pixfont.Drawable, x, y, width int, line string) {
+	switch s.Align {
+	case Center:
+		x += (width - s.textWidth(line)) / 2
+	case Right:
+		x += width - s.textWidth(line)
+	}
+	s.font().DrawString(dr, x, y, line, s.Color)
+}
+
+// Column stacks widgets vertically, left aligned.
+type Column struct {
+	Widgets []Widget
+	// Gap is the number of pixels between widgets.
+	Gap int
+}
+
+// Size implements Widget.
+func (c *Column) Size() image.Point {
+	var sz image.Point
+	for i, w := range c.Widgets {
+		ws := w.Size()
+		if ws.X > sz.X {
+			sz.X = ws.X
+		}
+		sz.Y += ws.Y
+		if i > 0 {
+			sz.Y += c.Gap
+		}
+	}
+	return sz
+}
+
+// Draw implements Widget.
+func (c *Column) Draw(dr pixfont.Drawable, x, y int) {
+	for _, w := range c.Widgets {
+		w.Draw(dr, x, y)
+		y += w.Size().Y + c.Gap
+	}
+}
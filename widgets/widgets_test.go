@@ -0,0 +1,211 @@
+package widgets
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/pbnjay/pixfont"
+	"github.com/pbnjay/pixfont/fonts/pico"
+)
+
+var (
+	black = color.Gray{}
+	gray  = color.Gray{0x40}
+	white = color.Gray{0xff}
+)
+
+// render draws w onto an image of its size, and returns its rows with '#' for
+// the text color, 'o' for the border, '.' for the background and ' ' for
+// pixels which were not drawn.
+func render(w Widget) string {
+	sz := w.Size()
+	img := image.NewGray(image.Rect(0, 0, sz.X, sz.Y))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	w.Draw(img, 0, 0)
+	var rows []string
+	for y := 0; y < sz.Y; y++ {
+		var b strings.Builder
+		for x := 0; x < sz.X; x++ {
+			switch img.GrayAt(x, y) {
+			case black:
+				b.WriteByte('#')
+			case gray:
+				b.WriteByte('o')
+			case white:
+				b.WriteByte('.')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		rows = append(rows, b.String())
+	}
+	return strings.Join(rows, "\n")
+}
+
+// testStyle draws black text in the pico font, whose 3x5 glyphs keep the
+// expected pictures small.
+func testStyle() Style {
+	return Style{Font: pico.Font3x5, Label: pixfont.Label{Color: black}}
+}
+
+func TestLabel(t *testing.T) {
+	st := testStyle()
+	st.Background, st.Border, st.Padding = white, gray, 1
+	l := &Label{Style: st, Text: "IT"}
+	if sz := l.Size(); sz != image.Pt(11, 9) {
+		t.Errorf("label size is %v, want 11x9", sz)
+	}
+	want := strings.Join([]string{
+		"ooooooooooo",
+		"o.........o",
+		"o.###.###.o",
+		"o..#...#..o",
+		"o..#...#..o",
+		"o..#...#..o",
+		"o.###..#..o",
+		"o.........o",
+		"ooooooooooo",
+	}, "\n")
+	if got := render(l); got != want {
+		t.Errorf("label is\n%s\nwant\n%s", got, want)
+	}
+
+	l.Rounded = true
+	if got := render(l); !strings.HasPrefix(got, " ooooooooo \n") || !strings.HasSuffix(got, "\n ooooooooo ") {
+		t.Errorf("rounded label is\n%s", got)
+	}
+
+	st = testStyle()
+	for align, want := range map[Align]string{
+		Left:   "###    \n #     \n #     \n #     \n###    ",
+		Center: "  ###  \n   #   \n   #   \n   #   \n  ###  ",
+		Right:  "    ###\n     # \n     # \n     # \n    ###",
+	} {
+		st.Align = align
+		if got := render(&Label{Style: st, Text: "I", Width: 7}); got != want {
+			t.Errorf("label aligned %d is\n%s\nwant\n%s", align, got, want)
+		}
+	}
+
+	l = &Label{Text: "I"}
+	if h := l.Size().Y; h != pixfont.DefaultFont.GetHeight() {
+		t.Errorf("label without a font is %d high, want the default font height", h)
+	}
+}
+
+func TestTextBox(t *testing.T) {
+	st := testStyle()
+	st.Align = Center
+	tb := &TextBox{Style: st, Text: "I\nTT", LineSpacing: 1}
+	want := strings.Join([]string{
+		"  ###  ",
+		"   #   ",
+		"   #   ",
+		"   #   ",
+		"  ###  ",
+		"       ",
+		"### ###",
+		" #   # ",
+		" #   # ",
+		" #   # ",
+		" #   # ",
+	}, "\n")
+	if got := render(tb); got != want {
+		t.Errorf("text box is\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTable(t *testing.T) {
+	st := testStyle()
+	st.Align = Right
+	tb := &Table{Style: st, Rows: [][2]string{{"I", "TT"}, {"TT", "I"}}, Gap: 2}
+	want := strings.Join([]string{
+		"###      ### ###",
+		" #        #   # ",
+		" #        #   # ",
+		" #        #   # ",
+		"###       #   # ",
+		"### ###      ###",
+		" #   #        # ",
+		" #   #        # ",
+		" #   #        # ",
+		" #   #       ###",
+	}, "\n")
+	if got := render(tb); got != want {
+		t.Errorf("table is\n%s\nwant\n%s", got, want)
+	}
+	if sz := (&Table{Style: st}).Size(); sz != (image.Point{}) {
+		t.Errorf("empty table size is %v", sz)
+	}
+}
+
+func TestColumn(t *testing.T) {
+	st := testStyle()
+	c := &Column{Widgets: []Widget{
+		&Label{Style: st, Text: "T"},
+		&Label{Style: st, Text: "II"},
+	}, Gap: 1}
+	if sz := c.Size(); sz != image.Pt(7, 11) {
+		t.Errorf("column size is %v, want 7x11", sz)
+	}
+	want := strings.Join([]string{
+		"###    ",
+		" #     ",
+		" #     ",
+		" #     ",
+		" #     ",
+		"       ",
+		"### ###",
+		" #   # ",
+		" #   # ",
+		" #   # ",
+		"### ###",
+	}, "\n")
+	if got := render(c); got != want {
+		t.Errorf("column is\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHexDump(t *testing.T) {
+	d := &HexDump{Data: []byte("AB\x01CDE"), Offset: 0x10, Width: 4}
+	want := []string{
+		"00000010  41 42 01 43  |AB.C|",
+		"00000014  44 45        |DE|",
+	}
+	if got := d.lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 16 bytes by default, in two groups like hexdump -C
+	d = &HexDump{Data: []byte("0123456789:;<=>?\x7f")}
+	want = []string{
+		"00000000  30 31 32 33 34 35 36 37  38 39 3a 3b 3c 3d 3e 3f  |0123456789:;<=>?|",
+		"00000010  7f                                                |.|",
+	}
+	if got := d.lines(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// every character takes a cell as wide as the widest, here 3 pixels plus
+	// the spacing
+	d = &HexDump{Style: testStyle(), Data: []byte("IT"), Width: 2, LineSpacing: 1}
+	line := "00000000  49 54  |IT|"
+	if sz := d.Size(); sz != image.Pt(len(line)*4-1, 5) {
+		t.Errorf("size is %v, want %dx5", sz, len(line)*4-1)
+	}
+	var text []string
+	for _, row := range strings.Split(render(d), "\n") {
+		text = append(text, row[18*4:20*4-1])
+	}
+	if got, want := strings.Join(text, "|"), "### ###| #   # | #   # | #   # |###  # "; got != want {
+		t.Errorf("ASCII gutter is %q, want %q", got, want)
+	}
+	d.Data = nil
+	if sz := d.Size(); sz != (image.Point{}) {
+		t.Errorf("empty dump size is %v", sz)
+	}
+}
@@ -0,0 +1,119 @@
+package pixfont
+
+import (
+	"image/color"
+	"strings"
+)
+
+// DrawStringWrapped draws s like DrawString, breaking it into lines no wider than
+// maxWidth pixels. Lines are broken at spaces where possible, then at soft
+// hyphens (U+00AD), which are drawn as hyphens only where a line breaks, and
+// within words that are too long to fit on a line by themselves. No-break
+// spaces (U+00A0) join words which are never broken apart. Newlines in s always start
+// a new line. Each line is drawn the font height below the previous one, and
+// DrawStringWrapped returns the total height of the lines drawn.
+func (p *PixFont) DrawStringWrapped(dr Drawable, x, y int, s string, maxWidth int, clr color.Color) int {
+	sp := p.Spacing()
+	lines := p.wrap(s, maxWidth, sp)
+	for i, line := range lines {
+		p.drawString(dr, x, y+i*int(p.charHeight), line, clr, sp)
+	}
+	return len(lines) * int(p.charHeight)
+}
+
+// MeasureStringWrapped returns the lines that DrawStringWrapped would draw for s
+// within maxWidth pixels, along with the width of the widest line and the total
+// height of the lines, so that a canvas can be sized before drawing. Like the
+// limit, the width doesn't include the spacing after the last character of a
+// line.
+func (p *PixFont) MeasureStringWrapped(s string, maxWidth int) (lines []string, w, h int) {
+	sp := p.Spacing()
+	lines = p.wrap(s, maxWidth, sp)
+	for _, line := range lines {
+		if lw := p.lineWidth(line, sp); lw > w {
+			w = lw
+		}
+	}
+	return lines, w, len(lines) * int(p.charHeight)
+}
+
+const (
+	// softHyphen marks where a word may be hyphenated. It is invisible unless
+	// wrapping breaks a line there, where a hyphen is drawn instead.
+	softHyphen = '\u00ad'
+	// noBreakSpace is a space which wrapping never breaks a line at.
+	noBreakSpace = '\u00a0'
+)
+
+// wrap splits s into lines that fit within maxWidth pixels with sp pixels
+// between characters, not counting the spacing after the last character of a
+// line. It measures without the cache, to avoid filling it with partial lines.
+func (p *PixFont) wrap(s string, maxWidth, sp int) []string {
+	var lines []string
+	emit := func(line string) {
+		lines = append(lines, strings.Replace(line, string(softHyphen), "", -1))
+	}
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Split(para, " ") {
+			for {
+				candidate := word
+				if line != "" {
+					candidate = line + " " + word
+				}
+				if p.lineWidth(candidate, sp) <= maxWidth {
+					line = candidate
+					break
+				}
+				if head, tail, ok := p.hyphenate(line, word, maxWidth, sp); ok {
+					emit(head)
+					line, word = "", tail
+					continue
+				}
+				if line != "" {
+					emit(line)
+					line = ""
+					continue
+				}
+
+				// break words that are too long for a line of their own
+				for _, c := range word {
+					if line != "" && p.lineWidth(line+string(c), sp) > maxWidth {
+						emit(line)
+						line = ""
+					}
+					line += string(c)
+				}
+				break
+			}
+		}
+		emit(line)
+	}
+	return lines
+}
+
+// lineWidth returns the width of s measured without the cache, without the
+// spacing after its last character.
+func (p *PixFont) lineWidth(s string, sp int) int {
+	w := p.measure(s, sp)
+	if w > 0 {
+		w -= sp
+	}
+	return w
+}
+
+// hyphenate breaks word at the last of its soft hyphens where the start of the
+// word, after line and a space, fits within maxWidth pixels with a hyphen. It
+// returns the hyphenated line and the rest of the word.
+func (p *PixFont) hyphenate(line, word string, maxWidth, sp int) (head, tail string, ok bool) {
+	if line != "" {
+		line += " "
+	}
+	for i := strings.LastIndex(word, string(softHyphen)); i > 0; i = strings.LastIndex(word[:i], string(softHyphen)) {
+		head = line + word[:i] + "-"
+		if p.lineWidth(head, sp) <= maxWidth {
+			return head, word[i+len(string(softHyphen)):], true
+		}
+	}
+	return "", "", false
+}